@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"flow-frame/pkg/api"
+	"flow-frame/pkg/config"
+	"flow-frame/pkg/video"
+	"flow-frame/pkg/videoFs"
+)
+
+// subcommand is a headless mode of the binary; none of them open a window
+type subcommand struct {
+	usage string
+	help  string
+	run   func(args []string) int
+}
+
+// subcommands is filled in init because the handlers refer back to it for their usage
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"probe":   {"probe [-json] <file>", "show container, streams and the decoder the player would select", runProbe},
+		"analyze": {"analyze [-json] <file>", "recommend a better codec or encoding for this device", runAnalyze},
+		"doctor":  {"doctor", "check drivers, devices, fonts, networking, disk and memory", runDoctor},
+		"sync":    {"sync <collection>", "download an S3 collection into the offline cache", runSync},
+		"ctl":     {"ctl [-addr host:port] [-token t] <command>", "control a running instance through its API", runCtl},
+		"update":  {"update [-check] [-now] [-channel c] [-manifest url] [-link path]", "install the latest signed release into the inactive slot", runUpdate},
+		"sign":    {"sign -key file -version v -url u <binary>", "print a signed release manifest (-genkey file creates a key)", runSign},
+		"version": {"version [-json]", "show the build version, device and available decoders", runVersion},
+	}
+}
+
+// runSubcommand runs a headless subcommand and returns the process exit code
+func runSubcommand(name string, args []string) int {
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "flow-frame: unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	// Subcommands print their results; library logging would only add noise
+	if os.Getenv("DEBUG") == "" {
+		log.SetOutput(io.Discard)
+	}
+	godotenv.Load()
+	cfg, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 2
+	}
+	cfg.Export()
+	return cmd.run(args)
+}
+
+// printUsage lists the subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: flow-frame [command]")
+	fmt.Fprintln(w, "Without a command the frame starts in full screen.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, name := range []string{"probe", "analyze", "doctor", "sync", "ctl", "update", "sign", "version"} {
+		cmd := subcommands[name]
+		fmt.Fprintf(w, "  %-45s %s\n", cmd.usage, cmd.help)
+	}
+	fmt.Fprintln(w, "\nSet DEBUG=1 to show log output.")
+}
+
+// newFlagSet creates a flag set whose usage line matches the subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: flow-frame %s\n", subcommands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// printJSON writes v as indented JSON
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// openCodecInfo opens path with the player's decoder selection and returns what it chose
+func openCodecInfo(path string) (video.CodecInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return video.CodecInfo{}, err
+	}
+	player, err := video.NewPlayer(file)
+	if err != nil {
+		file.Close()
+		return video.CodecInfo{}, err
+	}
+	defer player.Close()
+	return player.GetCodecInfo(), nil
+}
+
+// probeOutput is the -json output of probe
+type probeOutput struct {
+	Media   videoFs.MediaInfo `json:"media"`
+	Decoder *video.CodecInfo  `json:"decoder,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+func runProbe(args []string) int {
+	fs := newFlagSet("probe")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if fs.Parse(args) != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+
+	info, err := videoFs.Probe(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	out := probeOutput{Media: info}
+	if info.Playable {
+		codec, err := openCodecInfo(path)
+		if err != nil {
+			out.Error = err.Error()
+		} else {
+			out.Decoder = &codec
+		}
+	}
+	if *asJSON {
+		return printJSON(out)
+	}
+
+	fmt.Printf("File:       %s\n", path)
+	fmt.Printf("Container:  %s\n", info.Container)
+	fmt.Printf("Video:      %s %dx%d @ %.2ffps, %d-bit", info.VideoCodec, info.Width, info.Height, info.FrameRate, info.BitDepth)
+	if info.IsHDR() {
+		fmt.Print(", HDR")
+	}
+	if info.Rotation != 0 {
+		fmt.Printf(", rotated %d°", info.Rotation)
+	}
+	fmt.Println()
+	if info.AudioCodec != "" {
+		fmt.Printf("Audio:      %s (not played)\n", info.AudioCodec)
+	}
+	fmt.Printf("Duration:   %s\n", info.Duration.Round(time.Millisecond))
+	if !info.Playable {
+		fmt.Printf("Playable:   no (%s)\n", info.Reason)
+		return 1
+	}
+	fmt.Println("Playable:   yes")
+	if out.Decoder == nil {
+		fmt.Printf("Decoder:    failed to open (%s)\n", out.Error)
+		return 1
+	}
+	accel := "software"
+	if out.Decoder.IsHardwareAccel {
+		accel = "hardware"
+	}
+	fmt.Printf("Decoder:    %s (%s), %s\n", out.Decoder.Name, out.Decoder.LongName, accel)
+	return 0
+}
+
+func runAnalyze(args []string) int {
+	fs := newFlagSet("analyze")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if fs.Parse(args) != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	codec, err := openCodecInfo(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	rec := video.AnalyzeCodec(codec)
+	if *asJSON {
+		return printJSON(rec)
+	}
+
+	fmt.Printf("Codec:       %s (%s), %dx%d @ %.1ffps, hardware=%v\n",
+		rec.CurrentCodec, rec.CurrentType, codec.Width, codec.Height, codec.FPS, rec.IsHardwareAccel)
+	if rec.IsOptimal {
+		fmt.Printf("Optimal:     yes\n")
+		fmt.Printf("Reason:      %s\n", rec.Reason)
+		return 0
+	}
+	fmt.Printf("Optimal:     no\n")
+	fmt.Printf("Reason:      %s\n", rec.Reason)
+	fmt.Printf("Recommended: %s (%s)\n", rec.RecommendedCodec, rec.RecommendedType)
+	if rec.ExpectedImprovement != "" {
+		fmt.Printf("Expected:    %s\n", rec.ExpectedImprovement)
+	}
+	if rec.ReencodingCommand != "" {
+		fmt.Printf("Re-encode:   %s\n", rec.ReencodingCommand)
+	}
+	return 0
+}
+
+func runSync(args []string) int {
+	fs := newFlagSet("sync")
+	if fs.Parse(args) != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	collections := videoFs.BuiltinCollections()
+	name := fs.Arg(0)
+	idx := -1
+	for i, c := range collections {
+		if c.Id == name || strings.EqualFold(c.Title, name) || c.Folder == name {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		fmt.Fprintf(os.Stderr, "flow-frame: unknown collection %q; available:\n", name)
+		for _, c := range collections {
+			fmt.Fprintf(os.Stderr, "  %-4s %s\n", c.Id, c.Title)
+		}
+		return 2
+	}
+
+	// Ctrl-C keeps what has been synced so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collection := collections[idx]
+	fmt.Printf("Syncing %s into %s\n", collection.Title, videoFs.CacheDir(collection))
+	result, err := videoFs.SyncCollection(ctx, collection, func(i, count int, key string) {
+		fmt.Printf("[%d/%d] %s\n", i+1, count, key)
+	})
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "flow-frame: sync interrupted after %d download(s)\n", result.Downloaded)
+		return 130
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: sync failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Downloaded %d (%.1f MB), %d up to date, %d removed\n",
+		result.Downloaded, float64(result.Bytes)/(1<<20), result.UpToDate, result.Removed)
+	for _, key := range result.Rejected {
+		fmt.Printf("Skipped unplayable %s\n", key)
+	}
+	for _, key := range result.Failed {
+		fmt.Printf("Failed %s\n", key)
+	}
+	if len(result.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runCtl(args []string) int {
+	fs := newFlagSet("ctl")
+	addr := fs.String("addr", os.Getenv(api.EnvAddr), "API address of the running frame")
+	token := fs.String("token", os.Getenv(api.EnvToken), "API token")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: flow-frame %s\n", subcommands["ctl"].usage)
+		fmt.Fprintln(fs.Output(), "\nCommands:")
+		fmt.Fprintln(fs.Output(), "  status | collections | skip | pause | resume")
+		fmt.Fprintln(fs.Output(), "  power on|off")
+		fmt.Fprintln(fs.Output(), "  collection <id>")
+		fmt.Fprintln(fs.Output(), "  set speed=<x> interval=<1h|end|3 loops> brightness=<0-1>")
+		fmt.Fprintln(fs.Output(), "  logs [n=<count>] [level=debug|info|warn|error]")
+		fmt.Fprintln(fs.Output(), "  diagnostics > bundle.tar.gz")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+	if fs.Parse(args) != nil || fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *addr == "" || *token == "" {
+		fmt.Fprintf(os.Stderr, "flow-frame: set -addr and -token or %s and %s\n", api.EnvAddr, api.EnvToken)
+		return 2
+	}
+
+	method, path, body, err := ctlRequest(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		fs.Usage()
+		return 2
+	}
+
+	host := *addr
+	if strings.HasPrefix(host, ":") {
+		host = "127.0.0.1" + host
+	}
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://"+host+path, reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+	req.Header.Set("Content-Type", "application/json")
+
+	timeout := 10 * time.Second
+	if fs.Arg(0) == "diagnostics" {
+		timeout = time.Minute // runs nmcli and reads all log files
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, data, "", "  ") == nil {
+		data = append(pretty.Bytes(), '\n')
+	}
+	if resp.StatusCode >= 300 {
+		os.Stderr.Write(data)
+		return 1
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// ctlRequest maps ctl arguments to an API request
+func ctlRequest(args []string) (method, path string, body any, err error) {
+	switch args[0] {
+	case "status", "collections", "diagnostics":
+		return http.MethodGet, "/api/v1/" + args[0], nil, nil
+	case "skip", "pause", "resume":
+		return http.MethodPost, "/api/v1/" + args[0], nil, nil
+	case "power":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return "", "", nil, fmt.Errorf("usage: power on|off")
+		}
+		return http.MethodPost, "/api/v1/power", map[string]bool{"on": args[1] == "on"}, nil
+	case "collection":
+		if len(args) != 2 {
+			return "", "", nil, fmt.Errorf("usage: collection <id>")
+		}
+		return http.MethodPost, "/api/v1/collection", api.SelectCollectionRequest{ID: args[1]}, nil
+	case "set":
+		patch := map[string]any{}
+		for _, kv := range args[1:] {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok {
+				return "", "", nil, fmt.Errorf("expected key=value, got %q", kv)
+			}
+			switch key {
+			case "speed", "brightness":
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return "", "", nil, fmt.Errorf("invalid %s %q", key, value)
+				}
+				patch[key] = v
+			case "interval":
+				patch[key] = value
+			default:
+				return "", "", nil, fmt.Errorf("unknown setting %q", key)
+			}
+		}
+		if len(patch) == 0 {
+			return "", "", nil, fmt.Errorf("usage: set key=value...")
+		}
+		return http.MethodPatch, "/api/v1/settings", patch, nil
+	case "logs":
+		query := url.Values{}
+		for _, kv := range args[1:] {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok || (key != "n" && key != "level") {
+				return "", "", nil, fmt.Errorf("usage: logs [n=<count>] [level=<level>]")
+			}
+			query.Set(key, value)
+		}
+		path := "/api/v1/logs"
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+		return http.MethodGet, path, nil, nil
+	}
+	return "", "", nil, fmt.Errorf("unknown command %q", args[0])
+}
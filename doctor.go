@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/performance"
+	"flow-frame/ui"
+)
+
+// checkStatus is the outcome of a doctor check
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkOK:
+		return " OK "
+	case checkWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// checkResult is a single line of the doctor report
+type checkResult struct {
+	name   string
+	status checkStatus
+	detail string
+}
+
+// runDoctor checks the environment the frame needs and exits non-zero if anything is broken
+func runDoctor(args []string) int {
+	fs := newFlagSet("doctor")
+	if fs.Parse(args) != nil || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	code := 0
+	for _, r := range runChecks() {
+		fmt.Printf("[%s] %-16s %s\n", r.status, r.name, r.detail)
+		if r.status == checkFail {
+			code = 1
+		}
+	}
+	return code
+}
+
+// runChecks runs every environment check in report order
+func runChecks() []checkResult {
+	checks := []func() checkResult{
+		checkSDLDrivers,
+		checkDRI,
+		checkFonts,
+		checkNetworkManager,
+		checkDisk,
+		checkMemory,
+		checkThermal,
+		checkAWS,
+	}
+
+	results := make([]checkResult, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, check())
+	}
+	return results
+}
+
+// diagnosticLines formats the environment checks for the safe mode screen
+func diagnosticLines() []string {
+	var lines []string
+	for _, r := range runChecks() {
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", strings.TrimSpace(r.status.String()), r.name, r.detail))
+	}
+	return lines
+}
+
+// checkSDLDrivers lists the video drivers SDL was built with and whether the configured one is among them
+func checkSDLDrivers() checkResult {
+	var drivers []string
+	n, _ := sdl.GetNumVideoDrivers()
+	for i := 0; i < n; i++ {
+		drivers = append(drivers, sdl.GetVideoDriver(i))
+	}
+	r := checkResult{name: "SDL drivers", detail: strings.Join(drivers, ", ")}
+	if len(drivers) == 0 {
+		r.status, r.detail = checkFail, "SDL has no video drivers"
+		return r
+	}
+
+	want := os.Getenv("SDL_VIDEODRIVER")
+	if want == "" && runtime.GOOS == "linux" {
+		want = "kmsdrm"
+	}
+	if want != "" && !slices.Contains(drivers, want) {
+		r.status = checkWarn
+		r.detail = fmt.Sprintf("%s not available (have %s)", want, r.detail)
+	}
+	return r
+}
+
+// checkDRI verifies the DRM devices exist and can be opened by this user
+func checkDRI() checkResult {
+	r := checkResult{name: "/dev/dri"}
+	if runtime.GOOS != "linux" {
+		r.detail = "not applicable on " + runtime.GOOS
+		return r
+	}
+
+	entries, err := os.ReadDir("/dev/dri")
+	if err != nil {
+		r.status, r.detail = checkFail, fmt.Sprintf("%v (kmsdrm needs the DRM driver)", err)
+		return r
+	}
+	var usable, denied []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "card") && !strings.HasPrefix(e.Name(), "renderD") {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join("/dev/dri", e.Name()), os.O_RDWR, 0)
+		if err != nil {
+			denied = append(denied, e.Name())
+			continue
+		}
+		f.Close()
+		usable = append(usable, e.Name())
+	}
+
+	switch {
+	case len(usable) == 0 && len(denied) == 0:
+		r.status, r.detail = checkFail, "no card or render nodes"
+	case len(usable) == 0:
+		r.status, r.detail = checkFail, fmt.Sprintf("permission denied on %s (add the user to the video and render groups)", strings.Join(denied, ", "))
+	case len(denied) > 0:
+		r.status, r.detail = checkWarn, fmt.Sprintf("usable: %s; permission denied: %s", strings.Join(usable, ", "), strings.Join(denied, ", "))
+	default:
+		r.detail = strings.Join(usable, ", ")
+	}
+	return r
+}
+
+// checkFonts looks for one of the fonts the UI loads
+func checkFonts() checkResult {
+	for _, path := range ui.FontPaths {
+		if _, err := os.Stat(path); err == nil {
+			return checkResult{name: "Fonts", detail: path}
+		}
+	}
+	return checkResult{name: "Fonts", status: checkFail, detail: "none of the UI fonts are installed (install fonts-dejavu-core)"}
+}
+
+// checkNetworkManager verifies nmcli is installed and NetworkManager is running
+func checkNetworkManager() checkResult {
+	r := checkResult{name: "NetworkManager"}
+	path, err := exec.LookPath("nmcli")
+	if err != nil {
+		r.status, r.detail = checkWarn, "nmcli not found; Wi-Fi setup and the captive portal will not work"
+		return r
+	}
+
+	out, err := exec.Command(path, "-t", "-f", "RUNNING,STATE,CONNECTIVITY", "general").Output()
+	if err != nil {
+		r.status, r.detail = checkWarn, fmt.Sprintf("nmcli failed: %v", err)
+		return r
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ":")
+	r.detail = strings.Join(fields, ", ")
+	if len(fields) > 0 && fields[0] != "running" {
+		r.status = checkWarn
+	}
+	return r
+}
+
+// checkDisk reports free space where videos are downloaded and state is stored
+func checkDisk() checkResult {
+	r := checkResult{name: "Disk"}
+	var parts []string
+	for _, dir := range []string{"assets", appdata.Dir()} {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(dir, &st); err != nil {
+			parts = append(parts, fmt.Sprintf("%s: %v", dir, err))
+			r.status = max(r.status, checkWarn)
+			continue
+		}
+		freeMB := st.Bavail * uint64(st.Bsize) >> 20
+		parts = append(parts, fmt.Sprintf("%s: %d MB free", dir, freeMB))
+		switch {
+		case freeMB < 100:
+			r.status = checkFail
+		case freeMB < 500:
+			r.status = max(r.status, checkWarn)
+		}
+	}
+	r.detail = strings.Join(parts, "; ")
+	return r
+}
+
+// checkMemory reports system memory and pressure
+func checkMemory() checkResult {
+	mem := performance.GetSystemMemory()
+	pressure := performance.GetMemoryPressure()
+	r := checkResult{
+		name:   "Memory",
+		detail: fmt.Sprintf("%d MB available of %d MB, pressure %s", mem.AvailableMB, mem.TotalMB, pressure),
+	}
+	if mem.CgroupLimitMB > 0 {
+		r.detail += fmt.Sprintf(", cgroup limit %d MB", mem.CgroupLimitMB)
+	}
+	if stall, ok := performance.GetMemoryStall(); ok {
+		r.detail += fmt.Sprintf(", stalled %.1f%% (some) %.1f%% (full)", stall.SomeAvg10, stall.FullAvg10)
+	}
+	switch {
+	case pressure >= performance.MemoryPressureCritical:
+		r.status = checkFail
+	case pressure >= performance.MemoryPressureHigh:
+		r.status = checkWarn
+	}
+	return r
+}
+
+// checkThermal reports CPU temperature and throttling
+func checkThermal() checkResult {
+	snapshot := performance.ReadThermal()
+	r := checkResult{name: "Thermal"}
+	if len(snapshot.Zones) == 0 {
+		r.detail = "no thermal zones found"
+		return r
+	}
+	parts := make([]string, 0, len(snapshot.Zones)+1)
+	for _, zone := range snapshot.Zones {
+		parts = append(parts, fmt.Sprintf("%s %.1f°C", zone.Name, zone.TempC))
+	}
+	for _, cpu := range snapshot.CPUs {
+		parts = append(parts, fmt.Sprintf("%s %.0f/%.0f MHz", cpu.Policy, cpu.CurMHz, cpu.HwMaxMHz))
+	}
+	r.detail = strings.Join(parts, ", ")
+	switch snapshot.Level() {
+	case performance.ThermalThrottled:
+		r.status = checkWarn
+		r.detail += "; throttled"
+	case performance.ThermalWarm:
+		r.status = checkWarn
+		r.detail += "; near thermal limit"
+	}
+	return r
+}
+
+// checkAWS verifies credentials for S3 collections are configured
+func checkAWS() checkResult {
+	var missing []string
+	for _, key := range []string{"AWS_DEFAULT_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+		if os.Getenv(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return checkResult{name: "S3 credentials", status: checkWarn, detail: "missing " + strings.Join(missing, ", ") + "; only local and cached collections will play"}
+	}
+	return checkResult{name: "S3 credentials", detail: "configured"}
+}
@@ -1,33 +1,68 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
+	"strings"
+	"syscall"
 	"time"
 	"unsafe"
 
 	"github.com/joho/godotenv"
 	"github.com/veandco/go-sdl2/sdl"
 
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/config"
+	"flow-frame/pkg/diagnostics"
+	"flow-frame/pkg/logging"
+	"flow-frame/pkg/metrics"
+	"flow-frame/pkg/performance"
+	"flow-frame/pkg/safemode"
+	"flow-frame/pkg/sdnotify"
+	"flow-frame/pkg/sysinfo"
+	"flow-frame/pkg/updater"
 	"flow-frame/screens/root"
+	"flow-frame/screens/safeMode"
+	"flow-frame/widgets/settings"
 )
 
 const (
 	targetFPS      = 60
 	fallbackWidth  = 1920
 	fallbackHeight = 1080
+
+	// shutdownTimeout bounds cleanup after SIGTERM/SIGINT; systemd kills us after TimeoutStopSec=10s
+	shutdownTimeout = 8 * time.Second
+	// serviceStatusInterval is how often the systemd status line is refreshed
+	serviceStatusInterval = time.Second
 )
 
+// screen is what the game loop drives: the normal root screen or the safe mode screen
+type screen interface {
+	Update() error
+	Draw() error
+	StatusText() string
+	Reload()
+	Close()
+}
+
 func main() {
+	// Headless subcommands never open a window
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
+	}
+
 	// CRITICAL: Lock OS thread immediately before any other operations
 	runtime.LockOSThread()
 
-	// Configure ARM64-specific memory management and CGO environment
-	setupARMMemoryManagement()
-
 	// Configure logging
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
@@ -36,14 +71,60 @@ func main() {
 		log.Printf("Warning: .env file not found: %v", err)
 	}
 
-	// Get window title
-	windowTitle := os.Getenv("GAME_TITLE")
-	if windowTitle == "" {
-		windowTitle = "Art Frame"
+	// Catch signals before the slow startup so an early SIGTERM still shuts down cleanly
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	// Resolve flags, environment and config file before anything reads them
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		cfg.Print(os.Stdout)
+		return
+	}
+	cfg.Export()
+	closeLog := setupLogging(cfg)
+	defer closeLog()
+	diagnostics.SetConfig(cfg)
+	performance.SetSysfsRoot(cfg.String("sysfs-root"))
+	log.Printf("flow-frame %s on %s", buildinfo.Get(), sysinfo.Model())
+	if cfg.File != "" {
+		log.Printf("Loaded config file %s", cfg.File)
+	}
+
+	// A new version that keeps failing to start is replaced by the previous one
+	rollbackIfUnhealthy()
+
+	if addr := cfg.String("metrics-addr"); addr != "" {
+		if err := metrics.Serve(addr); err != nil {
+			log.Printf("Warning: metrics endpoint disabled: %v", err)
+		} else {
+			log.Printf("Serving metrics on %s/metrics", addr)
+		}
+	}
+
+	// Configure ARM64-specific memory management and CGO environment
+	setupARMMemoryManagement(cfg)
+
+	// Count this start before anything that can crash; too many failed starts in a row mean safe mode
+	threshold, _ := cfg.Int("safe-mode-after")
+	safe, entered := safemode.Begin(threshold)
+	if entered {
+		if _, err := settings.Reset(); err != nil {
+			log.Printf("Warning: Failed to reset settings: %v", err)
+		}
 	}
 
+	windowTitle := cfg.String("title")
+
 	// Initialize SDL2 with fallback options
-	if err := initializeSDL2(); err != nil {
+	if err := initializeSDL2(cfg.String("video-driver")); err != nil {
 		log.Fatalf("Failed to initialize SDL2: %v", err)
 	}
 	defer func() {
@@ -74,32 +155,65 @@ func main() {
 	defer renderer.Destroy()
 
 	// Create and initialize the screen
-	screen := root.NewRootScreen(window, renderer)
-	defer screen.Close()
+	var game screen
+	if safe {
+		log.Printf("Starting in safe mode after %d failed starts", safemode.FailedStarts())
+		game = safeMode.NewScreen(window, renderer, diagnosticLines())
+	} else {
+		game = root.NewRootScreen(window, renderer)
+	}
+	defer game.Close()
 
-	// Run the main game loop
-	runGameLoop(screen)
+	// Run the main game loop; systemd is told we are ready once the first frame is on screen
+	notifier := sdnotify.New()
+	if runGameLoop(game, signals, cfg, notifier) && !safe {
+		safemode.Stable()
+	}
+	notifier.Notify(sdnotify.StateStopping)
 
 	log.Println("Art Frame shutting down...")
 }
 
-// setupARMMemoryManagement configures ARM64-specific memory settings and CGO environment
-func setupARMMemoryManagement() {
+// rollbackIfUnhealthy counts a start of a freshly installed version and, once
+// it has used up its trial starts, execs the previous version in its place
+func rollbackIfUnhealthy() {
+	previous, err := updater.NewSlots().Boot()
+	if err != nil {
+		log.Printf("Warning: Failed to record update trial: %v", err)
+		return
+	}
+	if previous == "" {
+		return
+	}
+	log.Printf("Rolling back to %s", previous)
+	if err := syscall.Exec(previous, os.Args, os.Environ()); err != nil {
+		log.Fatalf("Failed to start previous version: %v", err)
+	}
+}
+
+// confirmUpdate marks a freshly installed version healthy once its first frame
+// is on screen. Reaching safe mode does not count as healthy.
+func confirmUpdate() {
+	if safemode.Active() {
+		return
+	}
+	if err := updater.NewSlots().ConfirmHealthy(); err != nil {
+		log.Printf("Warning: Failed to confirm update: %v", err)
+	}
+}
+
+// setupARMMemoryManagement applies the configured GC, memory limit and CPU settings and the CGO environment
+func setupARMMemoryManagement(cfg *config.Config) {
 	log.Printf("Configuring ARM64 memory management...")
 
 	// Set ARM64 specific environment variables early
 	os.Setenv("GODEBUG", "madvdontneed=1") // Removed gctrace=1 to stop GC log spam
-	os.Setenv("GOMAXPROCS", "1")
-	os.Setenv("GOGC", "25")
-	os.Setenv("GOMEMLIMIT", "256MiB")
 
 	// Set CGO environment variables for safer memory management
 	os.Setenv("CGO_CFLAGS", "-O1 -g -fPIC")
 	os.Setenv("CGO_LDFLAGS", "-Wl,--no-as-needed -fPIC")
 
-	// Force minimal memory usage pattern with reasonable limits
-	debug.SetGCPercent(25)          // Aggressive but not too aggressive GC
-	debug.SetMemoryLimit(256 << 20) // 256MB limit - reasonable for Pi 5
+	applyRuntimeConfig(cfg)
 
 	// Multiple GC cycles to establish stable memory pattern
 	for i := 0; i < 3; i++ {
@@ -107,11 +221,52 @@ func setupARMMemoryManagement() {
 		time.Sleep(100 * time.Millisecond)
 	}
 
-	log.Printf("ARM64 memory management configured: GOGC=25, GOMEMLIMIT=256MiB, GOMAXPROCS=1")
+	log.Printf("ARM64 memory management configured: GOGC=%s, GOMEMLIMIT=%s, GOMAXPROCS=%d",
+		cfg.String("gogc"), cfg.String("gomemlimit"), runtime.GOMAXPROCS(0))
+}
+
+// applyRuntimeConfig applies GOGC, GOMEMLIMIT and GOMAXPROCS. The runtime only
+// reads them from the environment at startup, so they are set directly.
+func applyRuntimeConfig(cfg *config.Config) {
+	if gogc, ok := cfg.Int("gogc"); ok {
+		debug.SetGCPercent(gogc) // defaults to 25: aggressive but not too aggressive GC
+	} else {
+		debug.SetGCPercent(-1)
+	}
+	if limit, err := config.ParseSize(cfg.String("gomemlimit")); err == nil {
+		debug.SetMemoryLimit(limit) // defaults to 256MB - reasonable for Pi 5
+	} else {
+		debug.SetMemoryLimit(math.MaxInt64)
+	}
+	if procs, ok := cfg.Int("gomaxprocs"); ok {
+		runtime.GOMAXPROCS(procs) // unset keeps the runtime default of all CPUs
+	}
+}
+
+// setupLogging sends logs to stderr, the rotated log file and the ring buffer
+// read by the API. Without a usable log file the frame still logs to stderr.
+func setupLogging(cfg *config.Config) (close func() error) {
+	level, _ := logging.ParseLevel(cfg.String("log-level"))
+	file := cfg.String("log-file")
+	switch file {
+	case "off":
+		file = ""
+	case "":
+		file = appdata.Path("logs/flow-frame.log")
+	}
+	maxSize, _ := config.ParseSize(cfg.String("log-max-size"))
+	maxFiles, _ := cfg.Int("log-files")
+
+	close, err := logging.Setup(logging.Options{Level: level, File: file, MaxSize: maxSize, MaxFiles: maxFiles})
+	if err != nil {
+		close, _ = logging.Setup(logging.Options{Level: level})
+		log.Printf("Warning: logging to stderr only: %v", err)
+	}
+	return close
 }
 
-// initializeSDL2 initializes SDL2 with fallback video drivers
-func initializeSDL2() error {
+// initializeSDL2 initializes SDL2 with the configured video driver first, then fallbacks
+func initializeSDL2(preferredDriver string) error {
 	// Force a GC cycle before SDL2 initialization to prevent interference
 	runtime.GC()
 	runtime.GC() // Double GC to ensure clean state
@@ -119,14 +274,13 @@ func initializeSDL2() error {
 	// Small delay to ensure system is ready
 	time.Sleep(100 * time.Millisecond)
 
-	// Respect environment variable first, then fallback
-	envDriver := os.Getenv("SDL_VIDEODRIVER")
+	// Respect the configured driver first, then fallback
 	var videoDrivers []string
 
-	if envDriver != "" {
-		log.Printf("Using environment SDL_VIDEODRIVER: %s", envDriver)
-		// Use environment driver first, then fallbacks including fbcon for Pi
-		videoDrivers = []string{envDriver, "fbcon", "software", "dummy"}
+	if preferredDriver != "" {
+		log.Printf("Using configured SDL_VIDEODRIVER: %s", preferredDriver)
+		// Use the configured driver first, then fallbacks including fbcon for Pi
+		videoDrivers = []string{preferredDriver, "fbcon", "software", "dummy"}
 	} else {
 		// Platform-specific video driver fallbacks
 		if runtime.GOOS == "darwin" {
@@ -436,14 +590,35 @@ func createRenderer(window *sdl.Window) (*sdl.Renderer, error) {
 	return renderer, nil
 }
 
-// runGameLoop executes the main SDL2 game loop
-func runGameLoop(game *root.RootScreen) {
+// runGameLoop executes the main SDL2 game loop until quit, an error or a termination signal.
+// It reports whether the loop ended cleanly rather than on an error.
+func runGameLoop(game screen, signals <-chan os.Signal, cfg *config.Config, notifier *sdnotify.Notifier) (clean bool) {
 	running := true
 	frameTime := time.Second / targetFPS
 	lastTime := time.Now()
 	frameCount := 0
+	var lastStatus time.Time
+	started := time.Now()
+	stable := safemode.Active()
 
 	for running {
+		// Handle signals on the main thread, between frames
+		select {
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				notifier.Notify(sdnotify.StateReloading)
+				cfg = reloadConfig(game, cfg)
+				notifier.Ready(game.StatusText())
+				break
+			}
+			log.Printf("Received %v, shutting down", sig)
+			notifier.Status("Shutting down")
+			startShutdownDeadline()
+			running = false
+			continue
+		default:
+		}
+
 		// Handle SDL2 events (already locked to main thread)
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 			switch event.(type) {
@@ -455,15 +630,32 @@ func runGameLoop(game *root.RootScreen) {
 		// Update game logic
 		if err := game.Update(); err != nil {
 			log.Printf("Game update error: %v", err)
-			running = false
-			break
+			notifier.Status("Error: " + err.Error())
+			return false
 		}
 
 		// Render frame
 		if err := game.Draw(); err != nil {
 			log.Printf("Game draw error: %v", err)
-			running = false
-			break
+			notifier.Status("Error: " + err.Error())
+			return false
+		}
+
+		// Only a loop that keeps presenting frames pings the watchdog, so a hung decode gets restarted
+		if frameCount == 0 {
+			if err := notifier.Ready(game.StatusText()); err != nil {
+				log.Printf("Warning: Failed to notify systemd: %v", err)
+			}
+			confirmUpdate()
+		}
+		notifier.Watchdog()
+		if time.Since(lastStatus) >= serviceStatusInterval {
+			lastStatus = time.Now()
+			notifier.Status(game.StatusText())
+		}
+		if !stable && time.Since(started) >= safemode.StableAfter {
+			stable = true
+			safemode.Stable()
 		}
 
 		// Periodic garbage collection (every 60 frames)
@@ -479,4 +671,34 @@ func runGameLoop(game *root.RootScreen) {
 		}
 		lastTime = time.Now()
 	}
+	return true
+}
+
+// reloadConfig re-reads the config file and environment for SIGHUP. An invalid
+// configuration is logged and the running one kept.
+func reloadConfig(game screen, cfg *config.Config) *config.Config {
+	log.Println("Received SIGHUP, reloading configuration")
+	reloaded, err := cfg.Reload()
+	if err != nil {
+		log.Printf("Warning: keeping current configuration: %v", err)
+		return cfg
+	}
+	reloaded.Export()
+	applyRuntimeConfig(reloaded)
+	diagnostics.SetConfig(reloaded)
+	performance.SetSysfsRoot(reloaded.String("sysfs-root"))
+	if level, err := logging.ParseLevel(reloaded.String("log-level")); err == nil {
+		logging.SetLevel(level)
+	}
+	game.Reload()
+	return reloaded
+}
+
+// startShutdownDeadline exits the process if cleanup has not finished in time,
+// so a hung download or nmcli call cannot outlive the stop request
+func startShutdownDeadline() {
+	time.AfterFunc(shutdownTimeout, func() {
+		log.Printf("Shutdown did not finish within %v, exiting", shutdownTimeout)
+		os.Exit(1)
+	})
 }
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"flow-frame/pkg/events"
+)
+
+const (
+	// eventsPingInterval keeps idle connections alive through NATs and proxies
+	eventsPingInterval = 30 * time.Second
+
+	wsCloseNormal    = 1000
+	wsCloseGoingAway = 1001
+	wsCloseTryAgain  = 1013 // subscriber fell behind; reconnect with ?since=
+)
+
+// EventsHello is the first message on every event stream. It is not part of
+// the sequence; it tells the client whether the replay that follows is complete.
+type EventsHello struct {
+	Type     string `json:"type"` // always "hello"
+	LastSeq  uint64 `json:"lastSeq"`
+	Replayed int    `json:"replayed"`
+	Complete bool   `json:"complete"` // false when events after ?since= were already evicted
+}
+
+// handleEvents upgrades to a WebSocket and streams events. Clients resume
+// after a reconnect with ?since=<last seq received>.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be a sequence number")
+			return
+		}
+		since = n
+	} else {
+		// Without since only new events are sent
+		since = s.events.LastSeq()
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err == errNotWebSocket {
+		writeError(w, http.StatusBadRequest, "websocket upgrade required")
+		return
+	} else if err != nil {
+		log.Printf("api: websocket upgrade failed: %v", err)
+		return
+	}
+
+	replay, complete, live, cancel := s.events.Subscribe(since)
+	defer cancel()
+
+	closed := make(chan error, 1)
+	go func() { closed <- conn.readLoop() }()
+
+	lastSeq := since
+	if len(replay) > 0 {
+		lastSeq = replay[len(replay)-1].Seq
+	}
+	hello, _ := json.Marshal(EventsHello{Type: "hello", LastSeq: lastSeq, Replayed: len(replay), Complete: complete})
+	if err := conn.WriteText(hello); err != nil {
+		conn.Close(wsCloseGoingAway)
+		return
+	}
+	for _, ev := range replay {
+		if err := writeEvent(conn, ev); err != nil {
+			conn.Close(wsCloseGoingAway)
+			return
+		}
+	}
+
+	ping := time.NewTicker(eventsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case ev, ok := <-live:
+			if !ok {
+				conn.Close(wsCloseTryAgain)
+				return
+			}
+			if err := writeEvent(conn, ev); err != nil {
+				conn.Close(wsCloseGoingAway)
+				return
+			}
+		case <-ping.C:
+			if err := conn.writeFrame(wsOpPing, nil); err != nil {
+				conn.Close(wsCloseGoingAway)
+				return
+			}
+		case <-closed:
+			conn.Close(wsCloseNormal)
+			return
+		case <-s.done:
+			conn.Close(wsCloseGoingAway)
+			return
+		}
+	}
+}
+
+// writeEvent sends one event envelope as a text message
+func writeEvent(conn *wsConn, ev events.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("api: failed to encode %s event: %v", ev.Type, err)
+		return nil
+	}
+	return conn.WriteText(data)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"flow-frame/pkg/logging"
+)
+
+//go:embed schema.json
+var schemaJSON []byte
+
+// handleStatus returns the last published status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Status())
+}
+
+// handleCollections lists the collections that can be selected
+func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	collections := s.Status().Collections
+	if collections == nil {
+		collections = []CollectionStatus{}
+	}
+	writeJSON(w, http.StatusOK, collections)
+}
+
+// handleSkip advances to the next video
+func (s *Server) handleSkip(w http.ResponseWriter, r *http.Request) {
+	s.handleCommand(w, r, Command{Kind: CommandSkip})
+}
+
+// handlePause freezes playback on the current frame
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.handleCommand(w, r, Command{Kind: CommandPause})
+}
+
+// handleResume continues playback after a pause
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.handleCommand(w, r, Command{Kind: CommandResume})
+}
+
+// SelectCollectionRequest is the body of POST /api/v1/collection
+type SelectCollectionRequest struct {
+	ID string `json:"id"`
+}
+
+// handleSelectCollection switches to another collection
+func (s *Server) handleSelectCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req SelectCollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeError(w, http.StatusBadRequest, "body must be {\"id\": \"<collection id>\"}")
+		return
+	}
+	s.handleCommand(w, r, Command{Kind: CommandSelectCollection, CollectionID: req.ID})
+}
+
+// PowerRequest is the body of POST /api/v1/power
+type PowerRequest struct {
+	On *bool `json:"on"`
+}
+
+// handlePower turns the picture on or off
+func (s *Server) handlePower(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req PowerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.On == nil {
+		writeError(w, http.StatusBadRequest, "body must be {\"on\": true|false}")
+		return
+	}
+	s.handleCommand(w, r, Command{Kind: CommandPower, On: *req.On})
+}
+
+// handleSettings changes speed, interval and brightness
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var patch SettingsPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid settings: "+err.Error())
+		return
+	}
+	if patch.Speed == nil && patch.Interval == nil && patch.Brightness == nil {
+		writeError(w, http.StatusBadRequest, "no settings given")
+		return
+	}
+	s.handleCommand(w, r, Command{Kind: CommandUpdateSettings, Settings: patch})
+}
+
+// handleCommand runs a command on the main thread and responds with the resulting status
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request, cmd Command) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if err := s.dispatch(r.Context(), cmd); err != nil {
+		status := http.StatusBadRequest
+		switch err {
+		case ErrBusy:
+			status = http.StatusServiceUnavailable
+		case ErrPending:
+			status = http.StatusGatewayTimeout
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Status())
+}
+
+// defaultLogCount is how many records GET /api/v1/logs returns without ?n=
+const defaultLogCount = 200
+
+// handleLogs returns the most recent log records
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	n := defaultLogCount
+	if v := r.URL.Query().Get("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			writeError(w, http.StatusBadRequest, "n must be a positive integer")
+			return
+		}
+		n = parsed
+	}
+	min := slog.LevelDebug
+	if v := r.URL.Query().Get("level"); v != "" {
+		level, err := logging.ParseLevel(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "level: "+err.Error())
+			return
+		}
+		min = level
+	}
+
+	entries := logging.Recent(n, min)
+	if entries == nil {
+		entries = []logging.Entry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleSchema serves the JSON schema of request and response bodies
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(schemaJSON)
+}
+
+// writeJSON encodes v as the response body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with an errorResponse body
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"flow-frame/pkg/events"
+)
+
+const (
+	// EnvAddr enables the API when set to a listen address such as ":8080"
+	EnvAddr = "FLOW_FRAME_API_ADDR"
+	// EnvToken is the bearer token every request must present
+	EnvToken = "FLOW_FRAME_API_TOKEN"
+
+	// commandTimeout bounds how long a request waits for the main thread
+	commandTimeout = 5 * time.Second
+)
+
+// ConfigFromEnv returns the listen address and token. ok is false when the
+// API is disabled or no token is configured; an unauthenticated API is never started.
+func ConfigFromEnv() (addr, token string, ok bool) {
+	addr = os.Getenv(EnvAddr)
+	token = os.Getenv(EnvToken)
+	if addr == "" {
+		return "", "", false
+	}
+	if token == "" {
+		log.Printf("api: %s is set but %s is empty; not starting the control API", EnvAddr, EnvToken)
+		return "", "", false
+	}
+	return addr, token, true
+}
+
+// Server is the LAN control API. Handlers never touch playback state
+// directly: reads are served from the last published Status and writes are
+// queued as Commands for the SDL main thread.
+type Server struct {
+	addr  string
+	token string
+
+	server    *http.Server
+	isRunning bool
+	mu        sync.RWMutex
+
+	mux      *http.ServeMux
+	commands chan Command
+	events   *events.Bus
+	done     chan struct{} // closed by Stop to end event streams, which Shutdown does not track
+
+	statusMu sync.RWMutex
+	status   Status
+}
+
+// NewServer creates an API server listening on addr and requiring token
+func NewServer(addr, token string) *Server {
+	s := &Server{
+		addr:     addr,
+		token:    token,
+		mux:      http.NewServeMux(),
+		commands: make(chan Command, 16),
+		events:   events.Default,
+	}
+
+	s.mux.HandleFunc("/api/v1/status", s.authenticated(s.handleStatus))
+	s.mux.HandleFunc("/api/v1/collections", s.authenticated(s.handleCollections))
+	s.mux.HandleFunc("/api/v1/skip", s.authenticated(s.handleSkip))
+	s.mux.HandleFunc("/api/v1/pause", s.authenticated(s.handlePause))
+	s.mux.HandleFunc("/api/v1/resume", s.authenticated(s.handleResume))
+	s.mux.HandleFunc("/api/v1/collection", s.authenticated(s.handleSelectCollection))
+	s.mux.HandleFunc("/api/v1/settings", s.authenticated(s.handleSettings))
+	s.mux.HandleFunc("/api/v1/power", s.authenticated(s.handlePower))
+	s.mux.HandleFunc("/api/v1/events", s.authenticated(s.handleEvents))
+	s.mux.HandleFunc("/api/v1/logs", s.authenticated(s.handleLogs))
+	s.mux.HandleFunc("/api/v1/schema", s.handleSchema) // public so tooling can discover the API
+
+	return s
+}
+
+// Handle registers an additional authenticated handler. It must be called before Start.
+func (s *Server) Handle(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, s.authenticated(handler))
+}
+
+// Start starts the HTTP server in the background
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("api server already running")
+	}
+
+	s.server = &http.Server{
+		Addr:         s.addr,
+		Handler:      s.mux,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+	s.isRunning = true
+	s.done = make(chan struct{})
+
+	go func() {
+		log.Printf("Starting control API on %s", s.addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Control API error: %v", err)
+			s.mu.Lock()
+			s.isRunning = false
+			s.mu.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+// Stop gracefully shuts down the HTTP server
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	close(s.done)
+	if err := s.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown api server: %w", err)
+	}
+
+	s.isRunning = false
+	log.Println("Control API stopped")
+	return nil
+}
+
+// Commands returns the queue the main thread drains once per frame
+func (s *Server) Commands() <-chan Command {
+	return s.commands
+}
+
+// PublishStatus replaces the status snapshot served to clients. Called from the main thread.
+func (s *Server) PublishStatus(status Status) {
+	s.statusMu.Lock()
+	s.status = status
+	s.statusMu.Unlock()
+}
+
+// Status returns the last published status snapshot
+func (s *Server) Status() Status {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	return s.status
+}
+
+// authenticated rejects requests without the configured bearer token. Browsers
+// cannot set headers on WebSocket requests, so those may pass ?access_token= instead.
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" && headerContains(r.Header, "Upgrade", "websocket") {
+			token = r.URL.Query().Get("access_token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="flow-frame"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// dispatch queues a command for the main thread and waits for its result. A
+// command that was never queued fails with ErrBusy, one that was queued but did
+// not finish in time with ErrPending.
+func (s *Server) dispatch(ctx context.Context, cmd Command) error {
+	cmd.reply = make(chan error, 1)
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	select {
+	case s.commands <- cmd:
+	case <-ctx.Done():
+		return ErrBusy
+	}
+
+	select {
+	case err := <-cmd.reply:
+		return err
+	case <-ctx.Done():
+		return ErrPending
+	}
+}
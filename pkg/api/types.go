@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/sharedTypes"
+)
+
+// ErrBusy is returned when the main thread did not pick up a command in time, or
+// when the command arrived while the API was shutting down
+var ErrBusy = errors.New("frame is busy, try again")
+
+// ErrPending is returned when a queued command did not finish in time. The main
+// thread may still run it, so retrying could apply it twice.
+var ErrPending = errors.New("command accepted, result unknown")
+
+// CommandKind identifies a control command
+type CommandKind string
+
+const (
+	CommandSkip             CommandKind = "skip"              // play the next video
+	CommandPause            CommandKind = "pause"             // freeze on the current frame
+	CommandResume           CommandKind = "resume"            // continue after a pause
+	CommandSelectCollection CommandKind = "select_collection" // switch to CollectionID
+	CommandUpdateSettings   CommandKind = "update_settings"   // apply Settings
+	CommandPower            CommandKind = "power"             // turn the picture on or off according to On
+)
+
+// Command is a request for the SDL main thread. The receiver must call Reply exactly once.
+type Command struct {
+	Kind         CommandKind
+	CollectionID string        // CommandSelectCollection
+	Settings     SettingsPatch // CommandUpdateSettings
+	On           bool          // CommandPower
+
+	reply chan error
+}
+
+// Reply reports the outcome of the command to the waiting request
+func (c Command) Reply(err error) {
+	if c.reply != nil {
+		c.reply <- err
+	}
+}
+
+// SettingsPatch changes the given playback settings; nil fields are left as they are
+type SettingsPatch struct {
+	Speed      *float64              `json:"speed,omitempty"`
+	Interval   *sharedTypes.Interval `json:"interval,omitempty"`
+	Brightness *float64              `json:"brightness,omitempty"`
+}
+
+// Status is a snapshot of the frame published by the main thread
+type Status struct {
+	Collection  CollectionStatus   `json:"collection"`
+	Video       VideoStatus        `json:"video"`
+	DisplayOn   bool               `json:"displayOn"`
+	Paused      bool               `json:"paused"`
+	Speed       float64            `json:"speed"`
+	Interval    string             `json:"interval"`
+	Brightness  float64            `json:"brightness"`
+	Memory      MemoryStatus       `json:"memory"`
+	Thermal     ThermalStatus      `json:"thermal"`
+	Collections []CollectionStatus `json:"collections,omitempty"`
+	Build       buildinfo.Info     `json:"build"`
+	Device      DeviceStatus       `json:"device"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+// CollectionStatus describes a collection
+type CollectionStatus struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Source string `json:"source"`
+}
+
+// VideoStatus describes the video on screen
+type VideoStatus struct {
+	Path          string  `json:"path"`
+	Position      float64 `json:"position"` // seconds into the current loop
+	Loops         int     `json:"loops"`    // completed loops of this video
+	Codec         string  `json:"codec"`
+	HardwareAccel bool    `json:"hardwareAccel"`
+	Width         int     `json:"width"`
+	Height        int     `json:"height"`
+	FPS           float64 `json:"fps"`
+}
+
+// MemoryStatus describes system memory
+type MemoryStatus struct {
+	Pressure    string `json:"pressure"`
+	AvailableMB uint64 `json:"availableMB"`
+	TotalMB     uint64 `json:"totalMB"`
+}
+
+// ThermalStatus describes CPU temperature and throttling
+type ThermalStatus struct {
+	State     string  `json:"state"` // normal, warm or throttled
+	TempC     float64 `json:"tempC"` // hottest thermal zone, 0 when there are none
+	Throttled bool    `json:"throttled"`
+}
+
+// DeviceStatus describes the hardware the frame runs on
+type DeviceStatus struct {
+	Model        string          `json:"model"`
+	Uptime       float64         `json:"uptime"`       // seconds since the frame started
+	SystemUptime float64         `json:"systemUptime"` // seconds since boot
+	Decoders     []DecoderStatus `json:"decoders"`
+}
+
+// DecoderStatus lists the decoders available for a codec
+type DecoderStatus struct {
+	Codec    string   `json:"codec"`
+	Hardware []string `json:"hardware,omitempty"`
+	Software bool     `json:"software"`
+}
+
+// errorResponse is the body of every non-2xx response
+type errorResponse struct {
+	Error string `json:"error"`
+}
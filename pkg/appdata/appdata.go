@@ -0,0 +1,128 @@
+// Package appdata locates the directory where persistent state such as user
+// settings is stored and provides crash-safe file writes into it.
+package appdata
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// EnvDataDir overrides the data directory
+	EnvDataDir = "FLOW_FRAME_DATA_DIR"
+	// SystemDataDir is used when the process may write to it (e.g. on the device)
+	SystemDataDir = "/var/lib/flow-frame"
+	// appName is the directory name under XDG_DATA_HOME
+	appName = "flow-frame"
+)
+
+var (
+	dirOnce sync.Once
+	dir     string
+)
+
+// Dir returns the data directory, creating it if necessary. It is resolved once,
+// in order: $FLOW_FRAME_DATA_DIR, systemd's $STATE_DIRECTORY, /var/lib/flow-frame
+// when writable, $XDG_DATA_HOME/flow-frame, ~/.local/share/flow-frame.
+func Dir() string {
+	dirOnce.Do(func() {
+		dir = resolveDir()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Printf("appdata: failed to create %s: %v", dir, err)
+		}
+		log.Printf("appdata: using data directory %s", dir)
+	})
+	return dir
+}
+
+// Path returns the location of name inside the data directory
+func Path(name string) string {
+	return filepath.Join(Dir(), name)
+}
+
+// resolveDir picks the data directory without creating it
+func resolveDir() string {
+	if d := os.Getenv(EnvDataDir); d != "" {
+		return d
+	}
+	if d := os.Getenv("STATE_DIRECTORY"); d != "" {
+		// systemd may pass several colon-separated paths; the first is ours
+		return filepath.SplitList(d)[0]
+	}
+	if isWritableDir(SystemDataDir) {
+		return SystemDataDir
+	}
+	if d := os.Getenv("XDG_DATA_HOME"); d != "" {
+		return filepath.Join(d, appName)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share", appName)
+	}
+	return "data"
+}
+
+// isWritableDir reports whether path is an existing directory we can create files in
+func isWritableDir(path string) bool {
+	st, err := os.Stat(path)
+	if err != nil || !st.IsDir() {
+		return false
+	}
+	f, err := os.CreateTemp(path, ".probe-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// WriteFileAtomic replaces path with data so that readers and crashes only ever
+// observe the old or the new content: it writes a temp file in the same
+// directory, fsyncs it, renames it over path and fsyncs the directory.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dirPath := filepath.Dir(path)
+	if err := os.MkdirAll(dirPath, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dirPath, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpName)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	// Persist the rename itself
+	d, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
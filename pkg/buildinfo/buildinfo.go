@@ -0,0 +1,96 @@
+// Package buildinfo describes the running binary. Release builds set the
+// variables below with -ldflags, e.g.
+//
+//	go build -ldflags "-X flow-frame/pkg/buildinfo.Version=1.4 -X flow-frame/pkg/buildinfo.Commit=$(git rev-parse --short HEAD)"
+//
+// Anything left unset is filled in from the module and VCS information that
+// the Go toolchain embeds.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags "-X flow-frame/pkg/buildinfo.<Name>=<value>"
+var (
+	Version string // release version, e.g. 1.4
+	Commit  string // VCS revision
+	Date    string // build time, RFC 3339
+)
+
+// DevVersion is reported by builds without a release version
+const DevVersion = "dev"
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion string `json:"goVersion"`
+}
+
+var (
+	infoOnce sync.Once
+	info     Info
+)
+
+// Get returns the build information, resolved once
+func Get() Info {
+	infoOnce.Do(func() {
+		info = Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+		bi, ok := debug.ReadBuildInfo()
+		if ok {
+			if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+				info.Version = bi.Main.Version
+			}
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					if info.Commit == "" {
+						info.Commit = s.Value
+					}
+				case "vcs.time":
+					if info.Date == "" {
+						info.Date = s.Value
+					}
+				case "vcs.modified":
+					info.Modified = s.Value == "true"
+				}
+			}
+		}
+		if len(info.Commit) > 12 {
+			info.Commit = info.Commit[:12]
+		}
+		if info.Version == "" {
+			info.Version = DevVersion
+		}
+	})
+	return info
+}
+
+// Released reports whether the binary carries a release version
+func (i Info) Released() bool {
+	return i.Version != DevVersion
+}
+
+// String summarizes the build, e.g. "1.4 (3f2a9c1, 2026-03-01T10:00:00Z)"
+func (i Info) String() string {
+	s := i.Version
+	commit := i.Commit
+	if commit != "" && i.Modified {
+		commit += "+dirty"
+	}
+	switch {
+	case commit != "" && i.Date != "":
+		s += fmt.Sprintf(" (%s, %s)", commit, i.Date)
+	case commit != "":
+		s += fmt.Sprintf(" (%s)", commit)
+	case i.Date != "":
+		s += fmt.Sprintf(" (%s)", i.Date)
+	}
+	return s
+}
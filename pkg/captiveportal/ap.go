@@ -2,7 +2,6 @@ package captiveportal
 
 import (
 	"fmt"
-	"log"
 	"os/exec"
 	"strings"
 	"time"
@@ -47,7 +46,7 @@ func disconnectInterface(iface string) error {
 		return fmt.Errorf("failed to check device state: %w", err)
 	}
 
-	log.Printf("Device states:\n%s", string(output))
+	logger.Debug("device states", "output", string(output))
 
 	// Parse output to check if our interface is connected
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -57,20 +56,19 @@ func disconnectInterface(iface string) error {
 		parts := strings.Split(line, ":")
 		if len(parts) >= 2 && parts[0] == iface {
 			deviceState = parts[1]
-			log.Printf("Interface %s state: %s", iface, deviceState)
+			logger.Info("interface state", "iface", iface, "state", deviceState)
 			if deviceState == "connected" || deviceState == "connecting" {
 				isConnected = true
 				break
 			}
 			// Check if unmanaged
 			if deviceState == "unmanaged" {
-				log.Printf("WARNING: Interface %s is unmanaged by NetworkManager", iface)
-				log.Printf("Attempting to set as managed...")
+				logger.Warn("interface is unmanaged by NetworkManager, setting it managed", "iface", iface)
 				manageCmd := exec.Command("nmcli", "device", "set", iface, "managed", "yes")
 				if manageOutput, manageErr := manageCmd.CombinedOutput(); manageErr != nil {
-					log.Printf("Failed to set managed state: %v (output: %s)", manageErr, string(manageOutput))
+					logger.Warn("cannot set interface managed", "iface", iface, "err", manageErr, "output", string(manageOutput))
 				} else {
-					log.Printf("Successfully set %s to managed", iface)
+					logger.Info("interface set to managed", "iface", iface)
 					time.Sleep(2 * time.Second) // Wait for state to settle
 				}
 				return nil
@@ -80,19 +78,19 @@ func disconnectInterface(iface string) error {
 
 	if !isConnected {
 		// Interface not connected, nothing to do
-		log.Printf("Interface %s is not connected (state: %s)", iface, deviceState)
+		logger.Info("interface is not connected", "iface", iface, "state", deviceState)
 		return nil
 	}
 
 	// Disconnect the interface
-	log.Printf("WiFi interface %s is connected, disconnecting...", iface)
+	logger.Info("disconnecting interface", "iface", iface)
 	cmd = exec.Command("nmcli", "device", "disconnect", iface)
 	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to disconnect %s: %w (output: %s)", iface, err, string(output))
 	}
 
-	log.Printf("Successfully disconnected %s", iface)
+	logger.Info("interface disconnected", "iface", iface)
 
 	// Wait a moment for the interface to fully disconnect
 	time.Sleep(2 * time.Second)
@@ -162,10 +160,10 @@ func createAP(iface, connectionName, ssid, ip string) error {
 	)
 
 	// Log the exact command for debugging
-	log.Printf("Executing: nmcli device wifi hotspot ifname %s con-name %s ssid %s", iface, connectionName, ssid)
+	logger.Info("creating hotspot", "iface", iface, "connection", connectionName, "ssid", ssid)
 
 	output, err := cmd.CombinedOutput()
-	log.Printf("nmcli hotspot output: %s", string(output))
+	logger.Debug("nmcli hotspot output", "output", string(output))
 	if err != nil {
 		// Provide helpful context for common errors
 		errMsg := string(output)
@@ -4,9 +4,9 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -48,7 +48,7 @@ func (ws *WebServer) handleScan(w http.ResponseWriter, r *http.Request) {
 
 	networks, err := scanWiFiNetworks()
 	if err != nil {
-		log.Printf("Failed to scan WiFi networks: %v", err)
+		logger.Warn("WiFi scan failed", "err", err)
 		http.Error(w, fmt.Sprintf("Failed to scan networks: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -96,17 +96,17 @@ func (ws *WebServer) handleConnect(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			connectionStatus = "error"
 			connectionError = err.Error()
-			log.Printf("Failed to connect to %s: %v", req.SSID, err)
+			logger.Warn("WiFi connection failed", "ssid", req.SSID, "err", err)
 		} else {
 			connectionStatus = "success"
-			log.Printf("Successfully connected to %s", req.SSID)
+			logger.Info("WiFi connected", "ssid", req.SSID)
 
 			// Wait a bit before verifying
 			time.Sleep(2 * time.Second)
 
 			// Verify connection
 			if current, err := getCurrentWiFi(); err == nil && current == req.SSID {
-				log.Printf("Connection verified: %s", current)
+				logger.Info("WiFi connection verified", "ssid", current)
 			}
 		}
 		connectionMu.Unlock()
@@ -207,9 +207,9 @@ func parseWiFiNetworks(output string) []WiFiNetwork {
 	return networks
 }
 
-// getCurrentWiFi returns the currently connected WiFi network
+// getCurrentWiFi returns the currently connected WiFi network and updates the Wi-Fi metrics
 func getCurrentWiFi() (string, error) {
-	cmd := exec.Command("nmcli", "-t", "-f", "ACTIVE,SSID", "dev", "wifi")
+	cmd := exec.Command("nmcli", "-t", "-f", "ACTIVE,SIGNAL,SSID", "dev", "wifi")
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -218,11 +218,18 @@ func getCurrentWiFi() (string, error) {
 
 	lines := strings.Split(string(output), "\n")
 	for _, line := range lines {
-		if strings.HasPrefix(line, "yes:") {
-			return strings.TrimPrefix(line, "yes:"), nil
+		// SSID comes last since it may contain colons
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) == 3 && fields[0] == "yes" {
+			signal, _ := strconv.Atoi(fields[1])
+			wifiConnected.Set(1)
+			wifiSignal.Set(float64(signal))
+			return fields[2], nil
 		}
 	}
 
+	wifiConnected.Set(0)
+	wifiSignal.Set(0)
 	return "", nil
 }
 
@@ -243,6 +250,6 @@ func connectToWiFi(ssid, password string) error {
 		return fmt.Errorf("failed to connect to %s: %w (output: %s)", ssid, err, string(output))
 	}
 
-	log.Printf("Successfully initiated connection to %s", ssid)
+	logger.Info("WiFi connection initiated", "ssid", ssid)
 	return nil
 }
@@ -2,11 +2,22 @@ package captiveportal
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/skip2/go-qrcode"
+
+	"flow-frame/pkg/logging"
+	"flow-frame/pkg/metrics"
+)
+
+// logger is the captiveportal subsystem logger
+var logger = logging.For("captiveportal")
+
+var (
+	wifiConnected = metrics.NewGauge("flowframe_wifi_connected", "1 when connected to a Wi-Fi network")
+	wifiSignal    = metrics.NewGauge("flowframe_wifi_signal_percent", "Signal strength of the connected Wi-Fi network")
+	portalRunning = metrics.NewGauge("flowframe_captive_portal_running", "1 while the Wi-Fi setup access point is up")
 )
 
 // Portal manages the complete captive portal system
@@ -40,11 +51,11 @@ func (p *Portal) Start() error {
 	defer p.mu.Unlock()
 
 	if p.isRunning {
-		log.Println("Captive portal is already running")
+		logger.Debug("captive portal is already running")
 		return nil
 	}
 
-	log.Println("Starting captive portal...")
+	logger.Info("starting captive portal")
 
 	// Step 1: Detect WiFi interface
 	iface, err := detectWiFiInterface()
@@ -52,19 +63,19 @@ func (p *Portal) Start() error {
 		return fmt.Errorf("failed to detect WiFi interface: %w", err)
 	}
 	p.wifiInterface = iface
-	log.Printf("Detected WiFi interface: %s", iface)
+	logger.Info("detected WiFi interface", "iface", iface)
 
 	// Step 2: Validate AP mode support
 	if err := validateAPSupport(iface); err != nil {
-		log.Printf("Warning: %v", err)
+		logger.Warn("access point support unverified", "err", err)
 	}
 
 	// Step 3: Create access point
-	log.Printf("Creating access point '%s' on interface %s", p.apSSID, iface)
+	logger.Info("creating access point", "ssid", p.apSSID, "iface", iface)
 	if err := createAP(iface, p.apName, p.apSSID, p.apIP); err != nil {
 		return fmt.Errorf("failed to create access point: %w", err)
 	}
-	log.Printf("Access point created successfully")
+	logger.Info("access point created")
 
 	// Step 4: Generate QR code
 	url := fmt.Sprintf("http://%s:%d", p.apIP, p.apPort)
@@ -75,7 +86,7 @@ func (p *Portal) Start() error {
 		return fmt.Errorf("failed to generate QR code: %w", err)
 	}
 	p.qrCodeData = qrCode
-	log.Printf("QR code generated for: %s", url)
+	logger.Debug("QR code generated", "url", url)
 
 	// Step 5: Start web server
 	p.server = NewWebServer(p.apIP, p.apPort, p.handleWiFiConnect)
@@ -84,10 +95,11 @@ func (p *Portal) Start() error {
 		_ = destroyAP(p.apName)
 		return fmt.Errorf("failed to start web server: %w", err)
 	}
-	log.Printf("Web server started on %s", url)
+	logger.Info("web server started", "url", url)
 
 	p.isRunning = true
-	log.Println("Captive portal started successfully")
+	portalRunning.Set(1)
+	logger.Info("captive portal started")
 	return nil
 }
 
@@ -100,22 +112,23 @@ func (p *Portal) Stop() error {
 		return nil
 	}
 
-	log.Println("Stopping captive portal...")
+	logger.Info("stopping captive portal")
 
 	// Step 1: Stop web server
 	if p.server != nil {
 		if err := p.server.Stop(); err != nil {
-			log.Printf("Error stopping web server: %v", err)
+			logger.Warn("cannot stop web server", "err", err)
 		}
 	}
 
 	// Step 2: Destroy access point
 	if err := destroyAP(p.apName); err != nil {
-		log.Printf("Error destroying access point: %v", err)
+		logger.Warn("cannot destroy access point", "err", err)
 	}
 
 	p.isRunning = false
-	log.Println("Captive portal stopped")
+	portalRunning.Set(0)
+	logger.Info("captive portal stopped")
 	return nil
 }
 
@@ -161,24 +174,24 @@ func (p *Portal) SetOnConnectCallback(callback func()) {
 
 // handleWiFiConnect is called by the web server when a connection attempt is made
 func (p *Portal) handleWiFiConnect(ssid, password string) error {
-	log.Printf("Attempting to connect to WiFi: %s", ssid)
+	logger.Info("connecting to WiFi", "ssid", ssid)
 
 	// Use the connectToWiFi function from handlers.go
 	if err := connectToWiFi(ssid, password); err != nil {
 		return err
 	}
 
-	log.Printf("WiFi connection to %s successful", ssid)
+	logger.Info("WiFi connected", "ssid", ssid)
 
 	// Schedule delayed shutdown of captive portal
 	// This gives the client time to see the success status before AP goes down
 	go func() {
-		log.Println("Scheduling captive portal shutdown in 10 seconds...")
+		logger.Info("stopping captive portal in 10 seconds")
 		time.Sleep(10 * time.Second)
 
-		log.Println("Stopping captive portal after successful WiFi connection")
+		logger.Info("stopping captive portal after WiFi connected")
 		if err := p.Stop(); err != nil {
-			log.Printf("Error stopping captive portal: %v", err)
+			logger.Warn("cannot stop captive portal", "err", err)
 		}
 
 		// Trigger callback after shutdown
@@ -3,7 +3,6 @@ package captiveportal
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 	"time"
@@ -59,9 +58,9 @@ func (ws *WebServer) Start() error {
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Starting captive portal web server on %s", addr)
+		logger.Info("starting web server", "addr", addr)
 		if err := ws.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Web server error: %v", err)
+			logger.Error("web server failed", "err", err)
 			ws.mu.Lock()
 			ws.isRunning = false
 			ws.mu.Unlock()
@@ -88,7 +87,7 @@ func (ws *WebServer) Stop() error {
 	}
 
 	ws.isRunning = false
-	log.Println("Captive portal web server stopped")
+	logger.Info("web server stopped")
 	return nil
 }
 
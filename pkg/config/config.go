@@ -0,0 +1,293 @@
+// Package config resolves the process configuration from command-line flags,
+// the environment, a JSON config file and built-in defaults, in that order of
+// precedence.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+const (
+	// EnvFile overrides the config file location
+	EnvFile = "FLOW_FRAME_CONFIG"
+	// DefaultFile is read when it exists and no other file is given
+	DefaultFile = "/etc/flow-frame/config.json"
+)
+
+// Source is where a resolved value came from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Value is a resolved option value
+type Value struct {
+	Value  string
+	Source Source
+}
+
+// Config is the resolved configuration
+type Config struct {
+	File        string // config file that was read, empty if none
+	PrintConfig bool   // --print-config was given
+
+	args   []string
+	values map[string]Value
+}
+
+var (
+	envOnce sync.Once
+	baseEnv map[string]string
+)
+
+// environ returns the environment as it was before the first Export, so a
+// reload sees changes to the config file instead of our own exported values
+func environ() map[string]string {
+	envOnce.Do(func() {
+		baseEnv = map[string]string{EnvFile: os.Getenv(EnvFile)}
+		for _, o := range Options {
+			if v, ok := os.LookupEnv(o.Env); ok {
+				baseEnv[o.Env] = v
+			}
+		}
+	})
+	return baseEnv
+}
+
+// Load resolves the configuration from args (without the program name). It
+// returns flag.ErrHelp when -h was given and otherwise reports every invalid
+// value at once.
+func Load(args []string) (*Config, error) {
+	env := environ()
+	cfg := &Config{args: args, values: make(map[string]Value)}
+
+	flags, file, err := cfg.parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := file != ""
+	if !explicit {
+		file = env[EnvFile]
+		explicit = file != ""
+	}
+	if !explicit {
+		file = DefaultFile
+	}
+	fileValues, err := readFile(file)
+	switch {
+	case err == nil:
+		cfg.File = file
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// The default file is optional
+	default:
+		return nil, err
+	}
+
+	var problems []error
+	for _, o := range Options {
+		v := Value{Value: o.Default, Source: SourceDefault}
+		if fv, ok := fileValues[o.Name]; ok {
+			v = Value{Value: fv, Source: SourceFile}
+		}
+		if ev := env[o.Env]; ev != "" {
+			v = Value{Value: ev, Source: SourceEnv}
+		}
+		if fv, ok := flags[o.Name]; ok {
+			v = Value{Value: fv, Source: SourceFlag}
+		}
+
+		normalized, err := o.normalize(v.Value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s (from %s): %w", o.Name, v.Source, err))
+			continue
+		}
+		v.Value = normalized
+		cfg.values[o.Name] = v
+	}
+
+	if len(problems) == 0 && cfg.String("api-addr") != "" && cfg.String("api-token") == "" {
+		problems = append(problems, errors.New("api-token is required when api-addr is set"))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %w", joinLines(problems))
+	}
+	return cfg, nil
+}
+
+// Reload resolves the configuration again with the same arguments, picking up config file changes
+func (c *Config) Reload() (*Config, error) {
+	return Load(c.args)
+}
+
+// parseFlags registers one flag per option and returns the values given on the command line
+func (c *Config) parseFlags(args []string) (map[string]string, string, error) {
+	fs := flag.NewFlagSet("flow-frame", flag.ContinueOnError)
+	file := fs.String("config", "", fmt.Sprintf("JSON config file ($%s, default %s)", EnvFile, DefaultFile))
+	fs.BoolVar(&c.PrintConfig, "print-config", false, "print the resolved configuration and exit")
+
+	given := make(map[string]string)
+	for i := range Options {
+		o := &Options[i]
+		fs.Var(&optionFlag{opt: o, given: given}, o.Name, fmt.Sprintf("%s ($%s)", o.Help, o.Env))
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: flow-frame [flags]")
+		fmt.Fprintln(fs.Output(), "       flow-frame help   (list headless commands)")
+		fmt.Fprintln(fs.Output(), "\nFlags take precedence over environment variables, which take precedence over the config file.")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, "", err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return nil, "", fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return given, *file, nil
+}
+
+// optionFlag records an option given on the command line
+type optionFlag struct {
+	opt   *Option
+	given map[string]string
+}
+
+func (f *optionFlag) String() string {
+	if f.opt == nil || f.opt.Default == "false" {
+		return "" // flag usage omits empty defaults
+	}
+	return f.opt.Default
+}
+
+func (f *optionFlag) Set(value string) error {
+	if _, err := f.opt.normalize(value); err != nil {
+		return err
+	}
+	f.given[f.opt.Name] = value
+	return nil
+}
+
+// IsBoolFlag lets boolean options be given without a value
+func (f *optionFlag) IsBoolFlag() bool {
+	return f.opt != nil && f.opt.Kind == KindBool
+}
+
+// readFile reads a JSON object mapping option names to strings, numbers or booleans
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	var problems []error
+	for name, v := range raw {
+		if _, ok := Lookup(name); !ok {
+			problems = append(problems, fmt.Errorf("unknown option %q", name))
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			values[name] = v
+		case bool:
+			values[name] = strconv.FormatBool(v)
+		case float64:
+			values[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			problems = append(problems, fmt.Errorf("%s must be a string, number or boolean", name))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Slice(problems, func(i, j int) bool { return problems[i].Error() < problems[j].Error() })
+		return nil, fmt.Errorf("config file %s:\n  %w", path, joinLines(problems))
+	}
+	return values, nil
+}
+
+// joinLines joins errors one per indented line
+func joinLines(errs []error) error {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return errors.New(strings.Join(lines, "\n  "))
+}
+
+// Export writes every resolved value to its environment variable, and
+// removes variables whose resolved value is empty
+func (c *Config) Export() {
+	for _, o := range Options {
+		v := c.values[o.Name].Value
+		if v == "" {
+			os.Unsetenv(o.Env)
+			continue
+		}
+		os.Setenv(o.Env, o.envValue(v))
+	}
+}
+
+// Lookup returns the resolved value of an option
+func (c *Config) Lookup(name string) (Value, bool) {
+	v, ok := c.values[name]
+	return v, ok
+}
+
+// String returns the resolved value of an option, empty when unset
+func (c *Config) String(name string) string {
+	return c.values[name].Value
+}
+
+// Bool returns the resolved value of a boolean option
+func (c *Config) Bool(name string) bool {
+	return c.values[name].Value == "true"
+}
+
+// Int returns the resolved value of an integer option; ok is false when it is unset or off
+func (c *Config) Int(name string) (n int, ok bool) {
+	n, err := strconv.Atoi(c.values[name].Value)
+	return n, err == nil
+}
+
+// Print writes the resolved configuration with the source of every value; secrets are masked
+func (c *Config) Print(w io.Writer) {
+	if c.File != "" {
+		fmt.Fprintf(w, "# config file: %s\n", c.File)
+	} else {
+		fmt.Fprintln(w, "# config file: none")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPTION\tVALUE\tSOURCE\tENV")
+	for _, o := range Options {
+		v := c.values[o.Name]
+		value := v.Value
+		switch {
+		case value == "":
+			value = "-"
+		case o.Secret:
+			value = "********"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", o.Name, value, v.Source, o.Env)
+	}
+	tw.Flush()
+}
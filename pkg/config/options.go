@@ -0,0 +1,248 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the value type of an option
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindBool
+	KindInt
+	KindSize
+)
+
+// Option is one configurable value. Its name is the command-line flag and the
+// config file key; Env is the environment variable it is read from and
+// exported to, so code that reads the environment directly (including the
+// decoder's C code) sees the resolved value.
+type Option struct {
+	Name     string
+	Env      string
+	Kind     Kind
+	Default  string
+	Help     string
+	AllowOff bool                     // "off" is accepted besides numbers
+	Secret   bool                     // masked by --print-config
+	Validate func(value string) error // optional check beyond the kind
+}
+
+// Options lists every option in the order --print-config shows them
+var Options = []Option{
+	{Name: "title", Env: "GAME_TITLE", Default: "Art Frame", Help: "window title"},
+	{Name: "video-driver", Env: "SDL_VIDEODRIVER", Help: "SDL video driver tried first (default: platform fallback list)", Validate: oneOf(VideoDrivers...)},
+	{Name: "video-decoder", Env: "VIDEO_DECODER", Help: "FFmpeg decoder name to prefer, e.g. h264_v4l2m2m"},
+	{Name: "force-software-decoder", Env: "FORCE_SOFTWARE_DECODER", Kind: KindBool, Default: "false", Help: "skip hardware decoders"},
+	{Name: "debug-decoders", Env: "DEBUG_DECODERS", Kind: KindBool, Default: "false", Help: "list the available FFmpeg decoders when a video opens"},
+	{Name: "debug-frame-updates", Env: "DEBUG_FRAME_UPDATES", Kind: KindBool, Default: "false", Help: "log every frame advance"},
+
+	{Name: "aws-region", Env: "AWS_DEFAULT_REGION", Help: "region of the S3 collections"},
+	{Name: "aws-access-key-id", Env: "AWS_ACCESS_KEY_ID", Help: "S3 access key"},
+	{Name: "aws-secret-access-key", Env: "AWS_SECRET_ACCESS_KEY", Secret: true, Help: "S3 secret key"},
+
+	{Name: "library-dirs", Env: "LOCAL_LIBRARY_DIRS", Help: "colon-separated folders scanned for local collections (default: assets/library)"},
+	{Name: "data-dir", Env: "FLOW_FRAME_DATA_DIR", Help: "directory for settings, cache and state (default: systemd state directory or XDG data home)"},
+
+	{Name: "api-addr", Env: "FLOW_FRAME_API_ADDR", Help: "listen address of the LAN control API, e.g. :8081", Validate: hostPort},
+	{Name: "api-token", Env: "FLOW_FRAME_API_TOKEN", Secret: true, Help: "bearer token required by the control API"},
+	{Name: "metrics-addr", Env: "FLOW_FRAME_METRICS_ADDR", Help: "listen address of the Prometheus /metrics endpoint, e.g. :9100 (default: disabled)", Validate: hostPort},
+
+	{Name: "mqtt-broker", Env: "MQTT_BROKER", Help: "MQTT broker URL, e.g. tcp://homeassistant.local:1883", Validate: brokerURL},
+	{Name: "mqtt-username", Env: "MQTT_USERNAME", Help: "MQTT user"},
+	{Name: "mqtt-password", Env: "MQTT_PASSWORD", Secret: true, Help: "MQTT password"},
+	{Name: "mqtt-topic-prefix", Env: "MQTT_TOPIC_PREFIX", Help: "topic prefix (default: flow-frame/<hostname>)"},
+	{Name: "mqtt-discovery-prefix", Env: "MQTT_DISCOVERY_PREFIX", Help: "Home Assistant discovery prefix (default: homeassistant)"},
+	{Name: "mqtt-device-name", Env: "MQTT_DEVICE_NAME", Help: "device name shown in Home Assistant"},
+
+	{Name: "safe-mode-after", Env: "FLOW_FRAME_SAFE_MODE_AFTER", Kind: KindInt, Default: "3", Help: "failed starts in a row before starting in safe mode", Validate: atLeast(1)},
+
+	{Name: "update-url", Env: "FLOW_FRAME_UPDATE_URL", Default: "https://luminateflow.ca/api/releases", Help: "release manifest checked by the update command", Validate: httpURL},
+	{Name: "update-keys", Env: "FLOW_FRAME_UPDATE_KEYS", Help: "comma-separated base64 ed25519 public keys trusted to sign releases", Validate: releaseKeys},
+	{Name: "update-dir", Env: "FLOW_FRAME_UPDATE_DIR", Help: "directory holding the A/B update slots (default: slots in the data directory)"},
+	{Name: "update-window", Env: "FLOW_FRAME_UPDATE_WINDOW", AllowOff: true, Default: "02:00-05:00", Help: "local time window in which updates may restart a frame that is on screen, or off", Validate: clockWindow},
+
+	{Name: "log-level", Env: "FLOW_FRAME_LOG_LEVEL", Default: "info", Help: "minimum level logged", Validate: oneOf("debug", "info", "warn", "error")},
+	{Name: "log-file", Env: "FLOW_FRAME_LOG_FILE", AllowOff: true, Help: "log file, or off (default: logs/flow-frame.log in the data directory)"},
+	{Name: "log-max-size", Env: "FLOW_FRAME_LOG_MAX_SIZE", Kind: KindSize, Default: "5MiB", Help: "size at which the log file is rotated"},
+	{Name: "log-files", Env: "FLOW_FRAME_LOG_FILES", Kind: KindInt, Default: "3", Help: "rotated log files kept", Validate: atLeast(1)},
+
+	{Name: "gogc", Env: "GOGC", Kind: KindInt, AllowOff: true, Default: "25", Help: "garbage collector target percentage, or off", Validate: atLeast(1)},
+	{Name: "gomemlimit", Env: "GOMEMLIMIT", Kind: KindSize, AllowOff: true, Default: "256MiB", Help: "soft memory limit such as 256MiB, or off"},
+	{Name: "gomaxprocs", Env: "GOMAXPROCS", Kind: KindInt, Help: "maximum number of CPUs running Go code (default: all CPUs)", Validate: atLeast(1)},
+
+	{Name: "sysfs-root", Env: "FLOW_FRAME_SYSFS_ROOT", Default: "/sys", Help: "where temperatures and CPU frequencies are read from; point at a fake tree to test thermal handling"},
+}
+
+// VideoDrivers are the SDL video drivers the frame knows how to configure
+var VideoDrivers = []string{"kmsdrm", "drm", "fbcon", "wayland", "x11", "cocoa", "software", "dummy"}
+
+// Lookup finds an option by name
+func Lookup(name string) (Option, bool) {
+	for _, o := range Options {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// normalize checks value against the option's kind and returns its canonical form
+func (o Option) normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if o.AllowOff && value == "off" {
+		return value, nil
+	}
+
+	switch o.Kind {
+	case KindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	case KindInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+	case KindSize:
+		if _, err := ParseSize(value); err != nil {
+			return "", err
+		}
+	}
+
+	if o.Validate != nil {
+		if err := o.Validate(value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// envValue is how a normalized value is exported to the environment. The C
+// decoder only treats "1" as enabled, so booleans are written as 1 and 0.
+func (o Option) envValue(value string) string {
+	if o.Kind == KindBool {
+		if value == "true" {
+			return "1"
+		}
+		return "0"
+	}
+	return value
+}
+
+// ParseSize parses a byte count with an optional B, KiB, MiB, GiB or TiB suffix, as GOMEMLIMIT does
+func ParseSize(value string) (int64, error) {
+	units := []struct {
+		suffix string
+		shift  uint
+	}{{"TiB", 40}, {"GiB", 30}, {"MiB", 20}, {"KiB", 10}, {"B", 0}}
+
+	shift := uint(0)
+	number := value
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			number, shift = strings.TrimSuffix(value, u.suffix), u.shift
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)>>shift {
+		return 0, fmt.Errorf("%q is not a size such as 256MiB", value)
+	}
+	return n << shift, nil
+}
+
+// oneOf accepts only the listed values
+func oneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+// atLeast accepts integers not below min
+func atLeast(min int) func(string) error {
+	return func(value string) error {
+		if n, err := strconv.Atoi(value); err == nil && n < min {
+			return fmt.Errorf("must be at least %d", min)
+		}
+		return nil
+	}
+}
+
+// hostPort accepts listen addresses such as :8081 or 0.0.0.0:8081
+func hostPort(value string) error {
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a host:port address", value)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("%q has an invalid port", value)
+	}
+	return nil
+}
+
+// httpURL accepts absolute http and https URLs
+func httpURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%q is not an http(s) URL", value)
+	}
+	return nil
+}
+
+// releaseKeys accepts comma-separated base64 ed25519 public keys
+func releaseKeys(value string) error {
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if raw, err := base64.StdEncoding.DecodeString(key); key != "" && (err != nil || len(raw) != 32) {
+			return fmt.Errorf("%q is not a base64 ed25519 public key", key)
+		}
+	}
+	return nil
+}
+
+// clockWindow accepts daily time windows such as 02:00-05:00
+func clockWindow(value string) error {
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return fmt.Errorf("%q is not a window such as 02:00-05:00", value)
+	}
+	for _, clock := range []string{from, to} {
+		if _, err := time.Parse("15:04", strings.TrimSpace(clock)); err != nil {
+			return fmt.Errorf("%q is not a window such as 02:00-05:00", value)
+		}
+	}
+	return nil
+}
+
+// brokerURL accepts the broker forms the MQTT client understands
+func brokerURL(value string) error {
+	if !strings.Contains(value, "://") {
+		value = "tcp://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%q is not a broker URL", value)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+		return nil
+	}
+	return fmt.Errorf("unsupported broker scheme %q", u.Scheme)
+}
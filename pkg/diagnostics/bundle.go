@@ -0,0 +1,237 @@
+// Package diagnostics collects what is needed to look into a problem report
+// into a single tarball: recent logs, the redacted configuration, recorded
+// codec, performance and memory history, network state and the video cache.
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/config"
+	"flow-frame/pkg/logging"
+	"flow-frame/pkg/sysinfo"
+)
+
+// commandTimeout bounds each command whose output goes into the bundle
+const commandTimeout = 5 * time.Second
+
+// File is a file added to the bundle by the caller, such as the current status
+type File struct {
+	Name string
+	Data []byte
+}
+
+// JSONFile encodes v as an indented JSON file
+func JSONFile(name string, v any) File {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		data = []byte(fmt.Sprintf("error: %v\n", err))
+	}
+	return File{Name: name, Data: append(data, '\n')}
+}
+
+// cfg is the configuration written to the bundle, set by SetConfig
+var cfg atomic.Pointer[config.Config]
+
+// SetConfig sets the configuration included in bundles; secrets are masked
+func SetConfig(c *config.Config) {
+	cfg.Store(c)
+}
+
+// networkCommands are the nmcli calls describing the network state
+var networkCommands = [][]string{
+	{"nmcli", "general", "status"},
+	{"nmcli", "device", "status"},
+	{"nmcli", "connection", "show", "--active"},
+	{"nmcli", "device", "wifi", "list", "--rescan", "no"},
+}
+
+// FileName returns the name of a bundle created now
+func FileName() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "flow-frame"
+	}
+	return fmt.Sprintf("flow-frame-diagnostics-%s-%s.tar.gz", host, time.Now().Format("20060102-150405"))
+}
+
+// Write writes a gzipped tarball of the diagnostics and the extra files to w.
+// Parts that cannot be collected are replaced by a note of the error, so a
+// bundle is produced even on a half broken device.
+func Write(ctx context.Context, w io.Writer, extra ...File) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dir := strings.TrimSuffix(FileName(), ".tar.gz")
+	now := time.Now()
+
+	add := func(f File) error {
+		header := &tar.Header{
+			Name:    dir + "/" + f.Name,
+			Mode:    0o644,
+			Size:    int64(len(f.Data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(f.Data)
+		return err
+	}
+
+	files := []File{
+		JSONFile("build.json", map[string]any{
+			"build":         buildinfo.Get(),
+			"model":         sysinfo.Model(),
+			"uptime":        sysinfo.FormatUptime(sysinfo.Uptime()),
+			"system_uptime": sysinfo.FormatUptime(sysinfo.SystemUptime()),
+		}),
+		configFile(),
+		recentLogFile(),
+	}
+	files = append(files, logFiles()...)
+	for _, kind := range []string{KindCodecs, KindPerformance, KindMemory, KindRenderer, KindVideos} {
+		files = append(files, JSONFile(kind+".json", History(kind)))
+	}
+	files = append(files, commandsFile(ctx, "network.txt", networkCommands), cacheListing())
+	files = append(files, extra...)
+
+	for _, f := range files {
+		if err := add(f); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteFile writes a bundle into dir and returns its path
+func WriteFile(ctx context.Context, dir string, extra ...File) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, FileName())
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := Write(ctx, f, extra...); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// Prune removes all but the newest keep bundles in dir
+func Prune(dir string, keep int) {
+	bundles, _ := filepath.Glob(filepath.Join(dir, "flow-frame-diagnostics-*.tar.gz"))
+	// The timestamp in the name sorts bundles of one host oldest first
+	slices.Sort(bundles)
+	for len(bundles) > keep {
+		os.Remove(bundles[0])
+		bundles = bundles[1:]
+	}
+}
+
+// configFile prints the resolved configuration with secrets masked
+func configFile() File {
+	c := cfg.Load()
+	if c == nil {
+		return File{Name: "config.txt", Data: []byte("configuration not available\n")}
+	}
+	var buf bytes.Buffer
+	c.Print(&buf)
+	return File{Name: "config.txt", Data: buf.Bytes()}
+}
+
+// recentLogFile formats the in-memory log records, which outlive a log file
+// that was disabled or rotated away
+func recentLogFile() File {
+	var buf bytes.Buffer
+	for _, e := range logging.Recent(0, slog.LevelDebug) {
+		buf.WriteString(e.String())
+		buf.WriteByte('\n')
+	}
+	return File{Name: "logs/recent.log", Data: buf.Bytes()}
+}
+
+// logFiles reads the log file and its rotated predecessors
+func logFiles() []File {
+	var files []File
+	for _, path := range logging.Files() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			data = []byte(fmt.Sprintf("error: %v\n", err))
+		}
+		files = append(files, File{Name: "logs/" + filepath.Base(path), Data: data})
+	}
+	return files
+}
+
+// commandsFile runs each command and collects its output
+func commandsFile(ctx context.Context, name string, commands [][]string) File {
+	var buf bytes.Buffer
+	for _, args := range commands {
+		fmt.Fprintf(&buf, "$ %s\n", strings.Join(args, " "))
+		cmdCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+		output, err := exec.CommandContext(cmdCtx, args[0], args[1:]...).CombinedOutput()
+		cancel()
+		buf.Write(output)
+		if err != nil {
+			fmt.Fprintf(&buf, "error: %v\n", err)
+		}
+		buf.WriteByte('\n')
+	}
+	return File{Name: name, Data: buf.Bytes()}
+}
+
+// cacheListing lists the files in the video cache with their sizes
+func cacheListing() File {
+	var buf bytes.Buffer
+	root := appdata.Path("cache")
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(&buf, "error: %v\n", err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		total += info.Size()
+		fmt.Fprintf(&buf, "%12d  %s  %s\n", info.Size(), info.ModTime().Format(time.RFC3339), rel)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(&buf, "error: %v\n", err)
+	}
+	fmt.Fprintf(&buf, "%12d  total in %s\n", total, root)
+	return File{Name: "cache.txt", Data: buf.Bytes()}
+}
@@ -0,0 +1,48 @@
+package diagnostics
+
+import (
+	"sync"
+	"time"
+)
+
+// Kinds of recorded state, also the file names in the bundle
+const (
+	KindCodecs      = "codecs"      // video.CodecInfo of every opened video
+	KindPerformance = "performance" // performance.PerformanceReport snapshots
+	KindMemory      = "memory"      // performance.MemorySnapshot snapshots
+	KindRenderer    = "renderer"    // SDL renderer info
+	KindVideos      = "videos"      // performance.VideoSummary of every finished video
+)
+
+// historySize is how many samples of each kind are kept
+const historySize = 120
+
+// Sample is a recorded value with the time it was recorded
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value any       `json:"value"`
+}
+
+var (
+	historyMu sync.Mutex
+	history   = map[string][]Sample{}
+)
+
+// Record keeps v as the latest sample of kind, dropping the oldest once
+// historySize samples are kept
+func Record(kind string, v any) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	samples := append(history[kind], Sample{Time: time.Now(), Value: v})
+	if len(samples) > historySize {
+		samples = append(samples[:0], samples[len(samples)-historySize:]...)
+	}
+	history[kind] = samples
+}
+
+// History returns the recorded samples of kind, oldest first
+func History(kind string) []Sample {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	return append([]Sample{}, history[kind]...)
+}
@@ -0,0 +1,139 @@
+// Package events broadcasts player and device state changes to subscribers
+// such as the WebSocket event stream. Publishing never blocks the caller.
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// replayCapacity is how many recent events are kept for reconnecting clients
+	replayCapacity = 256
+	// subscriberBuffer is how many events a subscriber may fall behind before it is dropped
+	subscriberBuffer = 64
+)
+
+// Event is the envelope every event is delivered in. Seq increases by one per
+// event so clients can detect gaps and resume with Subscribe(lastSeq).
+type Event struct {
+	Seq  uint64    `json:"seq"`
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Bus fans events out to subscribers and keeps a ring of recent events for replay
+type Bus struct {
+	mu          sync.Mutex
+	seq         uint64
+	recent      []Event // ring buffer of the last replayCapacity events
+	next        int     // ring index the next event is written to
+	subscribers map[chan Event]struct{}
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		recent:      make([]Event, 0, replayCapacity),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Default is the process-wide bus used by Publish and Subscribe
+var Default = NewBus()
+
+// Publish sends an event on the default bus
+func Publish(typ Type, data any) {
+	Default.Publish(typ, data)
+}
+
+// Subscribe subscribes to the default bus, see Bus.Subscribe
+func Subscribe(since uint64) (replay []Event, complete bool, ch <-chan Event, cancel func()) {
+	return Default.Subscribe(since)
+}
+
+// Publish assigns the next sequence number to an event and delivers it to all
+// subscribers. Subscribers whose buffer is full are dropped so a slow client
+// can never stall the render loop; they reconnect and replay what they missed.
+func (b *Bus) Publish(typ Type, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	ev := Event{Seq: b.seq, Type: typ, Time: time.Now(), Data: data}
+
+	if len(b.recent) < replayCapacity {
+		b.recent = append(b.recent, ev)
+	} else {
+		b.recent[b.next] = ev
+	}
+	b.next = (b.next + 1) % replayCapacity
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			log.Printf("events: dropping slow subscriber at seq %d", ev.Seq)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the buffered events newer than since followed by a
+// channel of live events. complete is false when events after since have
+// already been evicted from the replay buffer or were published by a
+// previous run. The channel is closed when cancel is called or the
+// subscriber falls too far behind.
+func (b *Bus) Subscribe(since uint64) (replay []Event, complete bool, ch <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A since beyond the last event means the process restarted and the
+	// client's position is meaningless
+	complete = since <= b.seq
+	if since < b.seq {
+		ordered := b.ordered()
+		if len(ordered) > 0 && ordered[0].Seq > since+1 {
+			complete = false
+		}
+		for _, ev := range ordered {
+			if ev.Seq > since {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	sub := make(chan Event, subscriberBuffer)
+	b.subscribers[sub] = struct{}{}
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[sub]; ok {
+				delete(b.subscribers, sub)
+				close(sub)
+			}
+		})
+	}
+	return replay, complete, sub, cancel
+}
+
+// LastSeq returns the sequence number of the most recent event
+func (b *Bus) LastSeq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq
+}
+
+// ordered returns the replay ring oldest first. Callers must hold b.mu.
+func (b *Bus) ordered() []Event {
+	if len(b.recent) < replayCapacity {
+		return b.recent
+	}
+	return append(append([]Event(nil), b.recent[b.next:]...), b.recent[:b.next]...)
+}
@@ -0,0 +1,107 @@
+package events
+
+// Type identifies the kind of an event and the shape of its Data
+type Type string
+
+const (
+	TypeVideoStarted          Type = "video.started"           // VideoStarted
+	TypeVideoEnded            Type = "video.ended"             // VideoEnded
+	TypeVideoFailed           Type = "video.failed"            // VideoFailed
+	TypeContentUnavailable    Type = "content.unavailable"     // ContentUnavailable
+	TypeCollectionSwitched    Type = "collection.switched"     // CollectionSwitched
+	TypeDownloadProgress      Type = "download.progress"       // DownloadProgress
+	TypePrefetchPending       Type = "prefetch.pending"        // PrefetchPending
+	TypeFrameSkipModeChanged  Type = "frameskip.mode_changed"  // FrameSkipModeChanged
+	TypeMemoryPressureChanged Type = "memory.pressure_changed" // MemoryPressureChanged
+	TypeThermalChanged        Type = "thermal.changed"         // ThermalChanged
+	TypeWiFiConnected         Type = "wifi.connected"          // WiFiState
+	TypeWiFiDisconnected      Type = "wifi.disconnected"       // WiFiState
+)
+
+// VideoStarted is published when a video begins playing
+type VideoStarted struct {
+	Path          string  `json:"path"`
+	CollectionID  string  `json:"collectionId"`
+	Codec         string  `json:"codec"`
+	HardwareAccel bool    `json:"hardwareAccel"`
+	Width         int     `json:"width"`
+	Height        int     `json:"height"`
+	FPS           float64 `json:"fps"`
+}
+
+// VideoEnded is published when a video stops playing, before the next one starts
+type VideoEnded struct {
+	Path          string  `json:"path"`
+	CollectionID  string  `json:"collectionId"`
+	Loops         int     `json:"loops"`         // completed loops
+	PlayedSeconds float64 `json:"playedSeconds"` // wall-clock time on screen in the current loop
+}
+
+// VideoFailed is published when a video cannot be opened or decoded and is skipped
+type VideoFailed struct {
+	Path         string `json:"path"`
+	CollectionID string `json:"collectionId"`
+	Key          string `json:"key,omitempty"` // collection item, empty for local fallback files
+	Error        string `json:"error"`
+}
+
+// ContentUnavailable is published when nothing is left to play and the no content screen is shown
+type ContentUnavailable struct {
+	CollectionID string `json:"collectionId"`
+	Error        string `json:"error"`
+}
+
+// CollectionSwitched is published once the first video of a new collection plays
+type CollectionSwitched struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	PreviousID string `json:"previousId,omitempty"`
+	Videos     int    `json:"videos"` // videos buffered for the new collection
+}
+
+// DownloadProgress reports the download of a single collection item
+type DownloadProgress struct {
+	CollectionID string `json:"collectionId"`
+	Key          string `json:"key"`
+	Index        int    `json:"index"` // position of the item in this batch, from 0
+	Count        int    `json:"count"` // items in this batch
+	Bytes        int64  `json:"bytes"`
+	TotalBytes   int64  `json:"totalBytes"` // 0 when unknown
+	Done         bool   `json:"done"`
+	Error        string `json:"error,omitempty"`
+}
+
+// PrefetchPending is published when a background prefetch starts and when it completes
+type PrefetchPending struct {
+	CollectionID string `json:"collectionId"`
+	Pending      bool   `json:"pending"`
+	Requested    int    `json:"requested,omitempty"`  // set when the prefetch starts
+	Downloaded   int    `json:"downloaded,omitempty"` // set when the prefetch completes
+	Error        string `json:"error,omitempty"`
+}
+
+// FrameSkipModeChanged is published when the adaptive frame skipper changes mode
+type FrameSkipModeChanged struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MemoryPressureChanged is published when the memory pressure level changes
+type MemoryPressureChanged struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	AvailableMB uint64 `json:"availableMB"`
+}
+
+// ThermalChanged is published when the CPU nears its thermal limit, starts or stops being throttled
+type ThermalChanged struct {
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+	TempC float64 `json:"tempC"` // hottest thermal zone
+}
+
+// WiFiState is the payload of the Wi-Fi connect and disconnect events
+type WiFiState struct {
+	Connected bool   `json:"connected"`
+	SSID      string `json:"ssid,omitempty"`
+}
@@ -0,0 +1,214 @@
+// Package logging sets up structured, leveled logging. Records go to stderr
+// (the journal on the device), to a size-rotated file in the data directory
+// and to an in-memory ring buffer of recent records for diagnostics. Packages
+// log through a per-subsystem logger:
+//
+//	var logger = logging.For("videoFs")
+//	logger.Warn("download failed", "key", key, "err", err)
+//
+// Output of the standard log package is routed through the same handlers.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// SubsystemKey is the attribute naming the part of the frame that logged a record
+const SubsystemKey = "subsystem"
+
+// DefaultRingSize is how many recent records are kept in memory
+const DefaultRingSize = 1000
+
+// Options configures Setup
+type Options struct {
+	Level    slog.Level
+	File     string // log file path, "" to log to stderr and the ring only
+	MaxSize  int64  // bytes after which the file is rotated
+	MaxFiles int    // rotated files kept next to the log file
+	RingSize int    // recent records kept in memory, DefaultRingSize when 0
+	Stderr   io.Writer
+}
+
+var (
+	// root is the handler the subsystem loggers forward to once Setup has run
+	root atomic.Pointer[slog.Handler]
+	// ring holds the recent records, nil before Setup
+	ring atomic.Pointer[Ring]
+	// logFile is the rotated log file, nil when logging to a file is disabled
+	logFile atomic.Pointer[RotatingFile]
+	// level can be changed at runtime, e.g. on config reload
+	level slog.LevelVar
+)
+
+// Setup installs the handlers and routes the standard log package through
+// them. The returned function closes the log file.
+func Setup(o Options) (close func() error, err error) {
+	level.Set(o.Level)
+	if o.Stderr == nil {
+		o.Stderr = os.Stderr
+	}
+	if o.RingSize <= 0 {
+		o.RingSize = DefaultRingSize
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: &level}
+	handlers := []slog.Handler{slog.NewTextHandler(o.Stderr, handlerOpts)}
+	close = func() error { return nil }
+	if o.File != "" {
+		file, err := OpenRotating(o.File, o.MaxSize, o.MaxFiles)
+		if err != nil {
+			return close, err
+		}
+		handlers = append(handlers, slog.NewTextHandler(file, handlerOpts))
+		logFile.Store(file)
+		close = file.Close
+	}
+	r := NewRing(o.RingSize)
+	handlers = append(handlers, r.Handler(&level))
+	ring.Store(r)
+
+	var h slog.Handler = fanout(handlers)
+	root.Store(&h)
+	slog.SetDefault(slog.New(h))
+
+	// slog.SetDefault routes the log package at info level; keep the
+	// levels that the message text implies instead
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{h: h})
+	return close, nil
+}
+
+// SetLevel changes the minimum level of every handler
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return l, fmt.Errorf("%q is not one of debug, info, warn, error", s)
+	}
+	return l, nil
+}
+
+// For returns the logger of a subsystem. It may be called before Setup, e.g.
+// in package variables; records then go to the default slog handler.
+func For(subsystem string) *slog.Logger {
+	return slog.New(&subsystemHandler{attrs: []slog.Attr{slog.String(SubsystemKey, subsystem)}})
+}
+
+// Recent returns up to n of the most recent records, oldest first, that are at
+// least at level min. It returns nil before Setup.
+func Recent(n int, min slog.Level) []Entry {
+	r := ring.Load()
+	if r == nil {
+		return nil
+	}
+	return r.Recent(n, min)
+}
+
+// Files returns the log file and its rotated predecessors, newest first
+func Files() []string {
+	if f := logFile.Load(); f != nil {
+		return f.Files()
+	}
+	return nil
+}
+
+// subsystemHandler forwards to the root handler that is current when a record
+// is logged, so loggers created before Setup pick it up
+type subsystemHandler struct {
+	attrs []slog.Attr
+	group string
+}
+
+func (h *subsystemHandler) target() slog.Handler {
+	var t slog.Handler
+	if p := root.Load(); p != nil {
+		t = *p
+	} else {
+		t = slog.Default().Handler()
+	}
+	if len(h.attrs) > 0 {
+		t = t.WithAttrs(h.attrs)
+	}
+	if h.group != "" {
+		t = t.WithGroup(h.group)
+	}
+	return t
+}
+
+func (h *subsystemHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if root.Load() != nil {
+		return l >= level.Level()
+	}
+	return slog.Default().Handler().Enabled(ctx, l)
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.target().Handle(ctx, r)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.group != "" {
+		// Attributes inside a group cannot be merged into the flat list
+		return h.target().WithAttrs(attrs)
+	}
+	return &subsystemHandler{attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	if h.group != "" {
+		return h.target().WithGroup(name)
+	}
+	return &subsystemHandler{attrs: h.attrs, group: name}
+}
+
+// fanout sends every record to all handlers that accept its level
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var first error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
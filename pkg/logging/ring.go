@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Entry is a log record kept in the ring buffer
+type Entry struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Subsystem string            `json:"subsystem,omitempty"`
+	Message   string            `json:"message"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+
+	level slog.Level
+}
+
+// String formats the entry as a single log line
+func (e Entry) String() string {
+	s := fmt.Sprintf("%s %-5s", e.Time.Format("2006-01-02 15:04:05.000"), e.Level)
+	if e.Subsystem != "" {
+		s += " [" + e.Subsystem + "]"
+	}
+	s += " " + e.Message
+	for _, k := range slices.Sorted(maps.Keys(e.Attrs)) {
+		s += fmt.Sprintf(" %s=%q", k, e.Attrs[k])
+	}
+	return s
+}
+
+// Ring keeps the most recent log records in memory
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int // index the next entry is written to
+	full    bool
+}
+
+// NewRing creates a ring holding up to size records
+func NewRing(size int) *Ring {
+	return &Ring{entries: make([]Entry, size)}
+}
+
+// Add stores an entry, replacing the oldest when the ring is full
+func (r *Ring) Add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Recent returns up to n of the most recent entries at or above min, oldest
+// first. n <= 0 returns all matching entries.
+func (r *Ring) Recent(n int, min slog.Level) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+	var out []Entry
+	// Walk backwards from the newest entry
+	for i := 0; i < count && (n <= 0 || len(out) < n); i++ {
+		e := r.entries[(r.next-1-i+len(r.entries))%len(r.entries)]
+		if e.level >= min {
+			out = append(out, e)
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// Handler returns a slog handler that records into the ring
+func (r *Ring) Handler(level slog.Leveler) slog.Handler {
+	return &ringHandler{ring: r, level: level}
+}
+
+// ringHandler turns slog records into ring entries
+type ringHandler struct {
+	ring   *Ring
+	level  slog.Leveler
+	attrs  []slog.Attr
+	prefix string // group prefix for attribute keys
+}
+
+func (h *ringHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *ringHandler) Handle(_ context.Context, rec slog.Record) error {
+	e := Entry{Time: rec.Time, Level: rec.Level.String(), Message: rec.Message, level: rec.Level}
+	add := func(prefix string, a slog.Attr) {
+		if a.Key == SubsystemKey && prefix == "" {
+			e.Subsystem = a.Value.String()
+			return
+		}
+		if e.Attrs == nil {
+			e.Attrs = map[string]string{}
+		}
+		e.Attrs[prefix+a.Key] = a.Value.Resolve().String()
+	}
+	for _, a := range h.attrs {
+		add("", a)
+	}
+	rec.Attrs(func(a slog.Attr) bool {
+		add(h.prefix, a)
+		return true
+	})
+	h.ring.Add(e)
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := *h
+	out.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		a.Key = h.prefix + a.Key
+		out.attrs = append(out.attrs, a)
+	}
+	return &out
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	out := *h
+	out.prefix = h.prefix + name + "."
+	return &out
+}
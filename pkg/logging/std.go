@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// stdWriter receives the output of the standard log package and logs each
+// line as a record. Most of the code base prefixes messages with "Warning:" or
+// "Error:" and often names its subsystem as in "mqtt: connected", so these
+// prefixes become the level and subsystem of the record.
+type stdWriter struct {
+	h slog.Handler
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	lvl, msg := stdLevel(msg)
+	h := w.h
+	if subsystem, rest, ok := stdSubsystem(msg); ok {
+		h = h.WithAttrs([]slog.Attr{slog.String(SubsystemKey, subsystem)})
+		msg = rest
+	}
+	if !h.Enabled(context.Background(), lvl) {
+		return len(p), nil
+	}
+	return len(p), h.Handle(context.Background(), slog.NewRecord(time.Now(), lvl, msg, 0))
+}
+
+// stdLevels maps message prefixes to levels, checked in order
+var stdLevels = []struct {
+	prefix string
+	level  slog.Level
+}{
+	{"Warning: ", slog.LevelWarn},
+	{"WARNING: ", slog.LevelWarn},
+	{"Error: ", slog.LevelError},
+	{"ERROR: ", slog.LevelError},
+	{"Debug: ", slog.LevelDebug},
+}
+
+// stdLevel derives the level of a log line from its prefix, removing the prefix.
+// Lines mentioning a failure without a prefix are logged as warnings.
+func stdLevel(msg string) (slog.Level, string) {
+	for _, l := range stdLevels {
+		if rest, ok := strings.CutPrefix(msg, l.prefix); ok {
+			return l.level, rest
+		}
+	}
+	lower := strings.ToLower(msg)
+	if strings.Contains(lower, "failed") || strings.Contains(lower, "error") {
+		return slog.LevelWarn, msg
+	}
+	return slog.LevelInfo, msg
+}
+
+// stdSubsystem splits "mqtt: connected" into its subsystem and message. Only
+// short lowercase prefixes count, so "Loaded config file x: y" is left alone.
+func stdSubsystem(msg string) (subsystem, rest string, ok bool) {
+	prefix, rest, found := strings.Cut(msg, ": ")
+	if !found || prefix == "" || len(prefix) > 20 {
+		return "", msg, false
+	}
+	for _, r := range prefix {
+		if (r < 'a' || r > 'z') && r != ' ' && r != '-' {
+			return "", msg, false
+		}
+	}
+	return prefix, rest, true
+}
@@ -0,0 +1,280 @@
+// Package metrics keeps counters, gauges and histograms and serves them in the
+// Prometheus text exposition format, so a frame can be scraped without pulling
+// in the Prometheus client library. Packages register their metrics in the
+// default registry from package variables:
+//
+//	var downloadBytes = metrics.NewCounter("flowframe_download_bytes_total", "Bytes downloaded from S3")
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metric types as written in the # TYPE line
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Label is a name and value pair attached to a sample
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single value of a family. Suffix is appended to the family
+// name, e.g. "_bucket" for histograms.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named metric with all of its samples
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector produces metric families when the registry is scraped
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc adapts a function to a Collector
+type CollectorFunc func() []Family
+
+// Collect calls f
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// Registry holds the collectors that are written on every scrape
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry the package-level constructors register in
+var Default = NewRegistry()
+
+// Register adds a collector
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Gather collects every family, sorted by name
+func (r *Registry) Gather() []Family {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	var families []Family
+	for _, c := range collectors {
+		families = append(families, c.Collect()...)
+	}
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// atomicFloat is a float64 updated without locks
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	name, help string
+	value      atomicFloat
+}
+
+// NewCounter creates a counter in the default registry
+func NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	Default.Register(c)
+	return c
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add adds delta, which must not be negative
+func (c *Counter) Add(delta float64) {
+	if delta > 0 {
+		c.value.add(delta)
+	}
+}
+
+// Value returns the current count
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+// Collect implements Collector
+func (c *Counter) Collect() []Family {
+	return []Family{{Name: c.name, Help: c.help, Type: TypeCounter, Samples: []Sample{{Value: c.value.load()}}}}
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	name, help string
+	value      atomicFloat
+}
+
+// NewGauge creates a gauge in the default registry
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	Default.Register(g)
+	return g
+}
+
+// Set sets the value
+func (g *Gauge) Set(v float64) {
+	g.value.store(v)
+}
+
+// SetBool sets the value to 1 when b is true, 0 otherwise
+func (g *Gauge) SetBool(b bool) {
+	if b {
+		g.Set(1)
+	} else {
+		g.Set(0)
+	}
+}
+
+// Value returns the current value
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+// Collect implements Collector
+func (g *Gauge) Collect() []Family {
+	return []Family{{Name: g.name, Help: g.help, Type: TypeGauge, Samples: []Sample{{Value: g.value.load()}}}}
+}
+
+// NewGaugeFunc registers a gauge whose value is read from f on every scrape
+func NewGaugeFunc(name, help string, f func() float64) {
+	Default.Register(CollectorFunc(func() []Family {
+		return []Family{{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Value: f()}}}}
+	}))
+}
+
+// StateSet is a gauge with one sample per state, 1 for the current state and 0 for the others
+type StateSet struct {
+	name, help, label string
+	states            []string
+	current           atomic.Int32
+}
+
+// NewStateSet creates a state set in the default registry; the first state is current
+func NewStateSet(name, help, label string, states ...string) *StateSet {
+	s := &StateSet{name: name, help: help, label: label, states: states}
+	Default.Register(s)
+	return s
+}
+
+// Set makes the state at index current; out of range indexes are ignored
+func (s *StateSet) Set(index int) {
+	if index >= 0 && index < len(s.states) {
+		s.current.Store(int32(index))
+	}
+}
+
+// Collect implements Collector
+func (s *StateSet) Collect() []Family {
+	current := int(s.current.Load())
+	samples := make([]Sample, len(s.states))
+	for i, state := range s.states {
+		samples[i] = Sample{Labels: []Label{{s.label, state}}}
+		if i == current {
+			samples[i].Value = 1
+		}
+	}
+	return []Family{{Name: s.name, Help: s.help, Type: TypeGauge, Samples: samples}}
+}
+
+// LatencyBuckets are histogram bounds in seconds suited to per-frame work at 60fps
+var LatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.0083, 0.0167, 0.025, 0.0333, 0.05, 0.1, 0.25}
+
+// Histogram counts observations into buckets
+type Histogram struct {
+	name, help string
+	bounds     []float64
+	counts     []atomic.Uint64 // per bucket, not cumulative; the last is +Inf
+	sum        atomicFloat
+	count      atomic.Uint64
+}
+
+// NewHistogram creates a histogram with the given upper bounds in the default registry
+func NewHistogram(name, help string, bounds []float64) *Histogram {
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+	h := &Histogram{name: name, help: help, bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+	Default.Register(h)
+	return h
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v) // first bound >= v, len(bounds) for +Inf
+	h.counts[i].Add(1)
+	h.sum.add(v)
+	h.count.Add(1)
+}
+
+// ObserveDuration records a duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// Collect implements Collector
+func (h *Histogram) Collect() []Family {
+	samples := make([]Sample, 0, len(h.bounds)+3)
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		le := math.Inf(1)
+		if i < len(h.bounds) {
+			le = h.bounds[i]
+		}
+		samples = append(samples, Sample{Suffix: "_bucket", Labels: []Label{{"le", formatValue(le)}}, Value: float64(cumulative)})
+	}
+	samples = append(samples,
+		Sample{Suffix: "_sum", Value: h.sum.load()},
+		Sample{Suffix: "_count", Value: float64(cumulative)},
+	)
+	return []Family{{Name: h.name, Help: h.help, Type: TypeHistogram, Samples: samples}}
+}
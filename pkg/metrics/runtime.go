@@ -0,0 +1,30 @@
+package metrics
+
+import "runtime"
+
+func init() {
+	Default.Register(CollectorFunc(collectRuntime))
+}
+
+// collectRuntime reports the Go heap and garbage collector. ReadMemStats
+// briefly stops the world, which is fine at scrape intervals.
+func collectRuntime() []Family {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	gauge := func(name, help string, v float64) Family {
+		return Family{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Value: v}}}
+	}
+	counter := func(name, help string, v float64) Family {
+		return Family{Name: name, Help: help, Type: TypeCounter, Samples: []Sample{{Value: v}}}
+	}
+	return []Family{
+		gauge("go_goroutines", "Number of goroutines", float64(runtime.NumGoroutine())),
+		gauge("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects", float64(m.HeapAlloc)),
+		gauge("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans", float64(m.HeapInuse)),
+		gauge("go_memstats_heap_objects", "Number of allocated heap objects", float64(m.HeapObjects)),
+		gauge("go_memstats_sys_bytes", "Bytes of memory obtained from the OS", float64(m.Sys)),
+		gauge("go_memstats_next_gc_bytes", "Heap size at which the next GC cycle starts", float64(m.NextGC)),
+		counter("go_gc_cycles_total", "Completed GC cycles", float64(m.NumGC)),
+		counter("go_gc_pause_seconds_total", "Total stop-the-world pause time of the GC", float64(m.PauseTotalNs)/1e9),
+	}
+}
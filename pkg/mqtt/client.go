@@ -0,0 +1,529 @@
+// Package mqtt connects the frame to an MQTT broker. It publishes the frame
+// state, accepts commands and announces itself to Home Assistant through
+// MQTT discovery. Only the subset of MQTT 3.1.1 the frame needs is implemented.
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flow-frame/pkg/api"
+)
+
+const (
+	EnvBroker          = "MQTT_BROKER"           // host:port, tcp://host:port or mqtts://host:port; MQTT is disabled when empty
+	EnvUsername        = "MQTT_USERNAME"         // optional
+	EnvPassword        = "MQTT_PASSWORD"         // optional
+	EnvTopicPrefix     = "MQTT_TOPIC_PREFIX"     // defaults to flow-frame/<device id>
+	EnvDiscoveryPrefix = "MQTT_DISCOVERY_PREFIX" // defaults to homeassistant
+	EnvDeviceName      = "MQTT_DEVICE_NAME"      // defaults to Flow Frame (<hostname>)
+
+	dialTimeout    = 10 * time.Second
+	writeTimeout   = 10 * time.Second
+	maxBackoff     = 2 * time.Minute
+	maxPacketBytes = 64 << 10
+
+	availabilityOnline  = "online"
+	availabilityOffline = "offline"
+)
+
+// Connection timing; variables so tests can run the keepalive and reconnect paths quickly
+var (
+	keepAlive  = 60 * time.Second
+	minBackoff = time.Second
+)
+
+// Config describes the broker connection and topic layout
+type Config struct {
+	Broker          string
+	Username        string
+	Password        string
+	DeviceID        string // stable identifier used in client id, topics and discovery
+	DeviceName      string // name shown in Home Assistant
+	TopicPrefix     string
+	DiscoveryPrefix string
+}
+
+// ConfigFromEnv reads the MQTT configuration. ok is false when no broker is configured.
+func ConfigFromEnv() (cfg Config, ok bool) {
+	cfg.Broker = os.Getenv(EnvBroker)
+	if cfg.Broker == "" {
+		return Config{}, false
+	}
+	cfg.Username = os.Getenv(EnvUsername)
+	cfg.Password = os.Getenv(EnvPassword)
+
+	hostname, _ := os.Hostname()
+	cfg.DeviceID = deviceID(hostname)
+	cfg.DeviceName = os.Getenv(EnvDeviceName)
+	if cfg.DeviceName == "" {
+		cfg.DeviceName = "Flow Frame"
+		if hostname != "" {
+			cfg.DeviceName += " (" + hostname + ")"
+		}
+	}
+	cfg.TopicPrefix = strings.TrimSuffix(os.Getenv(EnvTopicPrefix), "/")
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = "flow-frame/" + cfg.DeviceID
+	}
+	cfg.DiscoveryPrefix = strings.TrimSuffix(os.Getenv(EnvDiscoveryPrefix), "/")
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = "homeassistant"
+	}
+	return cfg, true
+}
+
+// deviceID turns a hostname into an identifier that is safe in topics and entity ids
+func deviceID(hostname string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(hostname) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0:
+			b.WriteByte('_')
+		}
+	}
+	id := strings.TrimRight(b.String(), "_")
+	if id == "" {
+		id = "frame"
+	}
+	return id
+}
+
+// Client keeps a connection to the broker open in the background, reconnecting
+// with backoff. Like the HTTP API it never touches playback state: state is
+// pushed in with PublishStatus and commands come out of Commands for the main
+// thread to apply.
+type Client struct {
+	cfg      Config
+	commands chan api.Command
+
+	statusMu sync.Mutex
+	status   *api.Status
+	changed  chan struct{} // signalled when a new status is waiting to be published
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewClient creates a client for cfg; call Start to connect
+func NewClient(cfg Config) *Client {
+	return &Client{
+		cfg:      cfg,
+		commands: make(chan api.Command, 16),
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// Start connects in the background
+func (c *Client) Start() {
+	if c.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.run(ctx)
+}
+
+// Stop marks the frame offline, disconnects and waits for the connection to close
+func (c *Client) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+	c.cancel = nil
+}
+
+// Commands returns the queue of commands received from the broker
+func (c *Client) Commands() <-chan api.Command {
+	return c.commands
+}
+
+// PublishStatus queues the frame status for publishing. Unchanged state is
+// not republished. Called from the main thread; never blocks.
+func (c *Client) PublishStatus(status api.Status) {
+	c.statusMu.Lock()
+	c.status = &status
+	c.statusMu.Unlock()
+
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// topic returns a topic below the configured prefix
+func (c *Client) topic(name string) string {
+	return c.cfg.TopicPrefix + "/" + name
+}
+
+// run connects and reconnects until ctx is cancelled
+func (c *Client) run(ctx context.Context) {
+	defer close(c.done)
+
+	backoff := minBackoff
+	for {
+		connected, err := c.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = minBackoff
+		}
+		log.Printf("mqtt: %v; reconnecting in %s", err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// session runs a single connection. connected reports whether the broker accepted it.
+func (c *Client) session(ctx context.Context) (connected bool, err error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to %s: %w", c.cfg.Broker, err)
+	}
+	defer conn.Close()
+	s := &session{client: c, conn: conn, r: bufio.NewReader(conn)}
+
+	if err := s.connect(); err != nil {
+		return false, err
+	}
+	log.Printf("mqtt: connected to %s as %s", c.cfg.Broker, c.cfg.DeviceID)
+
+	if err := s.start(); err != nil {
+		return true, err
+	}
+	return true, s.loop(ctx)
+}
+
+// dial opens a TCP or TLS connection to the broker
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	addr, useTLS, err := parseBroker(c.cfg.Broker)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		return (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// parseBroker accepts host, host:port, tcp://host:port, mqtt://, ssl:// and mqtts:// addresses
+func parseBroker(broker string) (addr string, useTLS bool, err error) {
+	if !strings.Contains(broker, "://") {
+		broker = "tcp://" + broker
+	}
+	u, err := url.Parse(broker)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid broker %q: %w", broker, err)
+	}
+
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+		port = "8883"
+	default:
+		return "", false, fmt.Errorf("unsupported broker scheme %q", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	if u.Hostname() == "" {
+		return "", false, fmt.Errorf("broker %q has no host", broker)
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// session is the state of one broker connection. Only the session goroutine
+// writes to conn; a reader goroutine forwards incoming packets.
+type session struct {
+	client *Client
+	conn   net.Conn
+	r      *bufio.Reader
+
+	lastState    []byte   // last state payload published
+	lastOptions  []string // collection options in the last discovery announcement
+	lastPingResp time.Time
+}
+
+// connect sends CONNECT with an "offline" last will and waits for CONNACK
+func (s *session) connect() error {
+	c := s.client
+	err := s.write(encodeConnect(connectOptions{
+		clientID:     "flow-frame-" + c.cfg.DeviceID,
+		username:     c.cfg.Username,
+		password:     c.cfg.Password,
+		keepAlive:    uint16(keepAlive / time.Second),
+		willTopic:    c.topic("availability"),
+		willPayload:  []byte(availabilityOffline),
+		willRetain:   true,
+		cleanSession: true,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to send CONNECT: %w", err)
+	}
+
+	s.conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	p, err := readPacket(s.r, maxPacketBytes)
+	s.conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return fmt.Errorf("no CONNACK: %w", err)
+	}
+	if p.kind != packetConnack || len(p.body) < 2 {
+		return fmt.Errorf("expected CONNACK, got packet type %d", p.kind)
+	}
+	if code := p.body[1]; code != 0 {
+		reason := connackReasons[code]
+		if reason == "" {
+			reason = "code " + strconv.Itoa(int(code))
+		}
+		return fmt.Errorf("broker refused connection: %s", reason)
+	}
+	return nil
+}
+
+// start subscribes to command topics and announces the frame
+func (s *session) start() error {
+	c := s.client
+	filters := []string{c.topic("+/set"), c.cfg.DiscoveryPrefix + "/status"}
+	if err := s.write(encodeSubscribe(1, filters)); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	if err := s.publish(c.topic("availability"), []byte(availabilityOnline), true); err != nil {
+		return err
+	}
+	return s.publishState(true)
+}
+
+// loop handles incoming packets, state updates and keepalive until the connection ends
+func (s *session) loop(ctx context.Context) error {
+	packets := make(chan packet)
+	readErr := make(chan error, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			p, err := readPacket(s.r, maxPacketBytes)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case packets <- p:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(keepAlive / 2)
+	defer ping.Stop()
+	s.lastPingResp = time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.publish(s.client.topic("availability"), []byte(availabilityOffline), true)
+			s.write(encodePacket(packetDisconnect, 0, nil))
+			return nil
+		case err := <-readErr:
+			return fmt.Errorf("connection lost: %w", err)
+		case p := <-packets:
+			if err := s.handle(p); err != nil {
+				return err
+			}
+		case <-s.client.changed:
+			if err := s.publishState(false); err != nil {
+				return err
+			}
+		case <-ping.C:
+			if time.Since(s.lastPingResp) > keepAlive+keepAlive/2 {
+				return fmt.Errorf("broker stopped answering pings")
+			}
+			if err := s.write(encodePacket(packetPingreq, 0, nil)); err != nil {
+				return fmt.Errorf("failed to ping: %w", err)
+			}
+		}
+	}
+}
+
+// handle processes one incoming packet
+func (s *session) handle(p packet) error {
+	switch p.kind {
+	case packetPingresp:
+		s.lastPingResp = time.Now()
+	case packetSuback:
+		if len(p.body) > 2 && bytes.IndexByte(p.body[2:], 0x80) >= 0 {
+			log.Printf("mqtt: broker rejected a subscription; commands may not work")
+		}
+	case packetPublish:
+		topic, id, payload, err := decodePublish(p)
+		if err != nil {
+			return fmt.Errorf("invalid PUBLISH: %w", err)
+		}
+		if id != 0 {
+			if err := s.write(encodePuback(id)); err != nil {
+				return err
+			}
+		}
+		return s.handleMessage(topic, payload)
+	}
+	return nil
+}
+
+// handleMessage turns a message on a command topic into a queued command
+func (s *session) handleMessage(topic string, payload []byte) error {
+	c := s.client
+	value := strings.TrimSpace(string(payload))
+
+	// Home Assistant announces restarts; discovery must be sent again
+	if topic == c.cfg.DiscoveryPrefix+"/status" {
+		if value == availabilityOnline {
+			s.lastOptions = nil
+			return s.publishState(true)
+		}
+		return nil
+	}
+
+	name, ok := strings.CutPrefix(topic, c.cfg.TopicPrefix+"/")
+	if !ok {
+		return nil
+	}
+	cmd, err := c.parseCommand(strings.TrimSuffix(name, "/set"), value)
+	if err != nil {
+		log.Printf("mqtt: ignoring %s: %v", topic, err)
+		return nil
+	}
+
+	select {
+	case c.commands <- cmd:
+	default:
+		log.Printf("mqtt: command queue full, dropping %s", cmd.Kind)
+	}
+	return nil
+}
+
+// parseCommand maps a command topic and payload to a command
+func (c *Client) parseCommand(name, value string) (api.Command, error) {
+	switch name {
+	case "power":
+		switch strings.ToUpper(value) {
+		case "ON":
+			return api.Command{Kind: api.CommandPower, On: true}, nil
+		case "OFF":
+			return api.Command{Kind: api.CommandPower, On: false}, nil
+		}
+		return api.Command{}, fmt.Errorf("expected ON or OFF, got %q", value)
+	case "playback":
+		switch strings.ToUpper(value) {
+		case "ON", "PLAY", "RESUME":
+			return api.Command{Kind: api.CommandResume}, nil
+		case "OFF", "PAUSE":
+			return api.Command{Kind: api.CommandPause}, nil
+		}
+		return api.Command{}, fmt.Errorf("expected ON or OFF, got %q", value)
+	case "skip":
+		return api.Command{Kind: api.CommandSkip}, nil
+	case "collection":
+		if id := c.collectionID(value); id != "" {
+			return api.Command{Kind: api.CommandSelectCollection, CollectionID: id}, nil
+		}
+		return api.Command{}, fmt.Errorf("unknown collection %q", value)
+	case "brightness":
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return api.Command{}, fmt.Errorf("invalid brightness %q", value)
+		}
+		brightness := percent / 100
+		return api.Command{Kind: api.CommandUpdateSettings, Settings: api.SettingsPatch{Brightness: &brightness}}, nil
+	}
+	return api.Command{}, fmt.Errorf("unknown command")
+}
+
+// collectionID resolves a collection option (title) or id to a collection id
+func (c *Client) collectionID(value string) string {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if c.status == nil {
+		return ""
+	}
+	for _, col := range c.status.Collections {
+		if col.ID == value || collectionOption(col) == value {
+			return col.ID
+		}
+	}
+	return ""
+}
+
+// publishState publishes the state topic when it changed, and the discovery
+// announcements when forced or when the collection options changed
+func (s *session) publishState(force bool) error {
+	c := s.client
+	c.statusMu.Lock()
+	status := c.status
+	c.statusMu.Unlock()
+	if status == nil {
+		return nil
+	}
+
+	st := newState(*status)
+	if force || !slices.Equal(st.options, s.lastOptions) {
+		if err := s.publishDiscovery(st.options); err != nil {
+			return err
+		}
+		s.lastOptions = st.options
+	}
+
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if !force && bytes.Equal(payload, s.lastState) {
+		return nil
+	}
+	if err := s.publish(c.topic("state"), payload, true); err != nil {
+		return err
+	}
+	s.lastState = payload
+	return nil
+}
+
+// publish sends a QoS 0 message
+func (s *session) publish(topic string, payload []byte, retain bool) error {
+	if err := s.write(encodePublish(topic, payload, retain)); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", topic, err)
+	}
+	return nil
+}
+
+// write sends a packet with a deadline so a stalled broker cannot block the session forever
+func (s *session) write(b []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := s.conn.Write(b)
+	return err
+}
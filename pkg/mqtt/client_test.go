@@ -0,0 +1,433 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"flow-frame/pkg/api"
+)
+
+const testTimeout = 5 * time.Second
+
+// fakeBroker is an in-process MQTT broker that hands every accepted
+// connection to the test
+type fakeBroker struct {
+	ln    net.Listener
+	conns chan *brokerConn
+}
+
+// brokerConn is the broker side of one client connection
+type brokerConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	b := &fakeBroker{ln: ln, conns: make(chan *brokerConn, 4)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				close(b.conns)
+				return
+			}
+			b.conns <- &brokerConn{Conn: conn, r: bufio.NewReader(conn)}
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return b
+}
+
+// accept waits for the client to connect
+func (b *fakeBroker) accept(t *testing.T) *brokerConn {
+	t.Helper()
+	select {
+	case conn, ok := <-b.conns:
+		if !ok {
+			t.Fatal("broker closed")
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	case <-time.After(testTimeout):
+		t.Fatal("client did not connect")
+		return nil
+	}
+}
+
+// read returns the next packet sent by the client
+func (c *brokerConn) read(t *testing.T) packet {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(testTimeout))
+	p, err := readPacket(c.r, maxPacketBytes)
+	if err != nil {
+		t.Fatalf("read packet: %v", err)
+	}
+	return p
+}
+
+// expect reads the next packet and fails unless it is of the given type
+func (c *brokerConn) expect(t *testing.T, kind byte) packet {
+	t.Helper()
+	p := c.read(t)
+	if p.kind != kind {
+		t.Fatalf("got packet type %d, want %d", p.kind, kind)
+	}
+	return p
+}
+
+// handshake accepts the CONNECT with a successful CONNACK and returns it
+func (c *brokerConn) handshake(t *testing.T) packet {
+	t.Helper()
+	p := c.expect(t, packetConnect)
+	c.send(t, encodePacket(packetConnack, 0, []byte{0, 0}))
+	return p
+}
+
+func (c *brokerConn) send(t *testing.T, b []byte) {
+	t.Helper()
+	if _, err := c.Write(b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// readPublishes reads n PUBLISH packets and returns them by topic
+func (c *brokerConn) readPublishes(t *testing.T, n int) map[string]packet {
+	t.Helper()
+	out := map[string]packet{}
+	for range n {
+		p := c.expect(t, packetPublish)
+		topic, _, _, err := decodePublish(p)
+		if err != nil {
+			t.Fatalf("decode publish: %v", err)
+		}
+		out[topic] = p
+	}
+	return out
+}
+
+func testConfig(broker string) Config {
+	return Config{
+		Broker:          broker,
+		Username:        "frame",
+		Password:        "secret",
+		DeviceID:        "livingroom",
+		DeviceName:      "Flow Frame (livingroom)",
+		TopicPrefix:     "flow-frame/livingroom",
+		DiscoveryPrefix: "homeassistant",
+	}
+}
+
+func testStatus() api.Status {
+	nature := api.CollectionStatus{ID: "c1", Title: "Nature", Source: "remote"}
+	return api.Status{
+		Collection:  nature,
+		Video:       api.VideoStatus{Path: "/videos/ocean_waves.mp4"},
+		DisplayOn:   true,
+		Brightness:  0.8,
+		Speed:       1,
+		Interval:    "1h",
+		Collections: []api.CollectionStatus{nature, {ID: "c2", Title: "Holiday", Source: "local"}},
+	}
+}
+
+// startClient connects a client to a fresh broker and completes the handshake
+func startClient(t *testing.T) (*Client, *fakeBroker, *brokerConn) {
+	t.Helper()
+	broker := newFakeBroker(t)
+	c := NewClient(testConfig(broker.ln.Addr().String()))
+	c.PublishStatus(testStatus())
+	c.Start()
+
+	// Stop before the broker side closes so the client is not left redialling
+	conn := broker.accept(t)
+	t.Cleanup(c.Stop)
+	conn.handshake(t)
+	return c, broker, conn
+}
+
+// setTiming shortens keepAlive and minBackoff for the duration of a test
+func setTiming(t *testing.T, ping, backoff time.Duration) {
+	oldKeepAlive, oldBackoff := keepAlive, minBackoff
+	keepAlive, minBackoff = ping, backoff
+	t.Cleanup(func() { keepAlive, minBackoff = oldKeepAlive, oldBackoff })
+}
+
+func TestConnectPacket(t *testing.T) {
+	broker := newFakeBroker(t)
+	c := NewClient(testConfig(broker.ln.Addr().String()))
+	c.Start()
+
+	conn := broker.accept(t)
+	t.Cleanup(c.Stop)
+	p := conn.handshake(t)
+
+	body := p.body
+	proto, body, err := readString(body)
+	if err != nil || proto != "MQTT" {
+		t.Fatalf("protocol name = %q, %v", proto, err)
+	}
+	if level := body[0]; level != 4 {
+		t.Errorf("protocol level = %d, want 4", level)
+	}
+	// user name, password, will retain, will flag, clean session
+	if flags := body[1]; flags != 0x80|0x40|0x20|0x04|0x02 {
+		t.Errorf("connect flags = %#x", flags)
+	}
+	if ka := binary.BigEndian.Uint16(body[2:]); ka != 60 {
+		t.Errorf("keepalive = %d, want 60", ka)
+	}
+	body = body[4:]
+
+	want := []string{
+		"flow-frame-livingroom",              // client id
+		"flow-frame/livingroom/availability", // will topic
+		"offline",                            // will payload
+		"frame",                              // user name
+		"secret",                             // password
+	}
+	for _, w := range want {
+		var got string
+		got, body, err = readString(body)
+		if err != nil {
+			t.Fatalf("reading %q: %v", w, err)
+		}
+		if got != w {
+			t.Errorf("got %q, want %q", got, w)
+		}
+	}
+	if len(body) != 0 {
+		t.Errorf("%d trailing bytes in CONNECT", len(body))
+	}
+}
+
+func TestConnectRefused(t *testing.T) {
+	broker := newFakeBroker(t)
+	c := NewClient(testConfig(broker.ln.Addr().String()))
+	conn, err := net.Dial("tcp", broker.ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s := &session{client: c, conn: conn, r: bufio.NewReader(conn)}
+
+	server := broker.accept(t)
+	go func() {
+		readPacket(server.r, maxPacketBytes)
+		server.Write(encodePacket(packetConnack, 0, []byte{0, 4}))
+	}()
+	if err := s.connect(); err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Fatalf("connect error = %v", err)
+	}
+}
+
+func TestDiscoveryIsRetained(t *testing.T) {
+	_, _, conn := startClient(t)
+
+	sub := conn.expect(t, packetSubscribe)
+	if sub.flags != 0x02 {
+		t.Errorf("SUBSCRIBE flags = %#x, want 0x02", sub.flags)
+	}
+
+	// availability, six discovery configs and the state
+	published := conn.readPublishes(t, 8)
+	for topic, p := range published {
+		if p.flags&0x01 == 0 {
+			t.Errorf("%s is not retained", topic)
+		}
+	}
+
+	if _, _, payload, _ := decodePublish(published["flow-frame/livingroom/availability"]); string(payload) != "online" {
+		t.Errorf("availability = %q, want online", payload)
+	}
+
+	for _, object := range []string{"switch/flow_frame_livingroom/power", "switch/flow_frame_livingroom/playback",
+		"select/flow_frame_livingroom/collection", "number/flow_frame_livingroom/brightness",
+		"sensor/flow_frame_livingroom/title", "button/flow_frame_livingroom/skip"} {
+		p, ok := published["homeassistant/"+object+"/config"]
+		if !ok {
+			t.Errorf("no discovery for %s", object)
+			continue
+		}
+		_, _, payload, _ := decodePublish(p)
+		var config map[string]any
+		if err := json.Unmarshal(payload, &config); err != nil {
+			t.Fatalf("%s: %v", object, err)
+		}
+		if config["availability_topic"] != "flow-frame/livingroom/availability" {
+			t.Errorf("%s availability_topic = %v", object, config["availability_topic"])
+		}
+		if !strings.HasPrefix(object, "button/") && config["state_topic"] != "flow-frame/livingroom/state" {
+			t.Errorf("%s state_topic = %v", object, config["state_topic"])
+		}
+		if object == "select/flow_frame_livingroom/collection" {
+			options, _ := json.Marshal(config["options"])
+			if string(options) != `["Nature","Holiday (local)"]` {
+				t.Errorf("collection options = %s", options)
+			}
+		}
+	}
+
+	_, _, payload, _ := decodePublish(published["flow-frame/livingroom/state"])
+	var st map[string]any
+	if err := json.Unmarshal(payload, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st["power"] != "ON" || st["collection"] != "Nature" || st["brightness"] != 80.0 || st["title"] != "ocean waves" {
+		t.Errorf("state = %s", payload)
+	}
+}
+
+func TestCommandDispatch(t *testing.T) {
+	c, _, conn := startClient(t)
+	conn.expect(t, packetSubscribe)
+	conn.readPublishes(t, 8)
+
+	publish := func(topic, payload string) {
+		conn.send(t, encodePublish(topic, []byte(payload), false))
+	}
+	next := func() api.Command {
+		t.Helper()
+		select {
+		case cmd := <-c.Commands():
+			return cmd
+		case <-time.After(testTimeout):
+			t.Fatal("no command received")
+			return api.Command{}
+		}
+	}
+
+	publish("flow-frame/livingroom/power/set", "OFF")
+	if cmd := next(); cmd.Kind != api.CommandPower || cmd.On {
+		t.Errorf("power OFF = %+v", cmd)
+	}
+
+	// Unknown commands and values are dropped without closing the connection
+	publish("flow-frame/livingroom/volume/set", "11")
+	publish("flow-frame/livingroom/collection/set", "Unknown")
+
+	publish("flow-frame/livingroom/collection/set", "Holiday (local)")
+	if cmd := next(); cmd.Kind != api.CommandSelectCollection || cmd.CollectionID != "c2" {
+		t.Errorf("collection = %+v", cmd)
+	}
+
+	publish("flow-frame/livingroom/brightness/set", "45")
+	if cmd := next(); cmd.Kind != api.CommandUpdateSettings || cmd.Settings.Brightness == nil || *cmd.Settings.Brightness != 0.45 {
+		t.Errorf("brightness = %+v", cmd)
+	}
+
+	// QoS 1 messages are acknowledged
+	body := appendString(nil, "flow-frame/livingroom/skip/set")
+	body = binary.BigEndian.AppendUint16(body, 7)
+	conn.send(t, encodePacket(packetPublish, 0x02, append(body, "PRESS"...)))
+	if cmd := next(); cmd.Kind != api.CommandSkip {
+		t.Errorf("skip = %+v", cmd)
+	}
+	ack := conn.expect(t, packetPuback)
+	if id := binary.BigEndian.Uint16(ack.body); id != 7 {
+		t.Errorf("PUBACK id = %d, want 7", id)
+	}
+
+	// A Home Assistant restart triggers discovery again
+	publish("homeassistant/status", "online")
+	if published := conn.readPublishes(t, 7); len(published) != 7 {
+		t.Errorf("rediscovery published %d topics, want 7", len(published))
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	setTiming(t, 200*time.Millisecond, 50*time.Millisecond)
+	_, broker, conn := startClient(t)
+	conn.expect(t, packetSubscribe)
+	conn.readPublishes(t, 8)
+
+	// Answered pings keep the connection open
+	for range 3 {
+		conn.expect(t, packetPingreq)
+		conn.send(t, encodePacket(packetPingresp, 0, nil))
+	}
+
+	// A broker that stops answering is dropped and dialled again
+	for {
+		conn.SetReadDeadline(time.Now().Add(testTimeout))
+		if _, err := readPacket(conn.r, maxPacketBytes); err != nil {
+			break
+		}
+	}
+	broker.accept(t).expect(t, packetConnect)
+}
+
+func TestResubscribeAfterReconnect(t *testing.T) {
+	setTiming(t, keepAlive, 50*time.Millisecond)
+	_, broker, conn := startClient(t)
+	first := conn.expect(t, packetSubscribe)
+	conn.readPublishes(t, 8)
+
+	conn.Close()
+
+	conn = broker.accept(t)
+	conn.handshake(t)
+	second := conn.expect(t, packetSubscribe)
+	if !slices.Equal(subscribeFilters(t, first), subscribeFilters(t, second)) {
+		t.Errorf("resubscribed to %v, want %v", subscribeFilters(t, second), subscribeFilters(t, first))
+	}
+	want := []string{"flow-frame/livingroom/+/set", "homeassistant/status"}
+	if got := subscribeFilters(t, second); !slices.Equal(got, want) {
+		t.Errorf("filters = %v, want %v", got, want)
+	}
+
+	// Availability, discovery and state are published again on the new connection
+	published := conn.readPublishes(t, 8)
+	if _, ok := published["flow-frame/livingroom/state"]; !ok {
+		t.Error("state not republished after reconnect")
+	}
+}
+
+// subscribeFilters returns the topic filters of a SUBSCRIBE packet
+func subscribeFilters(t *testing.T, p packet) []string {
+	t.Helper()
+	var filters []string
+	rest := p.body[2:] // packet id
+	for len(rest) > 0 {
+		filter, r, err := readString(rest)
+		if err != nil || len(r) == 0 {
+			t.Fatalf("malformed SUBSCRIBE: %v", err)
+		}
+		filters = append(filters, filter)
+		rest = r[1:] // requested QoS
+	}
+	return filters
+}
+
+func TestParseBroker(t *testing.T) {
+	tests := []struct {
+		broker string
+		addr   string
+		tls    bool
+	}{
+		{"broker.local", "broker.local:1883", false},
+		{"broker.local:1884", "broker.local:1884", false},
+		{"mqtt://10.0.0.2", "10.0.0.2:1883", false},
+		{"mqtts://broker.local", "broker.local:8883", true},
+		{"ssl://broker.local:9883", "broker.local:9883", true},
+	}
+	for _, tt := range tests {
+		addr, useTLS, err := parseBroker(tt.broker)
+		if err != nil || addr != tt.addr || useTLS != tt.tls {
+			t.Errorf("parseBroker(%q) = %q, %v, %v; want %q, %v", tt.broker, addr, useTLS, err, tt.addr, tt.tls)
+		}
+	}
+	if _, _, err := parseBroker("ws://broker.local"); err == nil {
+		t.Error("parseBroker accepted ws://")
+	}
+}
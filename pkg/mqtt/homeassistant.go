@@ -0,0 +1,155 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"flow-frame/pkg/api"
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/sysinfo"
+)
+
+// state is the retained payload of <prefix>/state that every entity reads
+type state struct {
+	Power      string  `json:"power"`    // ON or OFF
+	Playback   string  `json:"playback"` // ON while playing, OFF while paused
+	Collection string  `json:"collection"`
+	Brightness int     `json:"brightness"` // percent
+	Title      string  `json:"title"`
+	Speed      float64 `json:"speed"`
+	Interval   string  `json:"interval"`
+
+	options []string // collection select options
+}
+
+// newState derives the MQTT state from an API status
+func newState(status api.Status) state {
+	st := state{
+		Power:      onOff(status.DisplayOn),
+		Playback:   onOff(!status.Paused),
+		Collection: collectionOption(status.Collection),
+		Brightness: int(math.Round(status.Brightness * 100)),
+		Title:      videoTitle(status.Video.Path),
+		Speed:      status.Speed,
+		Interval:   status.Interval,
+	}
+	for _, c := range status.Collections {
+		st.options = append(st.options, collectionOption(c))
+	}
+	return st
+}
+
+// collectionOption is how a collection appears in the Home Assistant select
+func collectionOption(c api.CollectionStatus) string {
+	if c.Source == "local" {
+		return c.Title + " (local)"
+	}
+	return c.Title
+}
+
+// videoTitle turns a file name into a readable title
+func videoTitle(path string) string {
+	name := filepath.Base(path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.NewReplacer("_", " ", "-", " ").Replace(name)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
+
+// entity is one Home Assistant discovery announcement
+type entity struct {
+	component string
+	object    string
+	config    map[string]any
+}
+
+// publishDiscovery announces the frame's entities to Home Assistant. All
+// entities belong to one device and read the shared state topic.
+func (s *session) publishDiscovery(options []string) error {
+	c := s.client
+	device := map[string]any{
+		"identifiers":  []string{"flow_frame_" + c.cfg.DeviceID},
+		"name":         c.cfg.DeviceName,
+		"manufacturer": "Flow Frame",
+		"model":        "Flow Frame",
+		"sw_version":   buildinfo.Get().Version,
+		"hw_version":   sysinfo.Model(),
+	}
+	if options == nil {
+		options = []string{}
+	}
+
+	entities := []entity{
+		{"switch", "power", map[string]any{
+			"name":           "Display",
+			"icon":           "mdi:television",
+			"command_topic":  c.topic("power/set"),
+			"value_template": "{{ value_json.power }}",
+		}},
+		{"switch", "playback", map[string]any{
+			"name":           "Playing",
+			"icon":           "mdi:play-pause",
+			"command_topic":  c.topic("playback/set"),
+			"value_template": "{{ value_json.playback }}",
+		}},
+		{"select", "collection", map[string]any{
+			"name":           "Collection",
+			"icon":           "mdi:folder-play",
+			"command_topic":  c.topic("collection/set"),
+			"value_template": "{{ value_json.collection }}",
+			"options":        options,
+		}},
+		{"number", "brightness", map[string]any{
+			"name":                "Brightness",
+			"icon":                "mdi:brightness-6",
+			"command_topic":       c.topic("brightness/set"),
+			"value_template":      "{{ value_json.brightness }}",
+			"min":                 5,
+			"max":                 100,
+			"step":                5,
+			"mode":                "slider",
+			"unit_of_measurement": "%",
+		}},
+		{"sensor", "title", map[string]any{
+			"name":           "Now playing",
+			"icon":           "mdi:filmstrip",
+			"value_template": "{{ value_json.title }}",
+		}},
+		{"button", "skip", map[string]any{
+			"name":          "Next video",
+			"icon":          "mdi:skip-next",
+			"command_topic": c.topic("skip/set"),
+		}},
+	}
+
+	for _, e := range entities {
+		e.config["unique_id"] = fmt.Sprintf("flow_frame_%s_%s", c.cfg.DeviceID, e.object)
+		e.config["object_id"] = fmt.Sprintf("flow_frame_%s_%s", c.cfg.DeviceID, e.object)
+		e.config["availability_topic"] = c.topic("availability")
+		e.config["device"] = device
+		if e.component != "button" {
+			e.config["state_topic"] = c.topic("state")
+		}
+
+		payload, err := json.Marshal(e.config)
+		if err != nil {
+			return fmt.Errorf("failed to encode discovery for %s: %w", e.object, err)
+		}
+		topic := fmt.Sprintf("%s/%s/flow_frame_%s/%s/config", c.cfg.DiscoveryPrefix, e.component, c.cfg.DeviceID, e.object)
+		if err := s.publish(topic, payload, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
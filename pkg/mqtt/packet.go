@@ -0,0 +1,200 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types (upper nibble of the fixed header)
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+	maxRemainingBytes = 268435455
+)
+
+// connackReasons describes CONNACK return codes
+var connackReasons = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// packet is a decoded control packet
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// connectOptions are the fields of a CONNECT packet
+type connectOptions struct {
+	clientID     string
+	username     string
+	password     string
+	keepAlive    uint16 // seconds
+	willTopic    string
+	willPayload  []byte
+	willRetain   bool
+	cleanSession bool
+}
+
+// encodeConnect builds a CONNECT packet
+func encodeConnect(o connectOptions) []byte {
+	var flags byte
+	if o.cleanSession {
+		flags |= 0x02
+	}
+	if o.willTopic != "" {
+		flags |= 0x04 // will flag, QoS 0
+		if o.willRetain {
+			flags |= 0x20
+		}
+	}
+	if o.password != "" {
+		flags |= 0x40
+	}
+	if o.username != "" {
+		flags |= 0x80
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags) // protocol level 4 = 3.1.1
+	body = binary.BigEndian.AppendUint16(body, o.keepAlive)
+	body = appendString(body, o.clientID)
+	if o.willTopic != "" {
+		body = appendString(body, o.willTopic)
+		body = appendBytes(body, o.willPayload)
+	}
+	if o.username != "" {
+		body = appendString(body, o.username)
+	}
+	if o.password != "" {
+		body = appendString(body, o.password)
+	}
+	return encodePacket(packetConnect, 0, body)
+}
+
+// encodePublish builds a QoS 0 PUBLISH packet
+func encodePublish(topic string, payload []byte, retain bool) []byte {
+	var flags byte
+	if retain {
+		flags = 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return encodePacket(packetPublish, flags, body)
+}
+
+// encodeSubscribe builds a SUBSCRIBE packet requesting QoS 0 for every filter
+func encodeSubscribe(id uint16, filters []string) []byte {
+	body := binary.BigEndian.AppendUint16(nil, id)
+	for _, f := range filters {
+		body = appendString(body, f)
+		body = append(body, 0)
+	}
+	return encodePacket(packetSubscribe, 0x02, body)
+}
+
+// encodePuback acknowledges a QoS 1 PUBLISH
+func encodePuback(id uint16) []byte {
+	return encodePacket(packetPuback, 0, binary.BigEndian.AppendUint16(nil, id))
+}
+
+// encodePacket prefixes a body with the fixed header
+func encodePacket(kind, flags byte, body []byte) []byte {
+	out := []byte{kind<<4 | flags}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+// readPacket reads one control packet
+func readPacket(r *bufio.Reader, maxSize int) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(b&0x7F) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if length > maxSize || length > maxRemainingBytes {
+		return packet{}, fmt.Errorf("packet too large (%d bytes)", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{kind: header >> 4, flags: header & 0x0F, body: body}, nil
+}
+
+// decodePublish splits a PUBLISH body into topic, packet id (0 for QoS 0) and payload
+func decodePublish(p packet) (topic string, id uint16, payload []byte, err error) {
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if qos := (p.flags >> 1) & 0x03; qos > 0 {
+		if len(rest) < 2 {
+			return "", 0, nil, errors.New("publish without packet id")
+		}
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+	return topic, id, rest, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+func appendBytes(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("truncated string")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errors.New("truncated string")
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
@@ -1,11 +1,15 @@
 package performance
 
 import (
-	"log"
 	"runtime"
 	"time"
+
+	"flow-frame/pkg/logging"
 )
 
+// logger is the performance subsystem logger
+var logger = logging.For("performance")
+
 // MemorySnapshot represents memory state at a point in time
 type MemorySnapshot struct {
 	Timestamp   time.Time
@@ -13,6 +17,17 @@ type MemorySnapshot struct {
 	AvailableMB uint64 // Available memory for use
 	UsedMB      uint64 // Currently used memory
 	FreeMB      uint64 // Free memory (not including buffers/cache)
+
+	CgroupLimitMB uint64 // Tightest cgroup memory limit, 0 when none applies
+}
+
+// MemoryStall is the share of time tasks were stalled waiting for memory,
+// from Linux pressure stall information (PSI), in percent
+type MemoryStall struct {
+	SomeAvg10 float64 // At least one task stalled, over the last 10 seconds
+	SomeAvg60 float64 // At least one task stalled, over the last 60 seconds
+	FullAvg10 float64 // All non-idle tasks stalled, over the last 10 seconds
+	FullAvg60 float64 // All non-idle tasks stalled, over the last 60 seconds
 }
 
 // GetAvailableMemoryMB returns only the available memory in MB
@@ -53,16 +68,47 @@ type MemoryPressureLevel int
 
 const (
 	MemoryPressureNone MemoryPressureLevel = iota // >800MB available
-	MemoryPressureLow                              // 400-800MB available
-	MemoryPressureMedium                           // 200-400MB available
-	MemoryPressureHigh                             // 100-200MB available
-	MemoryPressureCritical                         // <100MB available
+	MemoryPressureLow                              // 400-800MB available, or some stall >= 5%
+	MemoryPressureMedium                           // 200-400MB available, or some stall >= 20%
+	MemoryPressureHigh                             // 100-200MB available, or some stall >= 40% / full stall >= 5%
+	MemoryPressureCritical                         // <100MB available, or full stall >= 20%
 )
 
-// GetMemoryPressure returns the current memory pressure level
+// GetMemoryPressure returns the current memory pressure level. Where PSI is
+// available, time actually spent stalled on memory can raise the level above
+// what available memory alone suggests.
 func GetMemoryPressure() MemoryPressureLevel {
-	available := GetAvailableMemoryMB()
+	return currentPressure(GetAvailableMemoryMB())
+}
+
+// currentPressure returns the memory pressure level at the given available
+// memory, raised by the current stall information
+func currentPressure(available uint64) MemoryPressureLevel {
+	level := pressureFor(available)
+	if stall, ok := GetMemoryStall(); ok {
+		level = max(level, stallPressureFor(stall))
+	}
+	return level
+}
 
+// stallPressureFor returns the memory pressure level indicated by PSI stall averages
+func stallPressureFor(stall MemoryStall) MemoryPressureLevel {
+	switch {
+	case stall.FullAvg10 >= 20:
+		return MemoryPressureCritical
+	case stall.FullAvg10 >= 5 || stall.SomeAvg10 >= 40:
+		return MemoryPressureHigh
+	case stall.SomeAvg10 >= 20:
+		return MemoryPressureMedium
+	case stall.SomeAvg10 >= 5:
+		return MemoryPressureLow
+	default:
+		return MemoryPressureNone
+	}
+}
+
+// pressureFor returns the memory pressure level at the given available memory
+func pressureFor(available uint64) MemoryPressureLevel {
 	switch {
 	case available < 100:
 		return MemoryPressureCritical
@@ -99,10 +145,18 @@ func (m MemoryPressureLevel) String() string {
 func LogMemorySnapshot() {
 	sys := GetSystemMemory()
 	goMem := GetGoMemory()
-	pressure := GetMemoryPressure()
+	pressure := currentPressure(sys.AvailableMB)
 
-	log.Printf("Memory: System[Total=%dMB, Avail=%dMB, Used=%dMB, Free=%dMB] Go[Alloc=%dMB, Sys=%dMB, GC=%d] Pressure=%s",
-		sys.TotalMB, sys.AvailableMB, sys.UsedMB, sys.FreeMB,
-		goMem.AllocMB, goMem.SysMB, goMem.NumGC,
-		pressure.String())
+	attrs := []any{
+		"total_mb", sys.TotalMB, "available_mb", sys.AvailableMB, "used_mb", sys.UsedMB, "free_mb", sys.FreeMB,
+		"go_alloc_mb", goMem.AllocMB, "go_sys_mb", goMem.SysMB, "gc", goMem.NumGC,
+		"pressure", pressure.String(),
+	}
+	if sys.CgroupLimitMB > 0 {
+		attrs = append(attrs, "cgroup_limit_mb", sys.CgroupLimitMB)
+	}
+	if stall, ok := GetMemoryStall(); ok {
+		attrs = append(attrs, "psi_some_avg10", stall.SomeAvg10, "psi_full_avg10", stall.FullAvg10)
+	}
+	logger.Info("memory", attrs...)
 }
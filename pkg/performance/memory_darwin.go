@@ -4,7 +4,6 @@
 package performance
 
 import (
-	"log"
 	"runtime"
 	"time"
 )
@@ -31,8 +30,7 @@ func GetSystemMemory() MemorySnapshot {
 		availableMB = totalMB / 2 // Fallback to 50% available
 	}
 
-	log.Printf("GetSystemMemory[Darwin]: Using Go runtime stats (approximation). Alloc=%dMB Sys=%dMB",
-		allocMB, sysMB)
+	logger.Debug("system memory approximated from Go runtime stats", "alloc_mb", allocMB, "sys_mb", sysMB)
 
 	return MemorySnapshot{
 		Timestamp:   time.Now(),
@@ -42,3 +40,8 @@ func GetSystemMemory() MemorySnapshot {
 		FreeMB:      freeMB,
 	}
 }
+
+// GetMemoryStall reports no stall information; PSI is Linux only
+func GetMemoryStall() (stall MemoryStall, ok bool) {
+	return MemoryStall{}, false
+}
@@ -4,18 +4,97 @@
 package performance
 
 import (
-	"log"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// Kernel interfaces read for memory accounting; variables so tests can point them at fixtures
+var (
+	procMeminfo        = "/proc/meminfo"
+	procSelfCgroup     = "/proc/self/cgroup"
+	cgroupRoot         = "/sys/fs/cgroup"
+	procPressureMemory = "/proc/pressure/memory"
+)
+
 // GetSystemMemory retrieves current system memory information on Linux
-// Uses syscall.Sysinfo for accurate system-wide memory stats
+// Available memory is the kernel's MemAvailable estimate, which counts
+// reclaimable page cache, capped by the cgroup v2 limit when one applies
 func GetSystemMemory() MemorySnapshot {
+	snapshot, err := readMeminfo()
+	if err != nil {
+		logger.Debug("meminfo unavailable, falling back to sysinfo", "err", err)
+		snapshot = sysinfoMemory()
+	}
+
+	// Under systemd MemoryMax= (or a container limit) the cgroup runs out first
+	if limitMB, availableMB, ok := cgroupMemory(); ok {
+		snapshot.CgroupLimitMB = limitMB
+		snapshot.TotalMB = min(snapshot.TotalMB, limitMB)
+		snapshot.AvailableMB = min(snapshot.AvailableMB, availableMB)
+		snapshot.UsedMB = snapshot.TotalMB - min(snapshot.AvailableMB, snapshot.TotalMB)
+	}
+	return snapshot
+}
+
+// readMeminfo reads system memory from /proc/meminfo
+func readMeminfo() (MemorySnapshot, error) {
+	f, err := os.Open(procMeminfo)
+	if err != nil {
+		return MemorySnapshot{}, err
+	}
+	defer f.Close()
+
+	// Values are in kB
+	fields := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, rest, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(rest), " kB"), 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return MemorySnapshot{}, err
+	}
+
+	total, ok := fields["MemTotal"]
+	if !ok {
+		return MemorySnapshot{}, fmt.Errorf("%s has no MemTotal", procMeminfo)
+	}
+	available, ok := fields["MemAvailable"]
+	if !ok {
+		// Kernels before 3.14 have no estimate; free memory plus caches is close enough
+		available = fields["MemFree"] + fields["Buffers"] + fields["Cached"]
+	}
+	available = min(available, total)
+
+	return MemorySnapshot{
+		Timestamp:   time.Now(),
+		TotalMB:     total / 1024,
+		AvailableMB: available / 1024,
+		UsedMB:      (total - available) / 1024,
+		FreeMB:      fields["MemFree"] / 1024,
+	}, nil
+}
+
+// sysinfoMemory retrieves system memory with syscall.Sysinfo. It does not see
+// the page cache, so available memory is underestimated.
+func sysinfoMemory() MemorySnapshot {
 	var info syscall.Sysinfo_t
 	err := syscall.Sysinfo(&info)
 	if err != nil {
-		log.Printf("GetSystemMemory: failed to get sysinfo: %v", err)
+		logger.Warn("sysinfo failed", "err", err)
 		return MemorySnapshot{
 			Timestamp: time.Now(),
 		}
@@ -41,3 +120,113 @@ func GetSystemMemory() MemorySnapshot {
 		FreeMB:      freeMB,
 	}
 }
+
+// cgroupMemory returns the tightest cgroup v2 memory.max on the path from this
+// process's cgroup to the root, and the memory left under it. Inactive file
+// cache charged to the cgroup is reclaimable and counts as available. ok is
+// false on cgroup v1 or when no limit is set.
+func cgroupMemory() (limitMB, availableMB uint64, ok bool) {
+	data, err := os.ReadFile(procSelfCgroup)
+	if err != nil {
+		return 0, 0, false
+	}
+	var path string
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, isV2 := strings.CutPrefix(line, "0::"); isV2 {
+			path, found = rest, true
+			break
+		}
+	}
+	if !found {
+		return 0, 0, false
+	}
+
+	root := filepath.Clean(cgroupRoot)
+	for dir := filepath.Join(root, path); ; dir = filepath.Dir(dir) {
+		if limit, err := readCgroupValue(dir, "memory.max"); err == nil {
+			current, _ := readCgroupValue(dir, "memory.current")
+			headroom := uint64(0)
+			if limit > current {
+				headroom = limit - current
+			}
+			headroom = min(headroom+cgroupInactiveFile(dir), limit)
+			if !ok || limit/(1<<20) < limitMB {
+				limitMB = limit / (1 << 20)
+			}
+			if !ok || headroom/(1<<20) < availableMB {
+				availableMB = headroom / (1 << 20)
+			}
+			ok = true
+		}
+		if dir == root || len(dir) < len(root) {
+			break
+		}
+	}
+	return limitMB, availableMB, ok
+}
+
+// readCgroupValue reads a single number from a cgroup interface file. "max"
+// (no limit) is reported as an error.
+func readCgroupValue(dir, name string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// cgroupInactiveFile returns the inactive file cache charged to a cgroup in bytes
+func cgroupInactiveFile(dir string) uint64 {
+	data, err := os.ReadFile(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, found := strings.CutPrefix(line, "inactive_file "); found {
+			value, _ := strconv.ParseUint(strings.TrimSpace(rest), 10, 64)
+			return value
+		}
+	}
+	return 0
+}
+
+// GetMemoryStall returns the memory pressure stall information from
+// /proc/pressure/memory. ok is false on kernels without PSI.
+func GetMemoryStall() (stall MemoryStall, ok bool) {
+	data, err := os.ReadFile(procPressureMemory)
+	if err != nil {
+		return MemoryStall{}, false
+	}
+	// Lines look like: some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var avg10, avg60 *float64
+		switch fields[0] {
+		case "some":
+			avg10, avg60 = &stall.SomeAvg10, &stall.SomeAvg60
+		case "full":
+			avg10, avg60 = &stall.FullAvg10, &stall.FullAvg60
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "avg10":
+				*avg10 = v
+			case "avg60":
+				*avg60 = v
+			}
+		}
+		ok = true
+	}
+	return stall, ok
+}
@@ -0,0 +1,233 @@
+//go:build linux
+// +build linux
+
+package performance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeKernel points the memory interfaces at a temporary tree and writes files into it
+type fakeKernel struct {
+	t *testing.T
+}
+
+func newFakeKernel(t *testing.T) *fakeKernel {
+	t.Helper()
+	root := t.TempDir()
+	oldMeminfo, oldCgroup, oldRoot, oldPressure := procMeminfo, procSelfCgroup, cgroupRoot, procPressureMemory
+	procMeminfo = filepath.Join(root, "proc", "meminfo")
+	procSelfCgroup = filepath.Join(root, "proc", "self", "cgroup")
+	cgroupRoot = filepath.Join(root, "sys", "fs", "cgroup")
+	procPressureMemory = filepath.Join(root, "proc", "pressure", "memory")
+	t.Cleanup(func() {
+		procMeminfo, procSelfCgroup, cgroupRoot, procPressureMemory = oldMeminfo, oldCgroup, oldRoot, oldPressure
+	})
+	return &fakeKernel{t: t}
+}
+
+// write creates path with content; path is an absolute path inside the fake tree
+func (k *fakeKernel) write(path, content string) {
+	k.t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		k.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		k.t.Fatal(err)
+	}
+}
+
+// cgroup writes an interface file of the cgroup at path below the cgroup root
+func (k *fakeKernel) cgroup(path, name, content string) {
+	k.t.Helper()
+	k.write(filepath.Join(cgroupRoot, path, name), content+"\n")
+}
+
+const fixtureMeminfo = `MemTotal:        2000000 kB
+MemFree:          100000 kB
+MemAvailable:     900000 kB
+Buffers:           20000 kB
+Cached:           700000 kB
+SwapCached:            0 kB
+HugePages_Total:       0
+`
+
+func TestReadMeminfo(t *testing.T) {
+	k := newFakeKernel(t)
+	k.write(procMeminfo, fixtureMeminfo)
+
+	got, err := readMeminfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TotalMB != 1953 || got.AvailableMB != 878 || got.UsedMB != 1074 || got.FreeMB != 97 {
+		t.Errorf("snapshot = %+v", got)
+	}
+}
+
+func TestReadMeminfoWithoutMemAvailable(t *testing.T) {
+	k := newFakeKernel(t)
+	k.write(procMeminfo, "MemTotal: 2000000 kB\nMemFree: 100000 kB\nBuffers: 20000 kB\nCached: 700000 kB\n")
+
+	got, err := readMeminfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// free + buffers + cached
+	if got.AvailableMB != 820000/1024 {
+		t.Errorf("available = %d MB, want %d", got.AvailableMB, 820000/1024)
+	}
+}
+
+func TestReadMeminfoErrors(t *testing.T) {
+	k := newFakeKernel(t)
+	if _, err := readMeminfo(); err == nil {
+		t.Error("readMeminfo succeeded without /proc/meminfo")
+	}
+	k.write(procMeminfo, "MemFree: 100000 kB\n")
+	if _, err := readMeminfo(); err == nil {
+		t.Error("readMeminfo succeeded without MemTotal")
+	}
+}
+
+func TestReadCgroupValue(t *testing.T) {
+	k := newFakeKernel(t)
+	k.cgroup("limited", "memory.max", "536870912")
+	k.cgroup("unlimited", "memory.max", "max")
+
+	if v, err := readCgroupValue(filepath.Join(cgroupRoot, "limited"), "memory.max"); err != nil || v != 512<<20 {
+		t.Errorf("limited memory.max = %d, %v", v, err)
+	}
+	if _, err := readCgroupValue(filepath.Join(cgroupRoot, "unlimited"), "memory.max"); err == nil {
+		t.Error(`"max" was read as a limit`)
+	}
+	if _, err := readCgroupValue(filepath.Join(cgroupRoot, "missing"), "memory.max"); err == nil {
+		t.Error("missing file was read as a limit")
+	}
+}
+
+func TestCgroupMemory(t *testing.T) {
+	const service = "system.slice/flow-frame.service"
+
+	tests := []struct {
+		name         string
+		setup        func(k *fakeKernel)
+		limit, avail uint64
+		ok           bool
+	}{
+		{
+			name: "service limit",
+			setup: func(k *fakeKernel) {
+				k.cgroup(service, "memory.max", "536870912")     // 512MiB
+				k.cgroup(service, "memory.current", "419430400") // 400MiB
+				k.cgroup(service, "memory.stat", "anon 300000000\ninactive_file 33554432\nactive_file 1000")
+				k.cgroup("system.slice", "memory.max", "max")
+			},
+			limit: 512, avail: 144, ok: true, // 112MiB headroom + 32MiB inactive file cache
+		},
+		{
+			name: "tighter parent",
+			setup: func(k *fakeKernel) {
+				k.cgroup(service, "memory.max", "536870912")
+				k.cgroup(service, "memory.current", "419430400")
+				k.cgroup("system.slice", "memory.max", "268435456")     // 256MiB
+				k.cgroup("system.slice", "memory.current", "209715200") // 200MiB
+			},
+			limit: 256, avail: 56, ok: true,
+		},
+		{
+			name: "over the limit",
+			setup: func(k *fakeKernel) {
+				k.cgroup(service, "memory.max", "268435456")
+				k.cgroup(service, "memory.current", "300000000")
+			},
+			limit: 256, avail: 0, ok: true,
+		},
+		{
+			name: "no limit",
+			setup: func(k *fakeKernel) {
+				k.cgroup(service, "memory.max", "max")
+				k.cgroup(service, "memory.current", "419430400")
+				k.cgroup("system.slice", "memory.max", "max")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newFakeKernel(t)
+			k.write(procSelfCgroup, "0::/"+service+"\n")
+			tt.setup(k)
+
+			limit, avail, ok := cgroupMemory()
+			if limit != tt.limit || avail != tt.avail || ok != tt.ok {
+				t.Errorf("cgroupMemory() = %d, %d, %v; want %d, %d, %v", limit, avail, ok, tt.limit, tt.avail, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCgroupMemoryV1(t *testing.T) {
+	k := newFakeKernel(t)
+	k.write(procSelfCgroup, "12:memory:/system.slice/flow-frame.service\n11:cpu,cpuacct:/\n")
+	k.cgroup("system.slice/flow-frame.service", "memory.max", "536870912")
+
+	if _, _, ok := cgroupMemory(); ok {
+		t.Error("cgroup v1 hierarchy was read as v2")
+	}
+}
+
+func TestGetSystemMemoryCappedByCgroup(t *testing.T) {
+	k := newFakeKernel(t)
+	k.write(procMeminfo, fixtureMeminfo)
+	k.write(procSelfCgroup, "0::/flow-frame\n")
+	k.cgroup("flow-frame", "memory.max", "536870912")
+	k.cgroup("flow-frame", "memory.current", "419430400")
+
+	got := GetSystemMemory()
+	if got.TotalMB != 512 || got.AvailableMB != 112 || got.UsedMB != 400 || got.CgroupLimitMB != 512 {
+		t.Errorf("snapshot = %+v", got)
+	}
+}
+
+func TestGetMemoryStall(t *testing.T) {
+	k := newFakeKernel(t)
+	if _, ok := GetMemoryStall(); ok {
+		t.Error("stall reported without PSI")
+	}
+
+	k.write(procPressureMemory, "some avg10=22.50 avg60=10.00 avg300=2.00 total=123456\nfull avg10=1.25 avg60=0.50 avg300=0.10 total=4567\n")
+	stall, ok := GetMemoryStall()
+	if !ok {
+		t.Fatal("no stall read")
+	}
+	want := MemoryStall{SomeAvg10: 22.5, SomeAvg60: 10, FullAvg10: 1.25, FullAvg60: 0.5}
+	if stall != want {
+		t.Errorf("stall = %+v, want %+v", stall, want)
+	}
+
+	// Plenty of memory, but tasks stall waiting for it
+	if level := currentPressure(2000); level != MemoryPressureMedium {
+		t.Errorf("pressure = %s, want Medium", level)
+	}
+}
+
+func TestStallPressureFor(t *testing.T) {
+	tests := []struct {
+		stall MemoryStall
+		want  MemoryPressureLevel
+	}{
+		{MemoryStall{}, MemoryPressureNone},
+		{MemoryStall{SomeAvg10: 5}, MemoryPressureLow},
+		{MemoryStall{SomeAvg10: 20}, MemoryPressureMedium},
+		{MemoryStall{SomeAvg10: 40}, MemoryPressureHigh},
+		{MemoryStall{FullAvg10: 5}, MemoryPressureHigh},
+		{MemoryStall{FullAvg10: 20}, MemoryPressureCritical},
+	}
+	for _, tt := range tests {
+		if got := stallPressureFor(tt.stall); got != tt.want {
+			t.Errorf("stallPressureFor(%+v) = %s, want %s", tt.stall, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package performance
+
+import "flow-frame/pkg/metrics"
+
+var (
+	decodeSeconds = metrics.NewHistogram("flowframe_frame_decode_seconds",
+		"Time spent decoding a video frame", metrics.LatencyBuckets)
+	uploadSeconds = metrics.NewHistogram("flowframe_frame_upload_seconds",
+		"Time spent copying a decoded video frame into its texture", metrics.LatencyBuckets)
+	renderSeconds = metrics.NewHistogram("flowframe_frame_render_seconds",
+		"Time spent drawing a video frame", metrics.LatencyBuckets)
+	presentSeconds = metrics.NewHistogram("flowframe_frame_present_seconds",
+		"Time spent presenting a finished frame", metrics.LatencyBuckets)
+	intervalSeconds = metrics.NewHistogram("flowframe_frame_interval_seconds",
+		"Time between presented frames", metrics.LatencyBuckets)
+	frameSeconds = metrics.NewHistogram("flowframe_frame_seconds",
+		"Total time spent on a video frame, decode and render", metrics.LatencyBuckets)
+	framesTotal   = metrics.NewCounter("flowframe_frames_total", "Video frames processed, including dropped ones")
+	framesDropped = metrics.NewCounter("flowframe_frames_dropped_total", "Video frames dropped")
+	framesJank    = metrics.NewCounter("flowframe_frames_jank_total", "Presented frames that took longer than the jank threshold")
+
+	throttleEvents = metrics.NewCounter("flowframe_thermal_throttle_events_total", "Times the CPU started being throttled")
+)
+
+func init() {
+	metrics.Default.Register(metrics.CollectorFunc(collectMemory))
+	metrics.Default.Register(metrics.CollectorFunc(collectThermal))
+}
+
+// collectThermal reports zone temperatures, CPU frequencies and throttling
+func collectThermal() []metrics.Family {
+	snapshot := ReadThermal()
+	if len(snapshot.Zones) == 0 && len(snapshot.CPUs) == 0 {
+		return nil
+	}
+
+	temps := metrics.Family{Name: "flowframe_thermal_temperature_celsius", Help: "Thermal zone temperature", Type: metrics.TypeGauge}
+	for _, zone := range snapshot.Zones {
+		temps.Samples = append(temps.Samples, metrics.Sample{Labels: []metrics.Label{{Name: "zone", Value: zone.Name}}, Value: zone.TempC})
+	}
+	freqs := metrics.Family{Name: "flowframe_cpu_frequency_hertz", Help: "Current CPU frequency of a cpufreq policy", Type: metrics.TypeGauge}
+	limits := metrics.Family{Name: "flowframe_cpu_frequency_max_hertz", Help: "Highest CPU frequency currently allowed for a cpufreq policy", Type: metrics.TypeGauge}
+	for _, cpu := range snapshot.CPUs {
+		labels := []metrics.Label{{Name: "policy", Value: cpu.Policy}}
+		freqs.Samples = append(freqs.Samples, metrics.Sample{Labels: labels, Value: cpu.CurMHz * 1e6})
+		limits.Samples = append(limits.Samples, metrics.Sample{Labels: labels, Value: cpu.MaxMHz * 1e6})
+	}
+	throttled := 0.0
+	if snapshot.Throttled {
+		throttled = 1
+	}
+	return []metrics.Family{
+		temps,
+		freqs,
+		limits,
+		{Name: "flowframe_cpu_throttled", Help: "1 while the CPU is held below its maximum frequency", Type: metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: throttled}}},
+	}
+}
+
+// collectMemory reports system memory and the pressure level derived from it
+func collectMemory() []metrics.Family {
+	snapshot := GetSystemMemory()
+	gauge := func(name, help string, v float64) metrics.Family {
+		return metrics.Family{Name: name, Help: help, Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: v}}}
+	}
+	families := []metrics.Family{
+		gauge("flowframe_memory_total_bytes", "Total system memory, or the cgroup limit when lower", float64(snapshot.TotalMB)*(1<<20)),
+		gauge("flowframe_memory_available_bytes", "System memory available for use", float64(snapshot.AvailableMB)*(1<<20)),
+		gauge("flowframe_memory_pressure", "Memory pressure level: 0 none, 1 low, 2 medium, 3 high, 4 critical",
+			float64(currentPressure(snapshot.AvailableMB))),
+	}
+	if snapshot.CgroupLimitMB > 0 {
+		families = append(families, gauge("flowframe_memory_cgroup_limit_bytes",
+			"Tightest cgroup memory limit on the process", float64(snapshot.CgroupLimitMB)*(1<<20)))
+	}
+	if stall, ok := GetMemoryStall(); ok {
+		families = append(families, metrics.Family{
+			Name: "flowframe_memory_stall_ratio",
+			Help: "Share of the last 10 seconds tasks were stalled waiting for memory (PSI)",
+			Type: metrics.TypeGauge,
+			Samples: []metrics.Sample{
+				{Labels: []metrics.Label{{Name: "kind", Value: "some"}}, Value: stall.SomeAvg10 / 100},
+				{Labels: []metrics.Label{{Name: "kind", Value: "full"}}, Value: stall.FullAvg10 / 100},
+			},
+		})
+	}
+	return families
+}
@@ -88,15 +88,25 @@ func (r *RollingAverage) Reset() {
 	r.samples = make([]time.Duration, r.maxSamples)
 }
 
+// DefaultJankFactor is how many frame budgets a frame may take before it counts as jank
+const DefaultJankFactor = 2.0
+
 // PerformanceMonitor tracks video playback performance metrics
 type PerformanceMonitor struct {
-	frameDecodeTimes *RollingAverage
-	frameRenderTimes *RollingAverage
-	totalFrameTime   *RollingAverage
-	droppedFrames    int
-	totalFrames      int
-	startTime        time.Time
-	mu               sync.RWMutex
+	frameDecodeTimes  *RollingAverage
+	frameUploadTimes  *RollingAverage
+	frameRenderTimes  *RollingAverage
+	framePresentTimes *RollingAverage
+	totalFrameTime    *RollingAverage
+	frameIntervals    *RollingAverage
+	droppedFrames     int
+	totalFrames       int
+	jankFrames        int
+	budget            time.Duration // time available per displayed frame
+	jankFactor        float64
+	video             *videoStats // nil until StartVideo
+	startTime         time.Time
+	mu                sync.RWMutex
 }
 
 // PerformanceReport contains aggregated performance metrics
@@ -107,21 +117,64 @@ type PerformanceReport struct {
 	DropRate          float64 // Percentage of dropped frames
 	TotalFrames       int     // Total frames processed
 	DroppedFrames     int     // Total frames dropped
-	IsHealthy         bool    // True if performance is good (no drops, good timing)
+	IsHealthy         bool    // True if performance is good (no drops, no jank, good timing)
 	UptimeSeconds     int64   // Seconds since monitor started
+
+	// Percentiles over the window, in milliseconds
+	Decode   Percentiles
+	Upload   Percentiles
+	Render   Percentiles
+	Present  Percentiles
+	Total    Percentiles
+	Interval Percentiles // time between presented frames
+
+	BudgetMs   float64 // Frame budget in milliseconds
+	JankFrames int     // Total frames that took longer than the jank threshold
+	JankRate   float64 // Percentage of frames in the window that were jank
 }
 
 // NewMonitor creates a new performance monitor
 // windowSize determines how many frames to average (120 = 2 seconds at 60fps)
 func NewMonitor(windowSize int) *PerformanceMonitor {
 	return &PerformanceMonitor{
-		frameDecodeTimes: NewRollingAverage(windowSize),
-		frameRenderTimes: NewRollingAverage(windowSize),
-		totalFrameTime:   NewRollingAverage(windowSize),
-		startTime:        time.Now(),
+		frameDecodeTimes:  NewRollingAverage(windowSize),
+		frameUploadTimes:  NewRollingAverage(windowSize),
+		frameRenderTimes:  NewRollingAverage(windowSize),
+		framePresentTimes: NewRollingAverage(windowSize),
+		totalFrameTime:    NewRollingAverage(windowSize),
+		frameIntervals:    NewRollingAverage(windowSize),
+		budget:            time.Second / 60,
+		jankFactor:        DefaultJankFactor,
+		startTime:         time.Now(),
 	}
 }
 
+// SetFrameBudget sets the time available per displayed frame (1/fps of the render loop)
+func (p *PerformanceMonitor) SetFrameBudget(budget time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if budget > 0 {
+		p.budget = budget
+	}
+}
+
+// SetJankFactor sets how many frame budgets a frame may take before it counts as jank
+func (p *PerformanceMonitor) SetJankFactor(factor float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if factor > 1 {
+		p.jankFactor = factor
+	}
+}
+
+// jankThresholdLocked returns the frame interval above which a frame is jank
+// Must be called with p.mu held
+func (p *PerformanceMonitor) jankThresholdLocked() time.Duration {
+	return time.Duration(float64(p.budget) * p.jankFactor)
+}
+
 // RecordFrameDecode records the time taken to decode a frame
 func (p *PerformanceMonitor) RecordFrameDecode(duration time.Duration) {
 	p.mu.Lock()
@@ -129,6 +182,24 @@ func (p *PerformanceMonitor) RecordFrameDecode(duration time.Duration) {
 
 	p.frameDecodeTimes.Add(duration)
 	p.totalFrames++
+	decodeSeconds.ObserveDuration(duration)
+	framesTotal.Inc()
+	if p.video != nil {
+		p.video.decode.Add(duration)
+		p.video.frames++
+	}
+}
+
+// RecordFrameUpload records the time taken to copy a decoded frame into its texture
+func (p *PerformanceMonitor) RecordFrameUpload(duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.frameUploadTimes.Add(duration)
+	uploadSeconds.ObserveDuration(duration)
+	if p.video != nil {
+		p.video.upload.Add(duration)
+	}
 }
 
 // RecordFrameRender records the time taken to render a frame
@@ -137,6 +208,43 @@ func (p *PerformanceMonitor) RecordFrameRender(duration time.Duration) {
 	defer p.mu.Unlock()
 
 	p.frameRenderTimes.Add(duration)
+	renderSeconds.ObserveDuration(duration)
+	if p.video != nil {
+		p.video.render.Add(duration)
+	}
+}
+
+// RecordFramePresent records the time taken to present a finished frame
+func (p *PerformanceMonitor) RecordFramePresent(duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.framePresentTimes.Add(duration)
+	presentSeconds.ObserveDuration(duration)
+	if p.video != nil {
+		p.video.present.Add(duration)
+	}
+}
+
+// RecordFrameInterval records the time between two presented frames. Intervals
+// longer than the jank threshold are counted as jank.
+func (p *PerformanceMonitor) RecordFrameInterval(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.frameIntervals.Add(interval)
+	intervalSeconds.ObserveDuration(interval)
+	jank := interval > p.jankThresholdLocked()
+	if jank {
+		p.jankFrames++
+		framesJank.Inc()
+	}
+	if p.video != nil {
+		p.video.interval.Add(interval)
+		if jank {
+			p.video.janks++
+		}
+	}
 }
 
 // RecordTotalFrameTime records the total time for decode + render
@@ -145,6 +253,10 @@ func (p *PerformanceMonitor) RecordTotalFrameTime(duration time.Duration) {
 	defer p.mu.Unlock()
 
 	p.totalFrameTime.Add(duration)
+	frameSeconds.ObserveDuration(duration)
+	if p.video != nil {
+		p.video.total.Add(duration)
+	}
 }
 
 // RecordFrameDropped increments the dropped frame counter
@@ -154,6 +266,12 @@ func (p *PerformanceMonitor) RecordFrameDropped() {
 
 	p.droppedFrames++
 	p.totalFrames++
+	framesDropped.Inc()
+	framesTotal.Inc()
+	if p.video != nil {
+		p.video.dropped++
+		p.video.frames++
+	}
 }
 
 // GetReport generates a performance report with current metrics
@@ -170,10 +288,15 @@ func (p *PerformanceMonitor) GetReport() PerformanceReport {
 		dropRate = (float64(p.droppedFrames) / float64(p.totalFrames)) * 100.0
 	}
 
+	threshold := p.jankThresholdLocked()
+	jankRate := p.jankRateLocked()
+	total := p.totalFrameTime.Percentiles()
+
 	// Performance is healthy if:
 	// - Drop rate < 1%
-	// - Average total frame time < 33ms (30fps target)
-	isHealthy := dropRate < 1.0 && avgTotal.Milliseconds() < 33
+	// - Jank rate < 1%
+	// - 95% of frames finish update within the jank threshold
+	isHealthy := dropRate < 1.0 && jankRate < 1.0 && total.P95 < toMs(threshold)
 
 	return PerformanceReport{
 		AvgDecodeMs:   float64(avgDecode.Microseconds()) / 1000.0,
@@ -184,7 +307,35 @@ func (p *PerformanceMonitor) GetReport() PerformanceReport {
 		DroppedFrames: p.droppedFrames,
 		IsHealthy:     isHealthy,
 		UptimeSeconds: int64(time.Since(p.startTime).Seconds()),
+		Decode:        p.frameDecodeTimes.Percentiles(),
+		Upload:        p.frameUploadTimes.Percentiles(),
+		Render:        p.frameRenderTimes.Percentiles(),
+		Present:       p.framePresentTimes.Percentiles(),
+		Total:         total,
+		Interval:      p.frameIntervals.Percentiles(),
+		BudgetMs:      toMs(p.budget),
+		JankFrames:    p.jankFrames,
+		JankRate:      jankRate,
+	}
+}
+
+// JankRate returns the percentage of frames in the window that were jank. It
+// is cheaper than GetReport for callers that need it every frame.
+func (p *PerformanceMonitor) JankRate() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.jankRateLocked()
+}
+
+// jankRateLocked returns the jank percentage over the interval window
+// Must be called with p.mu held
+func (p *PerformanceMonitor) jankRateLocked() float64 {
+	n := p.frameIntervals.Count()
+	if n == 0 {
+		return 0
 	}
+	return float64(p.frameIntervals.CountAbove(p.jankThresholdLocked())) / float64(n) * 100.0
 }
 
 // IsPerformanceDegrading returns true if performance metrics indicate problems
@@ -195,9 +346,11 @@ func (p *PerformanceMonitor) IsPerformanceDegrading() bool {
 	// - Drop rate > 5%
 	// - Average decode time > 30ms (too slow)
 	// - Average total time > 40ms (missing 30fps target by a lot)
+	// - Jank rate > 5%
 	return report.DropRate > 5.0 ||
 	       report.AvgDecodeMs > 30.0 ||
-	       report.AvgTotalMs > 40.0
+	       report.AvgTotalMs > 40.0 ||
+	       report.JankRate > 5.0
 }
 
 // Reset clears all performance metrics
@@ -206,9 +359,13 @@ func (p *PerformanceMonitor) Reset() {
 	defer p.mu.Unlock()
 
 	p.frameDecodeTimes.Reset()
+	p.frameUploadTimes.Reset()
 	p.frameRenderTimes.Reset()
+	p.framePresentTimes.Reset()
 	p.totalFrameTime.Reset()
+	p.frameIntervals.Reset()
 	p.droppedFrames = 0
 	p.totalFrames = 0
+	p.jankFrames = 0
 	p.startTime = time.Now()
 }
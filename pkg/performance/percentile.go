@@ -0,0 +1,154 @@
+package performance
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Percentiles summarises a set of durations in milliseconds
+type Percentiles struct {
+	P50 float64
+	P95 float64
+	P99 float64
+	Max float64
+}
+
+// String formats the percentiles as p50/p95/p99/max
+func (p Percentiles) String() string {
+	return fmt.Sprintf("%.1f/%.1f/%.1f/%.1fms", p.P50, p.P95, p.P99, p.Max)
+}
+
+// nearestRank returns the 1-based rank of the q quantile among n samples: the
+// smallest rank with at least q of the samples at or below it
+func nearestRank(q float64, n int) int {
+	return min(max(int(math.Ceil(q*float64(n))), 1), n)
+}
+
+// percentilesOf computes nearest-rank percentiles of sorted durations
+func percentilesOf(sorted []time.Duration) Percentiles {
+	if len(sorted) == 0 {
+		return Percentiles{}
+	}
+	rank := func(q float64) float64 {
+		return toMs(sorted[nearestRank(q, len(sorted))-1])
+	}
+	return Percentiles{
+		P50: rank(0.50),
+		P95: rank(0.95),
+		P99: rank(0.99),
+		Max: toMs(sorted[len(sorted)-1]),
+	}
+}
+
+// Percentiles returns p50/p95/p99/max over the samples in the window
+func (r *RollingAverage) Percentiles() Percentiles {
+	r.mu.RLock()
+	count := r.index
+	if r.filled {
+		count = r.maxSamples
+	}
+	sorted := make([]time.Duration, count)
+	copy(sorted, r.samples[:count])
+	r.mu.RUnlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentilesOf(sorted)
+}
+
+// CountAbove returns how many samples in the window exceed limit
+func (r *RollingAverage) CountAbove(limit time.Duration) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := r.index
+	if r.filled {
+		count = r.maxSamples
+	}
+	above := 0
+	for _, d := range r.samples[:count] {
+		if d > limit {
+			above++
+		}
+	}
+	return above
+}
+
+const (
+	// distributionResolution is the bucket width of a Distribution
+	distributionResolution = 100 * time.Microsecond
+	// distributionBuckets covers 0-250ms; slower samples share the last bucket
+	distributionBuckets = 2500
+)
+
+// Distribution is a streaming percentile estimator with fixed memory. Samples
+// are counted in 0.1ms buckets, so percentiles of a whole video can be taken
+// without keeping every frame time. Max is exact.
+type Distribution struct {
+	buckets []uint32
+	count   int
+	max     time.Duration
+}
+
+// NewDistribution creates an empty distribution
+func NewDistribution() *Distribution {
+	return &Distribution{buckets: make([]uint32, distributionBuckets)}
+}
+
+// Add records a sample
+func (d *Distribution) Add(v time.Duration) {
+	i := int(v / distributionResolution)
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(d.buckets) {
+		i = len(d.buckets) - 1
+	}
+	d.buckets[i]++
+	d.count++
+	if v > d.max {
+		d.max = v
+	}
+}
+
+// Count returns the number of samples recorded
+func (d *Distribution) Count() int {
+	return d.count
+}
+
+// Percentiles returns p50/p95/p99/max. Percentiles are the upper edge of the
+// bucket they fall in, capped at the maximum.
+func (d *Distribution) Percentiles() Percentiles {
+	if d.count == 0 {
+		return Percentiles{}
+	}
+	return Percentiles{
+		P50: d.quantile(0.50),
+		P95: d.quantile(0.95),
+		P99: d.quantile(0.99),
+		Max: toMs(d.max),
+	}
+}
+
+// quantile returns the nearest-rank q quantile in milliseconds
+func (d *Distribution) quantile(q float64) float64 {
+	target := nearestRank(q, d.count)
+	seen := 0
+	for i, n := range d.buckets {
+		seen += int(n)
+		if seen >= target {
+			upper := time.Duration(i+1) * distributionResolution
+			if upper > d.max {
+				upper = d.max
+			}
+			return toMs(upper)
+		}
+	}
+	return toMs(d.max)
+}
+
+// toMs converts a duration to fractional milliseconds
+func toMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
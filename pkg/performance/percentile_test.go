@@ -0,0 +1,99 @@
+package performance
+
+import (
+	"testing"
+	"time"
+)
+
+// millis returns the durations 1ms..n ms
+func millis(n int) []time.Duration {
+	d := make([]time.Duration, n)
+	for i := range d {
+		d[i] = time.Duration(i+1) * time.Millisecond
+	}
+	return d
+}
+
+func TestNearestRank(t *testing.T) {
+	tests := []struct {
+		q    float64
+		n    int
+		want int
+	}{
+		{0.50, 1, 1},
+		{0.99, 1, 1},
+		{0.50, 10, 5},
+		{0.95, 10, 10},
+		{0.95, 12, 12}, // 11.4 would round down, but only 11 of 12 samples are at or below rank 11
+		{0.95, 20, 19},
+		{0.99, 30, 30},
+		{0.99, 60, 60}, // 59.4
+		{0.99, 100, 99},
+		{0.95, 120, 114},
+		{0.99, 120, 119},
+		{0, 10, 1},
+		{1, 10, 10},
+	}
+	for _, tt := range tests {
+		if got := nearestRank(tt.q, tt.n); got != tt.want {
+			t.Errorf("nearestRank(%v, %d) = %d, want %d", tt.q, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPercentilesOf(t *testing.T) {
+	tests := []struct {
+		n    int
+		want Percentiles
+	}{
+		{0, Percentiles{}},
+		{1, Percentiles{P50: 1, P95: 1, P99: 1, Max: 1}},
+		{10, Percentiles{P50: 5, P95: 10, P99: 10, Max: 10}},
+		{12, Percentiles{P50: 6, P95: 12, P99: 12, Max: 12}},
+		{60, Percentiles{P50: 30, P95: 57, P99: 60, Max: 60}},
+		{200, Percentiles{P50: 100, P95: 190, P99: 198, Max: 200}},
+	}
+	for _, tt := range tests {
+		if got := percentilesOf(millis(tt.n)); got != tt.want {
+			t.Errorf("n=%d: percentiles = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRollingAveragePercentiles(t *testing.T) {
+	r := NewRollingAverage(12)
+	// The first samples are overwritten once the window is full
+	for _, d := range millis(30)[18:] {
+		r.Add(d)
+	}
+	for _, d := range millis(12) {
+		r.Add(d)
+	}
+	if want := (Percentiles{P50: 6, P95: 12, P99: 12, Max: 12}); r.Percentiles() != want {
+		t.Errorf("percentiles = %v, want %v", r.Percentiles(), want)
+	}
+	if n := r.CountAbove(10 * time.Millisecond); n != 2 {
+		t.Errorf("CountAbove(10ms) = %d, want 2", n)
+	}
+}
+
+func TestDistributionPercentiles(t *testing.T) {
+	d := NewDistribution()
+	if d.Percentiles() != (Percentiles{}) {
+		t.Errorf("empty distribution: %v", d.Percentiles())
+	}
+	for _, v := range millis(60) {
+		d.Add(v)
+	}
+	// Samples sit on bucket edges, so the upper edge of a bucket is the next 0.1ms
+	want := Percentiles{P50: 30.1, P95: 57.1, P99: 60, Max: 60}
+	if got := d.Percentiles(); got != want || d.Count() != 60 {
+		t.Errorf("percentiles = %v over %d samples, want %v over 60", got, d.Count(), want)
+	}
+
+	// Slow samples share the last bucket but the maximum stays exact
+	d.Add(time.Second)
+	if got := d.Percentiles(); got.Max != 1000 || got.P50 != 31.1 {
+		t.Errorf("with a slow sample: %v", got)
+	}
+}
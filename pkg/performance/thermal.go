@@ -0,0 +1,324 @@
+package performance
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// thermalWarnMargin is how close (°C) a zone may get to its first passive
+	// or hot trip point before decode cost is lowered
+	thermalWarnMargin = 10.0
+	// thermalWarnTemp is the warm temperature for zones without such a trip point
+	thermalWarnTemp = 70.0
+	// thermalClearMargin is how far (°C) below the warm temperature every zone
+	// must cool before a warm CPU counts as normal again
+	thermalClearMargin = 5.0
+	// throttleReleaseSamples is how many samples in a row must show no
+	// throttling before a throttled CPU counts as merely warm
+	throttleReleaseSamples = 3
+)
+
+// EnvSysfsRoot overrides where sysfs is mounted
+const EnvSysfsRoot = "FLOW_FRAME_SYSFS_ROOT"
+
+var (
+	sysfsMu   sync.RWMutex
+	sysfsRoot = SysfsRootFromEnv()
+
+	// capBaseline is the highest scaling_max_freq (MHz) seen per cpufreq policy
+	// since start. Boards that cap the CPU below cpuinfo_max_freq for good are
+	// only throttled when the cap drops below it.
+	capBaseline = map[string]float64{}
+)
+
+// SysfsRootFromEnv returns the sysfs root from FLOW_FRAME_SYSFS_ROOT, or /sys
+func SysfsRootFromEnv() string {
+	if root := os.Getenv(EnvSysfsRoot); root != "" {
+		return root
+	}
+	return "/sys"
+}
+
+// SetSysfsRoot sets where sysfs is mounted. Thermal zones and cpufreq are read
+// below it, so the sensor can be run against a fake tree.
+func SetSysfsRoot(root string) {
+	if root == "" {
+		root = "/sys"
+	}
+	sysfsMu.Lock()
+	defer sysfsMu.Unlock()
+	if root != sysfsRoot {
+		sysfsRoot = root
+		capBaseline = map[string]float64{}
+	}
+}
+
+// sysfsPath joins elem below the sysfs root
+func sysfsPath(elem ...string) string {
+	sysfsMu.RLock()
+	defer sysfsMu.RUnlock()
+	return filepath.Join(append([]string{sysfsRoot}, elem...)...)
+}
+
+// ThermalZone is one temperature sensor
+type ThermalZone struct {
+	Name  string  // zone type, e.g. cpu-thermal
+	TempC float64 // current temperature
+	TripC float64 // lowest passive or hot trip point, 0 when the zone has none
+}
+
+// CPUFreq is the frequency state of one cpufreq policy
+type CPUFreq struct {
+	Policy   string  // e.g. policy0
+	CurMHz   float64 // current frequency
+	MaxMHz   float64 // frequency currently allowed (scaling_max_freq)
+	HwMaxMHz float64 // highest frequency the hardware supports (cpuinfo_max_freq)
+}
+
+// ThermalSnapshot represents thermal and CPU frequency state at a point in time
+type ThermalSnapshot struct {
+	Timestamp time.Time
+	Zones     []ThermalZone
+	CPUs      []CPUFreq
+	Throttled bool // a CPU cooling device is active or a policy limit dropped below its baseline
+}
+
+// ReadThermal reads the thermal zones, cpufreq policies and CPU cooling devices
+// below the sysfs root. Policy limits are compared with the highest limit read
+// since start, see capBaseline.
+func ReadThermal() ThermalSnapshot {
+	snapshot := ThermalSnapshot{Timestamp: time.Now()}
+
+	zones, _ := filepath.Glob(sysfsPath("class", "thermal", "thermal_zone*"))
+	sort.Strings(zones)
+	for _, dir := range zones {
+		milli, err := readSysfsInt(filepath.Join(dir, "temp"))
+		if err != nil {
+			continue
+		}
+		snapshot.Zones = append(snapshot.Zones, ThermalZone{
+			Name:  readSysfsString(filepath.Join(dir, "type"), filepath.Base(dir)),
+			TempC: float64(milli) / 1000,
+			TripC: firstTripC(dir),
+		})
+	}
+
+	policies, _ := filepath.Glob(sysfsPath("devices", "system", "cpu", "cpufreq", "policy*"))
+	sort.Strings(policies)
+	for _, dir := range policies {
+		cur, err := readSysfsInt(filepath.Join(dir, "scaling_cur_freq"))
+		if err != nil {
+			continue
+		}
+		limit, _ := readSysfsInt(filepath.Join(dir, "scaling_max_freq"))
+		hwMax, _ := readSysfsInt(filepath.Join(dir, "cpuinfo_max_freq"))
+		cpu := CPUFreq{
+			Policy:   filepath.Base(dir),
+			CurMHz:   float64(cur) / 1000,
+			MaxMHz:   float64(limit) / 1000,
+			HwMaxMHz: float64(hwMax) / 1000,
+		}
+		if belowBaseline(cpu) {
+			snapshot.Throttled = true
+		}
+		snapshot.CPUs = append(snapshot.CPUs, cpu)
+	}
+
+	// Thermal drivers throttle the CPU through cpufreq cooling devices
+	cooling, _ := filepath.Glob(sysfsPath("class", "thermal", "cooling_device*"))
+	for _, dir := range cooling {
+		kind := strings.ToLower(readSysfsString(filepath.Join(dir, "type"), ""))
+		if !strings.HasPrefix(kind, "cpufreq") && kind != "processor" {
+			continue
+		}
+		if state, err := readSysfsInt(filepath.Join(dir, "cur_state")); err == nil && state > 0 {
+			snapshot.Throttled = true
+		}
+	}
+
+	return snapshot
+}
+
+// belowBaseline records the policy limit and reports whether it is below the
+// highest limit seen since start
+func belowBaseline(cpu CPUFreq) bool {
+	if cpu.MaxMHz <= 0 {
+		return false
+	}
+	sysfsMu.Lock()
+	defer sysfsMu.Unlock()
+	baseline := capBaseline[cpu.Policy]
+	if cpu.MaxMHz > baseline {
+		capBaseline[cpu.Policy] = cpu.MaxMHz
+		return false
+	}
+	return cpu.MaxMHz < baseline
+}
+
+// firstTripC returns the lowest passive or hot trip point of a thermal zone in °C, 0 when there is none
+func firstTripC(zone string) float64 {
+	types, _ := filepath.Glob(filepath.Join(zone, "trip_point_*_type"))
+	trip := 0.0
+	for _, typePath := range types {
+		switch readSysfsString(typePath, "") {
+		case "passive", "hot":
+		default:
+			continue
+		}
+		milli, err := readSysfsInt(strings.TrimSuffix(typePath, "_type") + "_temp")
+		if err != nil || milli <= 0 {
+			continue
+		}
+		if c := float64(milli) / 1000; trip == 0 || c < trip {
+			trip = c
+		}
+	}
+	return trip
+}
+
+// MaxTempC returns the highest zone temperature, 0 without zones
+func (s ThermalSnapshot) MaxTempC() float64 {
+	max := 0.0
+	for _, zone := range s.Zones {
+		if zone.TempC > max {
+			max = zone.TempC
+		}
+	}
+	return max
+}
+
+// Level classifies the snapshot on its own; ThermalSensor adds hysteresis
+func (s ThermalSnapshot) Level() ThermalLevel {
+	switch {
+	case s.Throttled:
+		return ThermalThrottled
+	case s.warm(0):
+		return ThermalWarm
+	default:
+		return ThermalNormal
+	}
+}
+
+// warm reports whether any zone is within margin of its warm temperature
+func (s ThermalSnapshot) warm(margin float64) bool {
+	for _, zone := range s.Zones {
+		warm := thermalWarnTemp
+		if zone.TripC > 0 {
+			warm = zone.TripC - thermalWarnMargin
+		}
+		if zone.TempC >= warm-margin {
+			return true
+		}
+	}
+	return false
+}
+
+// ThermalLevel represents how close the CPU is to thermal throttling
+type ThermalLevel int
+
+const (
+	ThermalNormal    ThermalLevel = iota // well below any trip point
+	ThermalWarm                          // within thermalWarnMargin of a trip point; throttling is near
+	ThermalThrottled                     // the CPU is being held below its maximum frequency
+)
+
+// String returns a human-readable description of the thermal level
+func (t ThermalLevel) String() string {
+	switch t {
+	case ThermalNormal:
+		return "Normal"
+	case ThermalWarm:
+		return "Warm"
+	case ThermalThrottled:
+		return "Throttled"
+	default:
+		return "Unknown"
+	}
+}
+
+// ThermalSensor tracks the thermal level between readings and logs and counts
+// throttling episodes. Levels are lowered with hysteresis so a temperature or
+// cooling state hovering at a boundary does not flip the level every sample.
+type ThermalSensor struct {
+	level       ThermalLevel
+	unthrottled int // samples in a row without throttling while Throttled
+	mu          sync.Mutex
+}
+
+// Sample reads the sensors and returns the snapshot with the new level and the
+// level of the previous sample, so callers can react to transitions
+func (t *ThermalSensor) Sample() (snapshot ThermalSnapshot, level, previous ThermalLevel) {
+	snapshot = ReadThermal()
+
+	t.mu.Lock()
+	previous = t.level
+	level = t.nextLevelLocked(snapshot)
+	t.level = level
+	t.mu.Unlock()
+
+	if level == previous {
+		return snapshot, level, previous
+	}
+	switch level {
+	case ThermalThrottled:
+		throttleEvents.Inc()
+		logger.Warn("CPU is being throttled", "temp_c", snapshot.MaxTempC(), "from", previous.String())
+	case ThermalWarm:
+		logger.Info("CPU is nearing its thermal limit", "temp_c", snapshot.MaxTempC(), "from", previous.String())
+	default:
+		logger.Info("CPU temperature back to normal", "temp_c", snapshot.MaxTempC(), "from", previous.String())
+	}
+	return snapshot, level, previous
+}
+
+// nextLevelLocked classifies a snapshot given the current level. Throttling is
+// reported at once but only cleared after throttleReleaseSamples clean samples,
+// and a warm CPU must cool thermalClearMargin below the warm temperature.
+// Must be called with t.mu held
+func (t *ThermalSensor) nextLevelLocked(snapshot ThermalSnapshot) ThermalLevel {
+	if snapshot.Throttled {
+		t.unthrottled = 0
+		return ThermalThrottled
+	}
+	if t.level == ThermalThrottled {
+		t.unthrottled++
+		if t.unthrottled < throttleReleaseSamples {
+			return ThermalThrottled
+		}
+	}
+	if snapshot.warm(0) || (t.level >= ThermalWarm && snapshot.warm(thermalClearMargin)) {
+		return ThermalWarm
+	}
+	return ThermalNormal
+}
+
+// Level returns the level of the last sample
+func (t *ThermalSensor) Level() ThermalLevel {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.level
+}
+
+// readSysfsInt reads a single integer from a sysfs attribute
+func readSysfsInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readSysfsString reads a sysfs attribute, returning fallback when it cannot be read
+func readSysfsString(path, fallback string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fallback
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,232 @@
+package performance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// fakeSysfs is a sysfs tree in a temporary directory that the thermal sensor
+// reads through FLOW_FRAME_SYSFS_ROOT
+type fakeSysfs struct {
+	t    *testing.T
+	root string
+}
+
+func newFakeSysfs(t *testing.T) *fakeSysfs {
+	t.Helper()
+	sysfsMu.RLock()
+	old := sysfsRoot
+	sysfsMu.RUnlock()
+
+	root := t.TempDir()
+	t.Setenv(EnvSysfsRoot, root)
+	SetSysfsRoot(SysfsRootFromEnv())
+	t.Cleanup(func() { SetSysfsRoot(old) })
+	return &fakeSysfs{t: t, root: root}
+}
+
+// write creates a sysfs attribute below the root
+func (f *fakeSysfs) write(value string, elem ...string) {
+	f.t.Helper()
+	path := filepath.Join(append([]string{f.root}, elem...)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(value+"\n"), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// zone writes thermal_zone<n> with a temperature in °C and a passive trip point, if tripC > 0
+func (f *fakeSysfs) zone(n int, name string, tempC, tripC float64) {
+	f.t.Helper()
+	dir := fmt.Sprintf("thermal_zone%d", n)
+	f.write(name, "class", "thermal", dir, "type")
+	f.temp(n, tempC)
+	if tripC > 0 {
+		f.write("passive", "class", "thermal", dir, "trip_point_0_type")
+		f.write(milli(tripC), "class", "thermal", dir, "trip_point_0_temp")
+	}
+}
+
+// temp changes the temperature of thermal_zone<n>
+func (f *fakeSysfs) temp(n int, tempC float64) {
+	f.t.Helper()
+	f.write(milli(tempC), "class", "thermal", fmt.Sprintf("thermal_zone%d", n), "temp")
+}
+
+// policy writes a cpufreq policy with frequencies in MHz
+func (f *fakeSysfs) policy(name string, curMHz, maxMHz, hwMaxMHz int) {
+	f.t.Helper()
+	dir := []string{"devices", "system", "cpu", "cpufreq", name}
+	f.write(strconv.Itoa(curMHz*1000), append(dir, "scaling_cur_freq")...)
+	f.write(strconv.Itoa(maxMHz*1000), append(dir, "scaling_max_freq")...)
+	f.write(strconv.Itoa(hwMaxMHz*1000), append(dir, "cpuinfo_max_freq")...)
+}
+
+// cooling writes cooling_device<n> with its current state
+func (f *fakeSysfs) cooling(n int, kind string, state int) {
+	f.t.Helper()
+	dir := fmt.Sprintf("cooling_device%d", n)
+	f.write(kind, "class", "thermal", dir, "type")
+	f.write(strconv.Itoa(state), "class", "thermal", dir, "cur_state")
+}
+
+func milli(c float64) string {
+	return strconv.Itoa(int(c * 1000))
+}
+
+func TestReadThermal(t *testing.T) {
+	f := newFakeSysfs(t)
+	f.zone(0, "cpu-thermal", 52.5, 85)
+	f.write("critical", "class", "thermal", "thermal_zone0", "trip_point_1_type")
+	f.write("70000", "class", "thermal", "thermal_zone0", "trip_point_1_temp")
+	f.write("hot", "class", "thermal", "thermal_zone0", "trip_point_2_type")
+	f.write("80000", "class", "thermal", "thermal_zone0", "trip_point_2_temp")
+	f.zone(1, "ddr-thermal", 48, 0)
+	f.policy("policy0", 1200, 1800, 1800)
+
+	s := ReadThermal()
+	if len(s.Zones) != 2 {
+		t.Fatalf("zones = %+v", s.Zones)
+	}
+	// Critical trips are not where throttling starts; the hot trip is lower than the passive one
+	if want := (ThermalZone{Name: "cpu-thermal", TempC: 52.5, TripC: 80}); s.Zones[0] != want {
+		t.Errorf("zone 0 = %+v, want %+v", s.Zones[0], want)
+	}
+	if want := (ThermalZone{Name: "ddr-thermal", TempC: 48}); s.Zones[1] != want {
+		t.Errorf("zone 1 = %+v, want %+v", s.Zones[1], want)
+	}
+	if want := []CPUFreq{{Policy: "policy0", CurMHz: 1200, MaxMHz: 1800, HwMaxMHz: 1800}}; len(s.CPUs) != 1 || s.CPUs[0] != want[0] {
+		t.Errorf("cpus = %+v, want %+v", s.CPUs, want)
+	}
+	if s.MaxTempC() != 52.5 || s.Throttled || s.Level() != ThermalNormal {
+		t.Errorf("max %.1f°C, throttled %v, level %s", s.MaxTempC(), s.Throttled, s.Level())
+	}
+}
+
+func TestFixedFrequencyCapIsNotThrottling(t *testing.T) {
+	f := newFakeSysfs(t)
+	f.zone(0, "cpu-thermal", 45, 85)
+	// Some boards keep the CPU capped below its hardware maximum for good
+	f.policy("policy0", 1200, 1200, 1800)
+
+	for i := range 3 {
+		if s := ReadThermal(); s.Throttled {
+			t.Fatalf("sample %d: fixed cap reported as throttling", i)
+		}
+	}
+}
+
+func TestFrequencyCapDropIsThrottling(t *testing.T) {
+	f := newFakeSysfs(t)
+	f.zone(0, "cpu-thermal", 80, 85)
+	f.policy("policy0", 1200, 1200, 1800)
+	ReadThermal() // baseline
+
+	f.policy("policy0", 1000, 1000, 1800)
+	if s := ReadThermal(); !s.Throttled {
+		t.Error("cap below the baseline not reported as throttling")
+	}
+
+	f.policy("policy0", 1200, 1200, 1800)
+	if s := ReadThermal(); s.Throttled {
+		t.Error("cap back at the baseline still reported as throttling")
+	}
+}
+
+func TestCoolingDeviceThrottling(t *testing.T) {
+	f := newFakeSysfs(t)
+	f.zone(0, "cpu-thermal", 80, 85)
+	f.cooling(0, "Fan", 3) // not a CPU cooling device
+	f.cooling(1, "cpufreq-cpu0", 0)
+
+	if s := ReadThermal(); s.Throttled {
+		t.Error("idle cooling devices reported as throttling")
+	}
+	f.cooling(1, "cpufreq-cpu0", 2)
+	if s := ReadThermal(); !s.Throttled || s.Level() != ThermalThrottled {
+		t.Errorf("active cpufreq cooling device: throttled %v, level %s", s.Throttled, s.Level())
+	}
+}
+
+func TestThermalLevel(t *testing.T) {
+	f := newFakeSysfs(t)
+	f.zone(0, "cpu-thermal", 74.9, 85) // warm from 75°C
+	f.zone(1, "gpu-thermal", 60, 0)    // warm from 70°C
+
+	if level := ReadThermal().Level(); level != ThermalNormal {
+		t.Errorf("level = %s, want Normal", level)
+	}
+	f.temp(0, 75)
+	if level := ReadThermal().Level(); level != ThermalWarm {
+		t.Errorf("level near the trip point = %s, want Warm", level)
+	}
+	f.temp(0, 50)
+	f.temp(1, 70)
+	if level := ReadThermal().Level(); level != ThermalWarm {
+		t.Errorf("level of a hot zone without trip points = %s, want Warm", level)
+	}
+}
+
+func TestThermalSensorHysteresis(t *testing.T) {
+	f := newFakeSysfs(t)
+	f.zone(0, "cpu-thermal", 60, 85) // warm from 75°C, normal again below 70°C
+	f.cooling(0, "cpufreq-cpu0", 0)
+	var sensor ThermalSensor
+
+	steps := []struct {
+		tempC   float64
+		cooling int
+		want    ThermalLevel
+	}{
+		{60, 0, ThermalNormal},
+		{75, 0, ThermalWarm},
+		{74, 0, ThermalWarm}, // hovering below the warm temperature
+		{70, 0, ThermalWarm},
+		{69.5, 0, ThermalNormal},
+		{74.5, 0, ThermalNormal},
+		{80, 1, ThermalThrottled},
+		{80, 0, ThermalThrottled}, // the cooling device flickers off
+		{80, 1, ThermalThrottled},
+		{80, 0, ThermalThrottled},
+		{80, 0, ThermalThrottled},
+		{80, 0, ThermalWarm}, // three clean samples in a row
+		{72, 0, ThermalWarm},
+		{80, 1, ThermalThrottled},
+		{60, 0, ThermalThrottled},
+		{60, 0, ThermalThrottled},
+		{60, 0, ThermalNormal},
+	}
+	previous := ThermalNormal
+	for i, step := range steps {
+		f.temp(0, step.tempC)
+		f.cooling(0, "cpufreq-cpu0", step.cooling)
+
+		_, level, prev := sensor.Sample()
+		if prev != previous {
+			t.Errorf("step %d: previous = %s, want %s", i, prev, previous)
+		}
+		if level != step.want {
+			t.Errorf("step %d (%.1f°C, cooling %d): level = %s, want %s", i, step.tempC, step.cooling, level, step.want)
+		}
+		if sensor.Level() != level {
+			t.Errorf("step %d: Level() = %s, want %s", i, sensor.Level(), level)
+		}
+		previous = level
+	}
+}
+
+func TestSysfsRootFromEnv(t *testing.T) {
+	t.Setenv(EnvSysfsRoot, "")
+	if root := SysfsRootFromEnv(); root != "/sys" {
+		t.Errorf("default root = %q", root)
+	}
+	t.Setenv(EnvSysfsRoot, "/tmp/fake-sys")
+	if root := SysfsRootFromEnv(); root != "/tmp/fake-sys" {
+		t.Errorf("root = %q", root)
+	}
+}
@@ -0,0 +1,91 @@
+package performance
+
+import "time"
+
+// videoStats accumulates frame timings over the whole playback of one video
+type videoStats struct {
+	name     string
+	started  time.Time
+	frames   int
+	dropped  int
+	janks    int
+	decode   *Distribution
+	upload   *Distribution
+	render   *Distribution
+	present  *Distribution
+	total    *Distribution
+	interval *Distribution
+}
+
+// VideoSummary describes how smoothly one video played
+type VideoSummary struct {
+	Name            string
+	DurationSeconds float64
+	Frames          int     // Frames processed, including dropped ones
+	DroppedFrames   int     // Frames not decoded
+	PresentedFrames int     // Frames shown on screen
+	JankFrames      int     // Presented frames that took longer than the jank threshold
+	JankRate        float64 // Percentage of presented frames that were jank
+	BudgetMs        float64 // Frame budget in milliseconds
+	JankThresholdMs float64 // Frame interval above which a frame is jank
+
+	// Percentiles over the whole video, in milliseconds
+	Decode   Percentiles
+	Upload   Percentiles
+	Render   Percentiles
+	Present  Percentiles
+	Total    Percentiles
+	Interval Percentiles
+}
+
+// StartVideo begins collecting a per-video summary. A summary still open is discarded.
+func (p *PerformanceMonitor) StartVideo(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.video = &videoStats{
+		name:     name,
+		started:  time.Now(),
+		decode:   NewDistribution(),
+		upload:   NewDistribution(),
+		render:   NewDistribution(),
+		present:  NewDistribution(),
+		total:    NewDistribution(),
+		interval: NewDistribution(),
+	}
+}
+
+// EndVideo closes the per-video summary started by StartVideo. ok is false when no video was started.
+func (p *PerformanceMonitor) EndVideo() (summary VideoSummary, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	v := p.video
+	if v == nil {
+		return VideoSummary{}, false
+	}
+	p.video = nil
+
+	presented := v.interval.Count()
+	jankRate := 0.0
+	if presented > 0 {
+		jankRate = float64(v.janks) / float64(presented) * 100.0
+	}
+	return VideoSummary{
+		Name:            v.name,
+		DurationSeconds: time.Since(v.started).Seconds(),
+		Frames:          v.frames,
+		DroppedFrames:   v.dropped,
+		PresentedFrames: presented,
+		JankFrames:      v.janks,
+		JankRate:        jankRate,
+		BudgetMs:        toMs(p.budget),
+		JankThresholdMs: toMs(p.jankThresholdLocked()),
+		Decode:          v.decode.Percentiles(),
+		Upload:          v.upload.Percentiles(),
+		Render:          v.render.Percentiles(),
+		Present:         v.present.Percentiles(),
+		Total:           v.total.Percentiles(),
+		Interval:        v.interval.Percentiles(),
+	}, true
+}
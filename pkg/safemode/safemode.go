@@ -0,0 +1,182 @@
+// Package safemode detects crash loops. Every start is counted in a file in
+// the data directory and the count is cleared once the frame has run stably or
+// shut down cleanly. When too many starts in a row fail before that, the next
+// start enters safe mode and the video that was being opened is quarantined.
+package safemode
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"flow-frame/pkg/appdata"
+)
+
+const (
+	// stateName is the file in the data directory holding the start counter
+	stateName = "startup.json"
+	// StableAfter is how long the frame has to run before a start counts as successful
+	StableAfter = 2 * time.Minute
+)
+
+// state is persisted across starts
+type state struct {
+	Attempts   int      `json:"attempts"`             // starts since the last stable run
+	Binary     string   `json:"binary,omitempty"`     // fingerprint of the executable that made the attempts
+	Opening    string   `json:"opening,omitempty"`    // video most recently handed to the decoder
+	Quarantine []string `json:"quarantine,omitempty"` // file names that are never played again
+	SafeMode   bool     `json:"safeMode,omitempty"`   // stays set until ExitSafeMode
+}
+
+var (
+	mu      sync.Mutex
+	current state
+	active  bool
+)
+
+// Begin records a start attempt and reports whether the frame must start in
+// safe mode: either it already was in safe mode, or more than threshold
+// previous starts failed, in which case entered is true. A new executable
+// (e.g. after an update) gets a fresh count.
+func Begin(threshold int) (safe, entered bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	current = load()
+	binary := binaryFingerprint()
+	if current.Binary != binary {
+		if current.Attempts > 0 || current.SafeMode {
+			log.Printf("safemode: executable changed, clearing %d failed start(s)", current.Attempts)
+		}
+		current.Attempts = 0
+		current.SafeMode = false
+		current.Binary = binary
+	}
+
+	current.Attempts++
+	if !current.SafeMode && current.Attempts > threshold {
+		current.SafeMode = true
+		entered = true
+		if current.Opening != "" && !quarantined(current.Opening) {
+			current.Quarantine = append(current.Quarantine, current.Opening)
+		}
+		log.Printf("safemode: %d starts failed in a row, entering safe mode (last video: %q)", current.Attempts-1, current.Opening)
+	}
+	active = current.SafeMode
+	save()
+	return active, entered
+}
+
+// Active reports whether this run is in safe mode
+func Active() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return active
+}
+
+// FailedStarts returns the number of starts before this one that never became stable
+func FailedStarts() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return max(current.Attempts-1, 0)
+}
+
+// QuarantinedFiles returns the file names that are skipped during playback
+func QuarantinedFiles() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]string(nil), current.Quarantine...)
+}
+
+// IsQuarantined reports whether the file at path must not be played
+func IsQuarantined(path string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return quarantined(path)
+}
+
+func quarantined(path string) bool {
+	name := filepath.Base(path)
+	for _, q := range current.Quarantine {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Opening records the video about to be handed to the decoder, so it can be
+// quarantined if the decoder takes the process down
+func Opening(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	name := filepath.Base(path)
+	if current.Opening == name {
+		return
+	}
+	current.Opening = name
+	save()
+}
+
+// Stable clears the failed start count after the frame ran long enough or shut down cleanly
+func Stable() {
+	mu.Lock()
+	defer mu.Unlock()
+	if current.Attempts == 0 {
+		return
+	}
+	current.Attempts = 0
+	save()
+}
+
+// ExitSafeMode makes the next start a normal one; quarantined files stay skipped
+func ExitSafeMode() {
+	mu.Lock()
+	defer mu.Unlock()
+	current.SafeMode = false
+	current.Attempts = 0
+	current.Opening = ""
+	save()
+}
+
+// load reads the state; a missing or corrupt file starts from zero. Callers must hold mu.
+func load() state {
+	var st state
+	data, err := os.ReadFile(appdata.Path(stateName))
+	if err != nil {
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		log.Printf("safemode: ignoring corrupt %s: %v", stateName, err)
+		return state{}
+	}
+	return st
+}
+
+// save writes the state. Callers must hold mu.
+func save() {
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := appdata.WriteFileAtomic(appdata.Path(stateName), data, 0o644); err != nil {
+		log.Printf("safemode: failed to save %s: %v", stateName, err)
+	}
+}
+
+// binaryFingerprint identifies the running executable by size and modification time
+func binaryFingerprint() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	st, err := os.Stat(exe)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", st.Size(), st.ModTime().Unix())
+}
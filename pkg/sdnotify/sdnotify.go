@@ -0,0 +1,103 @@
+// Package sdnotify implements the systemd notification protocol (sd_notify)
+// for readiness, watchdog keep-alives and status text. Every call is a no-op
+// when the process was not started by systemd with NOTIFY_SOCKET set.
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification states understood by systemd
+const (
+	StateReady     = "READY=1"
+	StateReloading = "RELOADING=1"
+	StateStopping  = "STOPPING=1"
+	StateWatchdog  = "WATCHDOG=1"
+)
+
+// Notifier sends notifications to the service manager. It is not safe for
+// concurrent use; the game loop owns it.
+type Notifier struct {
+	socket   string        // NOTIFY_SOCKET, empty when disabled
+	interval time.Duration // how often to ping the watchdog, 0 when disabled
+
+	lastPing   time.Time
+	lastStatus string
+}
+
+// New creates a notifier from NOTIFY_SOCKET, WATCHDOG_USEC and WATCHDOG_PID.
+// Pings are sent at half the watchdog timeout, as sd_watchdog_enabled(3) recommends.
+func New() *Notifier {
+	n := &Notifier{socket: os.Getenv("NOTIFY_SOCKET")}
+	if timeout, ok := WatchdogTimeout(); ok {
+		n.interval = timeout / 2
+	}
+	return n
+}
+
+// Enabled reports whether notifications are delivered anywhere
+func (n *Notifier) Enabled() bool {
+	return n.socket != ""
+}
+
+// WatchdogTimeout returns the watchdog timeout systemd configured for this process
+func WatchdogTimeout() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// Notify sends one or more newline-separated assignments such as READY=1
+func (n *Notifier) Notify(states ...string) error {
+	if n.socket == "" {
+		return nil
+	}
+
+	addr := &net.UnixAddr{Name: n.socket, Net: "unixgram"}
+	if strings.HasPrefix(n.socket, "@") {
+		addr.Name = "\x00" + n.socket[1:] // abstract namespace socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(strings.Join(states, "\n")))
+	return err
+}
+
+// Ready tells systemd startup has finished, along with the current status
+func (n *Notifier) Ready(status string) error {
+	n.lastStatus = status
+	n.lastPing = time.Now()
+	return n.Notify(StateReady, "STATUS="+status)
+}
+
+// Watchdog pings the watchdog when half its timeout has passed since the last ping.
+// Call it once per completed frame so a hung loop stops the pings.
+func (n *Notifier) Watchdog() error {
+	if n.interval == 0 || time.Since(n.lastPing) < n.interval {
+		return nil
+	}
+	n.lastPing = time.Now()
+	return n.Notify(StateWatchdog)
+}
+
+// Status updates the status line shown by systemctl status when it changed
+func (n *Notifier) Status(status string) error {
+	if status == n.lastStatus {
+		return nil
+	}
+	n.lastStatus = status
+	return n.Notify("STATUS=" + status)
+}
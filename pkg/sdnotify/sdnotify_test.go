@@ -0,0 +1,181 @@
+package sdnotify
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// listen binds a fake notify socket and points NOTIFY_SOCKET at it
+func listen(t *testing.T) *net.UnixConn {
+	t.Helper()
+	// Socket paths are limited to 108 bytes, so avoid the long t.TempDir names
+	dir, err := os.MkdirTemp("", "sdnotify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+// receive returns the next datagram sent to the socket
+func receive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("no notification received: %v", err)
+	}
+	return string(buf[:n])
+}
+
+// expectNothing fails when a datagram arrives within a short wait
+func expectNothing(t *testing.T, conn *net.UnixConn) {
+	t.Helper()
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	n, err := conn.Read(buf)
+	if err == nil {
+		t.Fatalf("unexpected notification %q", buf[:n])
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("read: %v", err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	conn := listen(t)
+	n := New()
+	if !n.Enabled() {
+		t.Fatal("notifier disabled with NOTIFY_SOCKET set")
+	}
+
+	if err := n.Ready("Playing Nature"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := receive(t, conn), "READY=1\nSTATUS=Playing Nature"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	conn := listen(t)
+	n := New()
+
+	if err := n.Status("Downloading 3 videos"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, conn); got != "STATUS=Downloading 3 videos" {
+		t.Errorf("got %q", got)
+	}
+
+	// An unchanged status is not sent again
+	n.Status("Downloading 3 videos")
+	expectNothing(t, conn)
+
+	n.Status("Playing Nature")
+	if got := receive(t, conn); got != "STATUS=Playing Nature" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWatchdogRateLimit(t *testing.T) {
+	conn := listen(t)
+	t.Setenv("WATCHDOG_USEC", "200000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	n := New()
+
+	n.Ready("ok")
+	receive(t, conn)
+
+	// Pings are sent at half the timeout, counted from READY
+	n.Watchdog()
+	expectNothing(t, conn)
+
+	time.Sleep(110 * time.Millisecond)
+	for range 5 {
+		if err := n.Watchdog(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := receive(t, conn); got != "WATCHDOG=1" {
+		t.Errorf("got %q, want WATCHDOG=1", got)
+	}
+	expectNothing(t, conn)
+
+	time.Sleep(110 * time.Millisecond)
+	n.Watchdog()
+	if got := receive(t, conn); got != "WATCHDOG=1" {
+		t.Errorf("got %q, want WATCHDOG=1", got)
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	tests := []struct {
+		usec, pid string
+		want      time.Duration
+		ok        bool
+	}{
+		{"", "", 0, false},
+		{"0", "", 0, false},
+		{"garbage", "", 0, false},
+		{"30000000", "", 30 * time.Second, true},
+		{"30000000", strconv.Itoa(os.Getpid()), 30 * time.Second, true},
+		{"30000000", "1", 0, false}, // meant for another process
+	}
+	for _, tt := range tests {
+		t.Setenv("WATCHDOG_USEC", tt.usec)
+		t.Setenv("WATCHDOG_PID", tt.pid)
+		got, ok := WatchdogTimeout()
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("WATCHDOG_USEC=%q WATCHDOG_PID=%q: got %v, %v; want %v, %v", tt.usec, tt.pid, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDisabledWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	t.Setenv("WATCHDOG_USEC", "1")
+	n := New()
+	if n.Enabled() {
+		t.Fatal("notifier enabled without NOTIFY_SOCKET")
+	}
+
+	time.Sleep(time.Millisecond)
+	for _, err := range []error{n.Ready("ok"), n.Watchdog(), n.Status("busy"), n.Notify(StateStopping)} {
+		if err != nil {
+			t.Errorf("call without NOTIFY_SOCKET returned %v", err)
+		}
+	}
+}
+
+func TestAbstractSocket(t *testing.T) {
+	name := "@flow-frame-sdnotify-test-" + strconv.Itoa(os.Getpid())
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "\x00" + name[1:], Net: "unixgram"})
+	if err != nil {
+		t.Skipf("abstract sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", name)
+
+	if err := New().Notify(StateStopping); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, conn); got != StateStopping {
+		t.Errorf("got %q, want %q", got, StateStopping)
+	}
+}
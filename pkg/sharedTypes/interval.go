@@ -0,0 +1,173 @@
+package sharedTypes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IntervalMode selects what ends a video's turn on screen
+type IntervalMode string
+
+const (
+	IntervalDuration IntervalMode = "duration" // switch after a fixed wall-clock time
+	IntervalVideoEnd IntervalMode = "end"      // switch when the video finishes playing once
+	IntervalLoops    IntervalMode = "loops"    // switch after the video played N times
+)
+
+// Interval describes when playback moves on to the next video. It is stored
+// as text: a Go duration ("1h", "90m"), "end", or "N loops" (e.g. "3 loops").
+type Interval struct {
+	Mode     IntervalMode
+	Duration time.Duration // IntervalDuration only
+	Loops    int           // IntervalLoops only
+}
+
+// Every returns a fixed-time interval
+func Every(d time.Duration) Interval {
+	return Interval{Mode: IntervalDuration, Duration: d}
+}
+
+// OnVideoEnd returns an interval that switches once the video has played through
+func OnVideoEnd() Interval {
+	return Interval{Mode: IntervalVideoEnd}
+}
+
+// AfterLoops returns an interval that switches after n full plays
+func AfterLoops(n int) Interval {
+	return Interval{Mode: IntervalLoops, Loops: n}
+}
+
+// ParseInterval parses the text form produced by Interval.String
+func ParseInterval(text string) (Interval, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == string(IntervalVideoEnd):
+		return OnVideoEnd(), nil
+	case strings.HasSuffix(text, " loops") || strings.HasSuffix(text, " loop"):
+		n, err := strconv.Atoi(strings.Fields(text)[0])
+		if err != nil || n < 1 {
+			return Interval{}, fmt.Errorf("invalid loop count in interval %q", text)
+		}
+		return AfterLoops(n), nil
+	default:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return Interval{}, fmt.Errorf("invalid interval %q: %w", text, err)
+		}
+		if d <= 0 {
+			return Interval{}, fmt.Errorf("interval %q must be positive", text)
+		}
+		return Every(d), nil
+	}
+}
+
+// Valid reports whether the interval can be used for playback
+func (iv Interval) Valid() bool {
+	switch iv.Mode {
+	case IntervalDuration:
+		return iv.Duration > 0
+	case IntervalVideoEnd:
+		return true
+	case IntervalLoops:
+		return iv.Loops > 0
+	}
+	return false
+}
+
+// IsZero reports whether the interval is unset
+func (iv Interval) IsZero() bool {
+	return iv.Mode == ""
+}
+
+// LoopCount returns how many full plays end the video's turn, or 0 for fixed-time intervals
+func (iv Interval) LoopCount() int {
+	switch iv.Mode {
+	case IntervalVideoEnd:
+		return 1
+	case IntervalLoops:
+		return iv.Loops
+	}
+	return 0
+}
+
+// String returns the persisted text form
+func (iv Interval) String() string {
+	switch iv.Mode {
+	case IntervalVideoEnd:
+		return string(IntervalVideoEnd)
+	case IntervalLoops:
+		return fmt.Sprintf("%d loops", iv.Loops)
+	case IntervalDuration:
+		return formatDuration(iv.Duration)
+	}
+	return ""
+}
+
+// Label returns a human readable description for menus
+func (iv Interval) Label() string {
+	switch iv.Mode {
+	case IntervalVideoEnd:
+		return "On video end"
+	case IntervalLoops:
+		if iv.Loops == 1 {
+			return "After 1 loop"
+		}
+		return fmt.Sprintf("After %d loops", iv.Loops)
+	case IntervalDuration:
+		return "Every " + durationLabel(iv.Duration)
+	}
+	return "Not set"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (iv Interval) MarshalText() ([]byte, error) {
+	return []byte(iv.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (iv *Interval) UnmarshalText(text []byte) error {
+	parsed, err := ParseInterval(string(text))
+	if err != nil {
+		return err
+	}
+	*iv = parsed
+	return nil
+}
+
+// formatDuration trims the zero units time.Duration.String adds ("1h0m0s" -> "1h")
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// durationLabel describes d in the largest whole unit, e.g. "hour", "12 hours", "90 minutes"
+func durationLabel(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{7 * 24 * time.Hour, "week"},
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+		{time.Second, "second"},
+	}
+	for _, u := range units {
+		if d >= u.size && d%u.size == 0 {
+			n := int64(d / u.size)
+			if n == 1 {
+				return u.name
+			}
+			return fmt.Sprintf("%d %ss", n, u.name)
+		}
+	}
+	return d.String()
+}
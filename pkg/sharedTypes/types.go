@@ -1,10 +1,71 @@
 package sharedTypes
 
+// Collection sources. An empty Source means the collection lives in S3.
+const (
+	SourceS3    = "s3"
+	SourceLocal = "local"
+)
+
+// LoopMode selects how a video repeats while it is on screen
+type LoopMode string
+
+const (
+	LoopRepeat LoopMode = "repeat" // restart from the first frame
+	LoopBounce LoopMode = "bounce" // play forwards, then backwards
+)
+
+// FitMode selects how a video is scaled to the screen
+type FitMode string
+
+const (
+	FitContain FitMode = "contain" // letterbox, showing the whole frame
+	FitCover   FitMode = "cover"   // fill the screen, cropping the edges
+	FitStretch FitMode = "stretch" // fill the screen, ignoring the aspect ratio
+)
+
 type Collection struct {
 	Id          string `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description,omitempty"`
 	Bucket      string `json:"bucket"`
-	Folder      string `json:"folder"`
+	Folder      string `json:"folder"` // S3 prefix, or an absolute directory for local collections
 	BounceLoop  bool   `json:"bounceLoop,omitempty"`
+	Source      string `json:"source,omitempty"`
+
+	// Playback overrides; zero values fall back to the user's settings
+	Speed    float64   `json:"speed,omitempty"`    // playback rate multiplier
+	Interval *Interval `json:"interval,omitempty"` // when to move on to the next video
+	Loop     LoopMode  `json:"loop,omitempty"`     // takes precedence over BounceLoop
+	Fit      FitMode   `json:"fit,omitempty"`
+}
+
+// IsLocal reports whether the collection is played straight from local storage
+func (c Collection) IsLocal() bool {
+	return c.Source == SourceLocal
+}
+
+// LoopMode returns the effective loop mode of the collection
+func (c Collection) LoopMode() LoopMode {
+	switch {
+	case c.Loop != "":
+		return c.Loop
+	case c.BounceLoop:
+		return LoopBounce
+	default:
+		return LoopRepeat
+	}
+}
+
+// FitMode returns the effective fit mode of the collection
+func (c Collection) FitMode() FitMode {
+	if c.Fit == "" {
+		return FitContain
+	}
+	return c.Fit
+}
+
+// CollectionItem is a single playable object within a collection.
+type CollectionItem struct {
+	Key    string  `json:"key"`
+	Weight float64 `json:"weight,omitempty"` // relative pick weight for weighted playback, defaults to 1
 }
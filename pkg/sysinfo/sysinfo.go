@@ -0,0 +1,72 @@
+// Package sysinfo reports facts about the device the frame runs on
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// started approximates the process start time
+var started = time.Now()
+
+var (
+	modelOnce sync.Once
+	model     string
+)
+
+// Model returns the board model from the device tree, e.g. "Radxa ZERO", or
+// the OS and architecture on machines without one
+func Model() string {
+	modelOnce.Do(func() {
+		model = runtime.GOOS + "/" + runtime.GOARCH
+		if data, err := os.ReadFile("/proc/device-tree/model"); err == nil {
+			if m := strings.TrimSpace(strings.TrimRight(string(data), "\x00")); m != "" {
+				model = m
+			}
+		}
+	})
+	return model
+}
+
+// Uptime returns how long the frame has been running
+func Uptime() time.Duration {
+	return time.Since(started)
+}
+
+// SystemUptime returns how long the system has been up, or 0 when unknown
+func SystemUptime() time.Duration {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// FormatUptime renders a duration as days, hours and minutes, e.g. "3d 4h 12m"
+func FormatUptime(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	minutes := int(d/time.Minute) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
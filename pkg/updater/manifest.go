@@ -0,0 +1,127 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Manifest describes the latest release as served by the release endpoint.
+// The binary URL keeps the "go-binary" key of the original endpoint.
+type Manifest struct {
+	Version   string `json:"version"`
+	URL       string `json:"go-binary"`
+	SHA256    string `json:"sha256"`            // hex digest of the binary
+	Size      int64  `json:"size,omitempty"`    // bytes, 0 when unknown
+	Channel   string `json:"channel,omitempty"` // release channel, "" for stable
+	Rollout   int    `json:"rollout,omitempty"` // percentage of devices offered the release, 0 for all
+	Signature string `json:"signature"`         // base64 ed25519 signature of SignedMessage
+}
+
+// SignedMessage is what the release key signs. The digest binds the binary,
+// so the download URL can change without re-signing. The channel is signed so
+// a dev build cannot be served to a stable frame.
+func (m Manifest) SignedMessage() []byte {
+	return []byte(fmt.Sprintf("flow-frame release\nversion=%s\nsha256=%s\nsize=%d\nchannel=%s\nrollout=%d\n",
+		m.Version, strings.ToLower(m.SHA256), m.Size, m.ChannelName(), m.Rollout))
+}
+
+// ChannelName returns the release channel, defaulting to stable
+func (m Manifest) ChannelName() string {
+	if m.Channel == "" {
+		return DefaultChannel
+	}
+	return m.Channel
+}
+
+// Validate checks that the manifest is complete
+func (m Manifest) Validate() error {
+	switch {
+	case m.Version == "":
+		return errors.New("manifest has no version")
+	case m.URL == "":
+		return errors.New("manifest has no binary URL")
+	case m.Signature == "":
+		return errors.New("manifest is not signed")
+	case m.Size < 0:
+		return fmt.Errorf("manifest has invalid size %d", m.Size)
+	case m.Rollout < 0 || m.Rollout > 100:
+		return fmt.Errorf("manifest has invalid rollout %d%%", m.Rollout)
+	case !slices.Contains(Channels, m.ChannelName()):
+		return fmt.Errorf("manifest has unknown channel %q", m.Channel)
+	}
+	if _, err := ParseVersion(m.Version); err != nil {
+		return fmt.Errorf("manifest has %w", err)
+	}
+	if digest, err := hex.DecodeString(m.SHA256); err != nil || len(digest) != 32 {
+		return fmt.Errorf("manifest has invalid sha256 %q", m.SHA256)
+	}
+	return nil
+}
+
+// Verify checks the signature against any of the trusted keys
+func (m Manifest) Verify(keys []ed25519.PublicKey) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no release keys configured")
+	}
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errors.New("manifest signature is malformed")
+	}
+	msg := m.SignedMessage()
+	for _, key := range keys {
+		if ed25519.Verify(key, msg, sig) {
+			return nil
+		}
+	}
+	return errors.New("manifest signature does not match any release key")
+}
+
+// Sign fills in the signature with the release key
+func (m *Manifest) Sign(key ed25519.PrivateKey) {
+	m.SHA256 = strings.ToLower(m.SHA256)
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, m.SignedMessage()))
+}
+
+// ParseKeys parses comma-separated base64 ed25519 public keys. Several keys
+// allow the release key to be rotated.
+func ParseKeys(value string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(field)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid release key %q: want %d base64 bytes", field, ed25519.PublicKeySize)
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	return keys, nil
+}
+
+// ParsePrivateKey parses a base64 ed25519 seed as written by GenerateKey
+func ParsePrivateKey(value string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key: want %d base64 bytes", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// GenerateKey creates a release key pair, returning the base64 private seed and public key
+func GenerateKey() (private, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.Seed()), base64.StdEncoding.EncodeToString(pub), nil
+}
@@ -0,0 +1,90 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"flow-frame/pkg/appdata"
+)
+
+// Phases of an update check as reported in Progress
+const (
+	PhaseRequested   = "requested"   // the frame asked for a check, see RequestCheck
+	PhaseChecking    = "checking"    // fetching the manifest
+	PhaseDownloading = "downloading" // Done of Total bytes received
+	PhaseInstalling  = "installing"  // verifying and switching slots
+	PhaseInstalled   = "installed"   // Version starts with the next restart
+	PhaseUpToDate    = "up-to-date"
+	PhaseDeferred    = "deferred" // outside the maintenance window or the rollout
+	PhaseFailed      = "failed"
+)
+
+const (
+	progressName = "progress.json"
+	// requestTTL is how long a check request from the frame stays valid, so a
+	// request that never reached the updater does not force a later scheduled run
+	requestTTL = 10 * time.Minute
+)
+
+// Progress is the state of the latest update check, written by the updater and
+// polled by the frame to show a check it requested
+type Progress struct {
+	Phase   string    `json:"phase"`
+	Version string    `json:"version,omitempty"` // release being installed or found
+	Done    int64     `json:"done,omitempty"`    // bytes downloaded
+	Total   int64     `json:"total,omitempty"`   // bytes to download, 0 when unknown
+	Message string    `json:"message,omitempty"` // error or reason for a deferral
+	Time    time.Time `json:"time"`
+}
+
+// Finished reports whether the check is over
+func (p Progress) Finished() bool {
+	switch p.Phase {
+	case PhaseInstalled, PhaseUpToDate, PhaseDeferred, PhaseFailed:
+		return true
+	}
+	return false
+}
+
+// Progress returns the state of the latest check. A missing file returns a zero Progress.
+func (s *Slots) Progress() (Progress, error) {
+	var p Progress
+	data, err := os.ReadFile(filepath.Join(s.Dir, progressName))
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	return p, json.Unmarshal(data, &p)
+}
+
+// SetProgress records the state of the running check
+func (s *Slots) SetProgress(p Progress) error {
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return appdata.WriteFileAtomic(filepath.Join(s.Dir, progressName), append(data, '\n'), 0o644)
+}
+
+// RequestCheck marks the next update check as requested by someone at the
+// frame, so it installs outside the maintenance window
+func (s *Slots) RequestCheck() error {
+	return s.SetProgress(Progress{Phase: PhaseRequested})
+}
+
+// Requested reports whether a check request is pending
+func (s *Slots) Requested() bool {
+	p, err := s.Progress()
+	return err == nil && p.Phase == PhaseRequested && time.Since(p.Time) < requestTTL
+}
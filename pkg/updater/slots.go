@@ -0,0 +1,268 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+
+	"flow-frame/pkg/appdata"
+)
+
+const (
+	// EnvDir overrides the directory holding the slots
+	EnvDir = "FLOW_FRAME_UPDATE_DIR"
+	// BinaryName is the executable inside each slot
+	BinaryName = "flow-frame"
+	// TrialBoots is how many starts a new version gets to render its first frame
+	TrialBoots = 3
+
+	stateName   = "update.json"
+	lockName    = "update.lock"
+	currentLink = "current"
+)
+
+// slotNames are the two slots; the inactive one receives the next update
+var slotNames = []string{"a", "b"}
+
+// executable returns the path of the running binary; tests stand in a slot's binary
+var executable = os.Executable
+
+// State is persisted in the slot directory and shared by the updater and the
+// running frame
+type State struct {
+	Active string            `json:"active,omitempty"` // slot confirmed healthy
+	Slots  map[string]string `json:"slots,omitempty"`  // version installed in each slot
+	Trial  *Trial            `json:"trial,omitempty"`  // slot switched to but not yet confirmed
+	Bad    []string          `json:"bad,omitempty"`    // versions that were rolled back and are never installed again
+}
+
+// Trial tracks a freshly installed version until it renders its first frame
+type Trial struct {
+	Slot     string `json:"slot"`
+	Version  string `json:"version"`
+	Previous string `json:"previous"` // slot to roll back to
+	Boots    int    `json:"boots"`    // starts so far
+}
+
+// Slots manages the A/B slot directory:
+//
+//	<dir>/a/flow-frame, <dir>/b/flow-frame   the two installed versions
+//	<dir>/current -> a                       the slot that starts next
+//	<dir>/update.json                        State
+type Slots struct {
+	Dir string
+}
+
+// DirFromEnv returns the slot directory from FLOW_FRAME_UPDATE_DIR, or "slots" in the data directory
+func DirFromEnv() string {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir
+	}
+	return appdata.Path("slots")
+}
+
+// NewSlots returns the slot directory configured in the environment
+func NewSlots() *Slots {
+	return &Slots{Dir: DirFromEnv()}
+}
+
+// BinaryPath returns the executable of a slot
+func (s *Slots) BinaryPath(slot string) string {
+	return filepath.Join(s.Dir, slot, BinaryName)
+}
+
+// CurrentPath is the stable path of the next binary to start, which the
+// installed command links to
+func (s *Slots) CurrentPath() string {
+	return filepath.Join(s.Dir, currentLink, BinaryName)
+}
+
+// Current returns the slot the current link points to, or "" before the first install
+func (s *Slots) Current() string {
+	target, err := os.Readlink(filepath.Join(s.Dir, currentLink))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// RunningSlot returns the slot of the running executable, or "" when it was not started from a slot
+func (s *Slots) RunningSlot() string {
+	exe, err := executable()
+	if err != nil {
+		return ""
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return ""
+	}
+	dir, err := filepath.EvalSymlinks(s.Dir)
+	if err != nil {
+		return ""
+	}
+	slotDir := filepath.Dir(exe)
+	if filepath.Dir(slotDir) != dir || filepath.Base(exe) != BinaryName {
+		return ""
+	}
+	if slot := filepath.Base(slotDir); slices.Contains(slotNames, slot) {
+		return slot
+	}
+	return ""
+}
+
+// Switch points the current link at slot. The new link is created next to the
+// old one and renamed over it, so a crash leaves either the old or the new target.
+func (s *Slots) Switch(slot string) error {
+	if !slices.Contains(slotNames, slot) {
+		return fmt.Errorf("unknown slot %q", slot)
+	}
+	link := filepath.Join(s.Dir, currentLink)
+	tmp := link + ".new"
+	os.Remove(tmp)
+	if err := os.Symlink(slot, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(s.Dir)
+}
+
+// Update loads the state under an exclusive lock, lets fn change it and saves
+// the result unless fn fails. The lock keeps the updater and the frame from
+// overwriting each other's changes.
+func (s *Slots) Update(fn func(st *State) error) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	lock, err := os.OpenFile(filepath.Join(s.Dir, lockName), os.O_CREATE|os.O_RDONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(&st); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return appdata.WriteFileAtomic(filepath.Join(s.Dir, stateName), append(data, '\n'), 0o644)
+}
+
+// State returns a snapshot of the persisted state
+func (s *Slots) State() (State, error) {
+	return s.load()
+}
+
+func (s *Slots) load() (State, error) {
+	st := State{Slots: map[string]string{}}
+	data, err := os.ReadFile(filepath.Join(s.Dir, stateName))
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("corrupt %s: %w", stateName, err)
+	}
+	if st.Slots == nil {
+		st.Slots = map[string]string{}
+	}
+	return st, nil
+}
+
+// Boot counts a start of a version on trial. After TrialBoots starts without
+// confirmation the current link is switched back to the previous slot and its
+// binary returned, so the caller can exec it instead of starting the bad version again.
+func (s *Slots) Boot() (rollback string, err error) {
+	running := s.RunningSlot()
+	if running == "" {
+		return "", nil
+	}
+	err = s.Update(func(st *State) error {
+		if st.Trial == nil || st.Trial.Slot != running {
+			return nil
+		}
+		st.Trial.Boots++
+		if st.Trial.Boots <= TrialBoots {
+			log.Printf("updater: starting %s on trial (%d/%d)", st.Trial.Version, st.Trial.Boots, TrialBoots)
+			return nil
+		}
+
+		log.Printf("updater: %s did not become healthy after %d starts, rolling back to slot %s",
+			st.Trial.Version, TrialBoots, st.Trial.Previous)
+		if err := s.Switch(st.Trial.Previous); err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+		if !slices.Contains(st.Bad, st.Trial.Version) {
+			st.Bad = append(st.Bad, st.Trial.Version)
+		}
+		rollback = s.BinaryPath(st.Trial.Previous)
+		st.Trial = nil
+		return nil
+	})
+	return rollback, err
+}
+
+// ConfirmHealthy makes a version on trial the active one. Call it once the
+// first frame is on screen.
+func (s *Slots) ConfirmHealthy() error {
+	running := s.RunningSlot()
+	if running == "" {
+		return nil
+	}
+	return s.Update(func(st *State) error {
+		switch {
+		case st.Trial == nil:
+			if st.Active == "" {
+				st.Active = running
+			}
+			return nil
+		case st.Trial.Slot != running:
+			// The switch never took effect, e.g. the link was reset by hand
+			log.Printf("updater: %s is on trial in slot %s but slot %s is running, dropping the trial",
+				st.Trial.Version, st.Trial.Slot, running)
+			st.Trial = nil
+			return nil
+		}
+		log.Printf("updater: %s confirmed healthy", st.Trial.Version)
+		st.Active = running
+		st.Trial = nil
+		return nil
+	})
+}
+
+// inactive returns the slot that is not active
+func inactive(active string) string {
+	if active == slotNames[0] {
+		return slotNames[1]
+	}
+	return slotNames[0]
+}
+
+// syncDir persists renames in dir
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
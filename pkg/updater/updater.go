@@ -0,0 +1,394 @@
+// Package updater installs signed releases. It fetches the release manifest of
+// the configured channel, verifies its ed25519 signature and the SHA-256 of
+// the download, and stages the binary into the inactive of two slots before
+// atomically switching to it. Only newer versions are installed, staged
+// rollouts reach a stable subset of devices, and while the frame is on screen
+// installs wait for the maintenance window. The new version starts on trial:
+// it is confirmed once it renders its first frame, and rolled back if it fails
+// to do so within TrialBoots starts.
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultManifestURL is the release endpoint
+	DefaultManifestURL = "https://luminateflow.ca/api/releases"
+
+	manifestTimeout  = 30 * time.Second
+	preflightTimeout = 15 * time.Second
+	// maxBinarySize bounds downloads whose manifest does not state a size
+	maxBinarySize = 512 << 20
+)
+
+// ErrTrialPending is returned while a previously installed version waits for confirmation
+var ErrTrialPending = errors.New("previous update has not been confirmed yet")
+
+// Updater checks for and installs releases
+type Updater struct {
+	ManifestURL    string
+	Keys           []ed25519.PublicKey // trusted release keys
+	Slots          *Slots
+	Link           string // installed command to turn into a link to the current slot; "" leaves it alone
+	CurrentVersion string // version of the running binary, recorded on the first install
+	Channel        string // release channel to follow, "" for stable
+	DeviceID       string // identifies the device for staged rollouts
+	Window         *Window
+	// Busy reports whether the frame is on screen; installs then wait for the
+	// Window unless the check was requested at the frame. Nil means never busy.
+	Busy   func() bool
+	Force  bool // install now regardless of Window, as if requested at the frame
+	Client *http.Client
+}
+
+// Result describes what Run did
+type Result struct {
+	Version   string // version offered by the manifest
+	Installed string // version active before the run
+	Slot      string // slot the release was staged into, when Updated
+	Updated   bool
+	Deferred  string // why a newer release was not installed, if it was not
+	Requested bool   // the check was requested at the frame or forced
+}
+
+// FetchManifest downloads and verifies the release manifest
+func (u *Updater) FetchManifest(ctx context.Context) (Manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, manifestTimeout)
+	defer cancel()
+
+	var m Manifest
+	manifestURL, err := url.Parse(u.ManifestURL)
+	if err != nil {
+		return m, fmt.Errorf("manifest URL: %w", err)
+	}
+	query := manifestURL.Query()
+	query.Set("channel", u.channel())
+	manifestURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL.String(), nil)
+	if err != nil {
+		return m, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return m, fmt.Errorf("fetch manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return m, fmt.Errorf("fetch manifest: %s", resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&m); err != nil {
+		return m, fmt.Errorf("decode manifest: %w", err)
+	}
+	if err := m.Verify(u.Keys); err != nil {
+		return m, err
+	}
+	if m.ChannelName() != u.channel() {
+		return m, fmt.Errorf("manifest is for channel %s, not %s", m.ChannelName(), u.channel())
+	}
+	return m, nil
+}
+
+func (u *Updater) channel() string {
+	if u.Channel == "" {
+		return DefaultChannel
+	}
+	return u.Channel
+}
+
+// Run installs the release from the manifest when it is newer than the active
+// version. The switch takes effect on the next start of the frame. Progress is
+// recorded for the frame to show, see Slots.Progress.
+func (u *Updater) Run(ctx context.Context) (Result, error) {
+	res := Result{Requested: u.Force || u.Slots.Requested()}
+	u.progress(Progress{Phase: PhaseChecking})
+	res, err := u.run(ctx, res)
+	switch {
+	case err != nil:
+		u.progress(Progress{Phase: PhaseFailed, Version: res.Version, Message: err.Error()})
+	case res.Updated:
+		u.progress(Progress{Phase: PhaseInstalled, Version: res.Version})
+	case res.Deferred != "":
+		u.progress(Progress{Phase: PhaseDeferred, Version: res.Version, Message: res.Deferred})
+	default:
+		u.progress(Progress{Phase: PhaseUpToDate, Version: res.Installed})
+	}
+	return res, err
+}
+
+func (u *Updater) run(ctx context.Context, res Result) (Result, error) {
+	m, err := u.FetchManifest(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.Version = m.Version
+
+	var target string
+	err = u.Slots.Update(func(st *State) error {
+		if err := u.bootstrap(st); err != nil {
+			return err
+		}
+		if st.Trial != nil {
+			return fmt.Errorf("%w (%s in slot %s)", ErrTrialPending, st.Trial.Version, st.Trial.Slot)
+		}
+		res.Installed = st.Slots[st.Active]
+		newer, err := Newer(m.Version, res.Installed)
+		switch {
+		case err != nil:
+			return err
+		case !newer:
+			if m.Version != res.Installed {
+				log.Printf("updater: not downgrading %s to %s", res.Installed, m.Version)
+			}
+		case slices.Contains(st.Bad, m.Version):
+			log.Printf("updater: skipping %s, it was rolled back before", m.Version)
+		default:
+			target = inactive(st.Active)
+		}
+		return nil
+	})
+	if err != nil || target == "" {
+		return res, err
+	}
+
+	if !InRollout(u.DeviceID, m.Version, m.Rollout) {
+		res.Deferred = fmt.Sprintf("%s is rolled out to %d%% of frames, not yet to this one", m.Version, m.Rollout)
+		log.Printf("updater: %s", res.Deferred)
+		return res, nil
+	}
+	if !res.Requested && u.Busy != nil && !u.Window.Contains(time.Now()) && u.Busy() {
+		res.Deferred = fmt.Sprintf("the frame is on screen, %s waits for the maintenance window %s", m.Version, u.Window)
+		log.Printf("updater: %s", res.Deferred)
+		return res, nil
+	}
+
+	log.Printf("updater: installing %s from channel %s into slot %s (active: %s)", m.Version, m.ChannelName(), target, res.Installed)
+	if err := u.stage(ctx, m, target); err != nil {
+		return res, err
+	}
+	u.progress(Progress{Phase: PhaseInstalling, Version: m.Version})
+
+	err = u.Slots.Update(func(st *State) error {
+		st.Slots[target] = m.Version
+		st.Trial = &Trial{Slot: target, Version: m.Version, Previous: st.Active}
+		return u.Slots.Switch(target)
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Slot = target
+	res.Updated = true
+	log.Printf("updater: %s staged in slot %s, active on next start", m.Version, target)
+	return res, nil
+}
+
+// bootstrap moves an installation that predates the slots into slot a and
+// links the installed command to the current slot
+func (u *Updater) bootstrap(st *State) error {
+	if st.Active == "" {
+		slot := u.Slots.RunningSlot()
+		if slot == "" {
+			slot = slotNames[0]
+			exe, err := executable()
+			if err != nil {
+				return err
+			}
+			log.Printf("updater: copying %s into slot %s", exe, slot)
+			if err := copyFile(exe, u.Slots.BinaryPath(slot)); err != nil {
+				return fmt.Errorf("initialize slot %s: %w", slot, err)
+			}
+			if err := u.Slots.Switch(slot); err != nil {
+				return err
+			}
+		}
+		st.Active = slot
+		st.Slots[slot] = u.CurrentVersion
+	}
+	return u.ensureLink()
+}
+
+// ensureLink replaces the installed command with a link to the current slot.
+// Nothing is done when the command is not installed at Link.
+func (u *Updater) ensureLink() error {
+	if u.Link == "" {
+		return nil
+	}
+	want := u.Slots.CurrentPath()
+	if target, err := os.Readlink(u.Link); err == nil && target == want {
+		return nil
+	}
+	if _, err := os.Lstat(u.Link); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	tmp := u.Link + ".new"
+	os.Remove(tmp)
+	if err := os.Symlink(want, tmp); err != nil {
+		return fmt.Errorf("link %s: %w", u.Link, err)
+	}
+	if err := os.Rename(tmp, u.Link); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("link %s: %w", u.Link, err)
+	}
+	log.Printf("updater: %s now links to %s", u.Link, want)
+	return syncDir(filepath.Dir(u.Link))
+}
+
+// stage downloads the release into slot, checking size and digest, and makes
+// sure the binary runs before it replaces the slot's executable
+func (u *Updater) stage(ctx context.Context, m Manifest, slot string) error {
+	dst := u.Slots.BinaryPath(slot)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp := dst + ".new"
+	defer os.Remove(tmp)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", m.Version, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", m.Version, resp.Status)
+	}
+
+	limit := int64(maxBinarySize)
+	if m.Size > 0 {
+		limit = m.Size
+	}
+	total := m.Size
+	if total == 0 && resp.ContentLength > 0 {
+		total = resp.ContentLength
+	}
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	counter := &progressWriter{u: u, p: Progress{Phase: PhaseDownloading, Version: m.Version, Total: total}}
+	n, err := io.Copy(io.MultiWriter(f, h, counter), io.LimitReader(resp.Body, limit+1))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("download %s: %w", m.Version, err)
+	}
+
+	switch {
+	case n > limit:
+		return fmt.Errorf("download %s: larger than %d bytes", m.Version, limit)
+	case m.Size > 0 && n != m.Size:
+		return fmt.Errorf("download %s: got %d bytes, want %d", m.Version, n, m.Size)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != strings.ToLower(m.SHA256) {
+		return fmt.Errorf("download %s: sha256 mismatch: got %s", m.Version, sum)
+	}
+
+	if err := preflight(ctx, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(dst))
+}
+
+// preflight runs the new binary's help to catch builds that cannot even start,
+// e.g. for the wrong architecture or with missing libraries
+func preflight(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "help").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("new binary does not run: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// copyFile copies an executable so the destination appears complete or not at all
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".new"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// progress records the state of the check, logging rather than failing on errors
+func (u *Updater) progress(p Progress) {
+	if err := u.Slots.SetProgress(p); err != nil {
+		log.Printf("updater: failed to record progress: %v", err)
+	}
+}
+
+// progressWriter counts downloaded bytes and records them now and then
+type progressWriter struct {
+	u       *Updater
+	p       Progress
+	written time.Time
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	w.p.Done += int64(len(b))
+	if time.Since(w.written) >= 500*time.Millisecond {
+		w.written = time.Now()
+		w.p.Time = w.written
+		w.u.progress(w.p)
+	}
+	return len(b), nil
+}
+
+func (u *Updater) client() *http.Client {
+	if u.Client != nil {
+		return u.Client
+	}
+	return http.DefaultClient
+}
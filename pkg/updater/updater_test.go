@@ -0,0 +1,317 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// releaseBinary stands in for a release; preflight runs it with "help"
+var releaseBinary = []byte("#!/bin/sh\necho flow-frame 1.1.0\n")
+
+// releaseServer is a local stand-in for the release endpoint serving one
+// manifest and the binary it points at
+type releaseServer struct {
+	*httptest.Server
+	manifest Manifest
+	binary   []byte
+}
+
+func newReleaseServer(t *testing.T) *releaseServer {
+	t.Helper()
+	rs := &releaseServer{binary: releaseBinary}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/releases", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("channel"); got != rs.manifest.ChannelName() {
+			http.Error(w, "wrong channel "+got, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(rs.manifest)
+	})
+	mux.HandleFunc("/releases/flow-frame", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(rs.binary)
+	})
+	rs.Server = httptest.NewServer(mux)
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+// publish signs a manifest for version with key and serves it
+func (rs *releaseServer) publish(version string, key ed25519.PrivateKey) {
+	sum := sha256.Sum256(rs.binary)
+	rs.manifest = Manifest{
+		Version: version,
+		URL:     rs.URL + "/releases/flow-frame",
+		SHA256:  hex.EncodeToString(sum[:]),
+		Size:    int64(len(rs.binary)),
+	}
+	rs.manifest.Sign(key)
+}
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+// newSlots creates a slot directory with version 1.0.0 installed and active in slot a
+func newSlots(t *testing.T) *Slots {
+	t.Helper()
+	slots := &Slots{Dir: filepath.Join(t.TempDir(), "slots")}
+	if err := os.MkdirAll(filepath.Dir(slots.BinaryPath("a")), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(slots.BinaryPath("a"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := slots.Switch("a"); err != nil {
+		t.Fatal(err)
+	}
+	err := slots.Update(func(st *State) error {
+		st.Active = "a"
+		st.Slots["a"] = "1.0.0"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return slots
+}
+
+func newUpdater(rs *releaseServer, slots *Slots, keys ...ed25519.PublicKey) *Updater {
+	return &Updater{
+		ManifestURL:    rs.URL + "/api/releases",
+		Keys:           keys,
+		Slots:          slots,
+		CurrentVersion: "1.0.0",
+		Client:         rs.Client(),
+	}
+}
+
+// runAs makes RunningSlot report slot for the rest of the test
+func runAs(t *testing.T, slots *Slots, slot string) {
+	old := executable
+	executable = func() (string, error) { return slots.BinaryPath(slot), nil }
+	t.Cleanup(func() { executable = old })
+}
+
+// assertNothingStaged checks that a failed run left slot a active and slot b empty
+func assertNothingStaged(t *testing.T, slots *Slots) {
+	t.Helper()
+	if _, err := os.Stat(slots.BinaryPath("b")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("slot b has a binary after a failed update: %v", err)
+	}
+	if _, err := os.Stat(slots.BinaryPath("b") + ".new"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial download left behind: %v", err)
+	}
+	if current := slots.Current(); current != "a" {
+		t.Errorf("current slot = %q, want a", current)
+	}
+	st, err := slots.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Trial != nil || st.Active != "a" {
+		t.Errorf("state changed by a failed update: %+v", st)
+	}
+	if p, _ := slots.Progress(); p.Phase != PhaseFailed {
+		t.Errorf("progress phase = %q, want %q", p.Phase, PhaseFailed)
+	}
+}
+
+func TestRunRejectsBadSignature(t *testing.T) {
+	trusted, _ := newKey(t)
+	_, untrusted := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("1.1.0", untrusted)
+	slots := newSlots(t)
+
+	_, err := newUpdater(rs, slots, trusted).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "signature does not match") {
+		t.Fatalf("Run with a manifest signed by an unknown key: %v", err)
+	}
+	assertNothingStaged(t, slots)
+}
+
+func TestRunRejectsTamperedManifest(t *testing.T) {
+	pub, priv := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("1.1.0", priv)
+	rs.manifest.Version = "9.0.0" // signed as 1.1.0
+	slots := newSlots(t)
+
+	if _, err := newUpdater(rs, slots, pub).Run(context.Background()); err == nil {
+		t.Fatal("Run accepted a manifest changed after signing")
+	}
+	assertNothingStaged(t, slots)
+}
+
+func TestRunRejectsDigestMismatch(t *testing.T) {
+	pub, priv := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("1.1.0", priv)
+	// Same length, different content: only the digest can catch it
+	rs.binary = bytes.Replace(releaseBinary, []byte("1.1.0"), []byte("6.6.6"), 1)
+	slots := newSlots(t)
+
+	_, err := newUpdater(rs, slots, pub).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("Run with a corrupted download: %v", err)
+	}
+	assertNothingStaged(t, slots)
+}
+
+func TestRunStagesIntoInactiveSlot(t *testing.T) {
+	pub, priv := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("1.1.0", priv)
+	slots := newSlots(t)
+	u := newUpdater(rs, slots, pub)
+
+	res, err := u.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Updated || res.Slot != "b" || res.Installed != "1.0.0" || res.Version != "1.1.0" {
+		t.Errorf("result = %+v", res)
+	}
+
+	got, err := os.ReadFile(slots.BinaryPath("b"))
+	if err != nil || !bytes.Equal(got, releaseBinary) {
+		t.Errorf("slot b binary = %q, %v", got, err)
+	}
+	if got, _ := os.ReadFile(slots.BinaryPath("a")); string(got) != "#!/bin/sh\n" {
+		t.Errorf("active slot a was modified: %q", got)
+	}
+	if current := slots.Current(); current != "b" {
+		t.Errorf("current slot = %q, want b", current)
+	}
+
+	st, err := slots.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Trial{Slot: "b", Version: "1.1.0", Previous: "a"}
+	if st.Trial == nil || *st.Trial != want {
+		t.Errorf("trial = %+v, want %+v", st.Trial, want)
+	}
+	if st.Active != "a" || st.Slots["b"] != "1.1.0" {
+		t.Errorf("state = %+v", st)
+	}
+	if p, _ := slots.Progress(); p.Phase != PhaseInstalled {
+		t.Errorf("progress phase = %q, want %q", p.Phase, PhaseInstalled)
+	}
+
+	// Nothing else is installed until the new version is confirmed or rolled back
+	if _, err := u.Run(context.Background()); !errors.Is(err, ErrTrialPending) {
+		t.Errorf("second Run = %v, want ErrTrialPending", err)
+	}
+}
+
+func TestRunSkipsOlderVersion(t *testing.T) {
+	pub, priv := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("0.9.0", priv)
+	slots := newSlots(t)
+
+	res, err := newUpdater(rs, slots, pub).Run(context.Background())
+	if err != nil || res.Updated {
+		t.Fatalf("Run offered a downgrade: %+v, %v", res, err)
+	}
+	if current := slots.Current(); current != "a" {
+		t.Errorf("current slot = %q, want a", current)
+	}
+}
+
+func TestBootRollsBackUnconfirmedVersion(t *testing.T) {
+	pub, priv := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("1.1.0", priv)
+	slots := newSlots(t)
+	u := newUpdater(rs, slots, pub)
+	if _, err := u.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new version starts from slot b but never calls ConfirmHealthy
+	runAs(t, slots, "b")
+	for i := 1; i <= TrialBoots; i++ {
+		rollback, err := slots.Boot()
+		if err != nil || rollback != "" {
+			t.Fatalf("boot %d: rollback = %q, %v", i, rollback, err)
+		}
+	}
+	rollback, err := slots.Boot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rollback != slots.BinaryPath("a") {
+		t.Errorf("rollback = %q, want %q", rollback, slots.BinaryPath("a"))
+	}
+	if current := slots.Current(); current != "a" {
+		t.Errorf("current slot after rollback = %q, want a", current)
+	}
+	st, err := slots.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Trial != nil || st.Active != "a" || !slices.Contains(st.Bad, "1.1.0") {
+		t.Errorf("state after rollback = %+v", st)
+	}
+
+	// The rolled back version is not offered again
+	runAs(t, slots, "a")
+	res, err := u.Run(context.Background())
+	if err != nil || res.Updated {
+		t.Errorf("Run after rollback = %+v, %v", res, err)
+	}
+}
+
+func TestConfirmHealthyEndsTrial(t *testing.T) {
+	pub, priv := newKey(t)
+	rs := newReleaseServer(t)
+	rs.publish("1.1.0", priv)
+	slots := newSlots(t)
+	if _, err := newUpdater(rs, slots, pub).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	runAs(t, slots, "b")
+	if _, err := slots.Boot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := slots.ConfirmHealthy(); err != nil {
+		t.Fatal(err)
+	}
+	st, err := slots.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Trial != nil || st.Active != "b" {
+		t.Errorf("state after confirmation = %+v", st)
+	}
+
+	// A confirmed version is never rolled back
+	for range TrialBoots + 1 {
+		if rollback, err := slots.Boot(); err != nil || rollback != "" {
+			t.Fatalf("Boot after confirmation: %q, %v", rollback, err)
+		}
+	}
+	if current := slots.Current(); current != "b" {
+		t.Errorf("current slot = %q, want b", current)
+	}
+}
@@ -0,0 +1,153 @@
+package updater
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version. Release versions such as "1.4" that omit
+// the patch number are accepted with a patch of 0.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string // dot-separated pre-release identifiers, e.g. beta.2
+}
+
+// ParseVersion parses versions like 1.4, v1.4.2 and 2.0.0-beta.1. Build
+// metadata after "+" is ignored.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	rest, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(s), "v"), "+")
+	core, pre, hasPre := strings.Cut(rest, "-")
+	if hasPre {
+		if pre == "" {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.Pre = strings.Split(pre, ".")
+		for _, id := range v.Pre {
+			if id == "" {
+				return v, fmt.Errorf("invalid version %q", s)
+			}
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 when v is older than, equal to or newer than o,
+// using semantic versioning precedence
+func (v Version) Compare(o Version) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A pre-release is older than the release it precedes
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		if c := comparePre(v.Pre[i], o.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.Pre), len(o.Pre))
+}
+
+// comparePre orders pre-release identifiers: numeric ones numerically and
+// before alphanumeric ones, which compare as strings
+func comparePre(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	return s
+}
+
+// Newer reports whether candidate is a newer version than installed. An
+// unknown or unparsable installed version is treated as older than any release.
+func Newer(candidate, installed string) (bool, error) {
+	c, err := ParseVersion(candidate)
+	if err != nil {
+		return false, err
+	}
+	if installed == "" {
+		return true, nil
+	}
+	i, err := ParseVersion(installed)
+	if err != nil {
+		return true, nil
+	}
+	return c.Compare(i) > 0, nil
+}
+
+// Channels are the release channels a frame can follow, from most to least tested
+var Channels = []string{"stable", "beta", "dev"}
+
+// DefaultChannel is followed when no channel is configured
+const DefaultChannel = "stable"
+
+// InRollout reports whether a device takes part in a release rolled out to
+// percent of all devices. The device's position is derived from its ID and
+// the version, so it is stable across checks but differs between releases.
+func InRollout(deviceID, version string, percent int) bool {
+	if percent <= 0 || percent >= 100 {
+		return true
+	}
+	sum := sha256.Sum256([]byte(deviceID + "\x00" + version))
+	return binary.BigEndian.Uint64(sum[:8])%100 < uint64(percent)
+}
+
+// DeviceID identifies the device for staged rollouts: the systemd machine ID,
+// or the hostname when there is none
+func DeviceID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+	host, _ := os.Hostname()
+	return host
+}
@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"1.4", "1.4.0", true},
+		{"v1.4.2", "1.4.2", true},
+		{" 2.0.0-beta.1 ", "2.0.0-beta.1", true},
+		{"1.0.0+build.7", "1.0.0", true},
+		{"1.0.0-rc.1+build.7", "1.0.0-rc.1", true},
+		{"", "", false},
+		{"1", "", false},
+		{"1.2.3.4", "", false},
+		{"1.x.0", "", false},
+		{"1.-2.0", "", false},
+		{"1.0.0-", "", false},
+		{"1.0.0-beta..1", "", false},
+		{"dev", "", false},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseVersion(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && v.String() != tt.want {
+			t.Errorf("ParseVersion(%q) = %s, want %s", tt.in, v, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.4", "1.4.0", 0},
+		{"v1.4.0", "1.4", 0},
+		{"1.0.0+a", "1.0.0+b", 0},
+		{"1.4.1", "1.4.0", 1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-beta.2", "1.0.0-beta.10", -1},
+		{"1.0.0-beta.10", "1.0.0", -1},
+		{"1.0.0-beta", "1.0.0-beta.1", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+		{"1.0.1-alpha", "1.0.0", 1},
+	}
+	for _, tt := range tests {
+		a, errA := ParseVersion(tt.a)
+		b, errB := ParseVersion(tt.b)
+		if errA != nil || errB != nil {
+			t.Fatalf("parse %q, %q: %v, %v", tt.a, tt.b, errA, errB)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareOrdersPreReleases(t *testing.T) {
+	want := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-beta.2", "1.0.0-beta.10", "1.0.0-rc.1", "1.0.0", "1.0.1"}
+	versions := make([]Version, 0, len(want))
+	for _, s := range slices.Backward(want) {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+
+	slices.SortFunc(versions, Version.Compare)
+	got := make([]string, len(versions))
+	for i, v := range versions {
+		got[i] = v.String()
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("sorted = %v, want %v", got, want)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		candidate, installed string
+		want                 bool
+		ok                   bool
+	}{
+		{"1.1.0", "1.0.0", true, true},
+		{"1.0.0", "1.0.0", false, true},
+		{"1.4", "1.4.0", false, true},
+		{"0.9.0", "1.0.0", false, true},       // never a downgrade
+		{"1.0.0", "1.0.0-beta.3", true, true}, // the release after its pre-release
+		{"1.0.0-beta.10", "1.0.0-beta.2", true, true},
+		{"1.0.0-beta.2", "1.0.0", false, true},
+		{"1.0.0", "", true, true},      // nothing recorded as installed
+		{"1.0.0", "dev", true, true},   // a development build
+		{"1.0.0", "1.0.x", true, true}, // an unparsable installed version
+		{"garbage", "1.0.0", false, false},
+	}
+	for _, tt := range tests {
+		got, err := Newer(tt.candidate, tt.installed)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, %v; want %v, ok %v", tt.candidate, tt.installed, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	const devices = 2000
+	for _, percent := range []int{-5, 0, 100, 150} {
+		for i := range 50 {
+			if !InRollout(fmt.Sprintf("device-%d", i), "1.1.0", percent) {
+				t.Fatalf("device-%d excluded from a %d%% rollout", i, percent)
+			}
+		}
+	}
+
+	in := 0
+	for i := range devices {
+		id := fmt.Sprintf("device-%d", i)
+		got := InRollout(id, "1.1.0", 50)
+		for range 3 {
+			if InRollout(id, "1.1.0", 50) != got {
+				t.Fatalf("%s: rollout membership changed between checks", id)
+			}
+		}
+		if got {
+			in++
+			// Devices in a staged rollout stay in it as the percentage grows
+			if !InRollout(id, "1.1.0", 75) {
+				t.Errorf("%s: in the 50%% rollout but not the 75%% one", id)
+			}
+		}
+	}
+	if in < devices*45/100 || in > devices*55/100 {
+		t.Errorf("%d of %d devices in a 50%% rollout", in, devices)
+	}
+
+	// Another release picks a different half
+	same := 0
+	for i := range devices {
+		id := fmt.Sprintf("device-%d", i)
+		if InRollout(id, "1.1.0", 50) == InRollout(id, "1.2.0", 50) {
+			same++
+		}
+	}
+	if same == devices {
+		t.Error("every release is rolled out to the same devices")
+	}
+}
@@ -0,0 +1,70 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily maintenance window in local time during which scheduled
+// updates may install and restart the frame. It may wrap past midnight.
+type Window struct {
+	Start, End time.Duration // offsets from midnight
+}
+
+// ParseWindow parses a window such as "02:00-05:00" or "23:30-01:00". An empty
+// value or "off" returns nil, meaning updates may install at any time.
+func ParseWindow(s string) (*Window, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return nil, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("%q is not a window such as 02:00-05:00", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("window %q is empty", s)
+	}
+	return &Window{Start: start, End: end}, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time such as 02:00", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t falls inside the window. A nil window contains every time.
+func (w *Window) Contains(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	y, m, d := t.Date()
+	offset := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+func (w *Window) String() string {
+	if w == nil {
+		return "any time"
+	}
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
@@ -0,0 +1,84 @@
+package updater
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"02:00-05:00", "02:00-05:00", true},
+		{" 23:30 - 01:00 ", "23:30-01:00", true},
+		{"22:00-02:00", "22:00-02:00", true},
+		{"", "any time", true},
+		{"off", "any time", true},
+		{"02:00", "", false},
+		{"2am-5am", "", false},
+		{"02:00-24:00", "", false},
+		{"03:00-03:00", "", false},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseWindow(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && w.String() != tt.want {
+			t.Errorf("ParseWindow(%q) = %s, want %s", tt.in, w, tt.want)
+		}
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, time.March, 14, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		window string
+		t      time.Time
+		want   bool
+	}{
+		{"02:00-05:00", at(1, 59), false},
+		{"02:00-05:00", at(2, 0), true},
+		{"02:00-05:00", at(4, 59), true},
+		{"02:00-05:00", at(5, 0), false},
+		{"02:00-05:00", at(14, 0), false},
+		// Wrapping past midnight
+		{"22:00-02:00", at(21, 59), false},
+		{"22:00-02:00", at(22, 0), true},
+		{"22:00-02:00", at(23, 59), true},
+		{"22:00-02:00", at(0, 0), true},
+		{"22:00-02:00", at(1, 59), true},
+		{"22:00-02:00", at(2, 0), false},
+		{"22:00-02:00", at(12, 0), false},
+		{"off", at(12, 0), true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Errorf("%s contains %s = %v, want %v", tt.window, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestWindowContainsLocalTime(t *testing.T) {
+	w, err := ParseWindow("22:00-02:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same instant is inside the window in one zone and outside in another
+	instant := time.Date(2026, time.March, 14, 23, 0, 0, 0, time.UTC)
+	if !w.Contains(instant) {
+		t.Error("23:00 UTC not in 22:00-02:00")
+	}
+	if w.Contains(instant.In(time.FixedZone("UTC+5", 5*3600))) {
+		t.Error("04:00 UTC+5 in 22:00-02:00")
+	}
+}
@@ -0,0 +1,31 @@
+#include <stdarg.h>
+#include <stdio.h>
+#include <libavutil/log.h>
+
+#include "_cgo_export.h"
+
+// av_log_callback replaces FFmpeg's default callback, which writes to stderr,
+// and hands every line at or above the av_log_set_level threshold to Go
+static void av_log_callback(void *avcl, int level, const char *fmt, va_list vl) {
+    if (level > av_log_get_level()) {
+        return;
+    }
+    char line[1024];
+    int print_prefix = 1;
+    av_log_format_line2(avcl, level, fmt, vl, line, sizeof(line), &print_prefix);
+    goAVLog(level, line);
+}
+
+void install_av_log_callback(void) {
+    av_log_set_callback(av_log_callback);
+}
+
+// decoder_log logs a message of the decoder's C code at an AV_LOG_* level
+void decoder_log(int level, const char *fmt, ...) {
+    char line[1024];
+    va_list vl;
+    va_start(vl, fmt);
+    vsnprintf(line, sizeof(line), fmt, vl);
+    va_end(vl);
+    goDecoderLog(level, line);
+}
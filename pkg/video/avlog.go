@@ -0,0 +1,67 @@
+package video
+
+/*
+#include <libavutil/log.h>
+
+void install_av_log_callback(void);
+*/
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"flow-frame/pkg/logging"
+)
+
+// logger is the video subsystem logger
+var logger = logging.For("video")
+
+// ffmpegLogger receives FFmpeg's own messages (av_log) and those of the
+// decoder's C code, which would otherwise go straight to stderr
+var (
+	ffmpegLogger  = logger.With("source", "ffmpeg")
+	decoderLogger = logger.With("source", "decoder")
+)
+
+func init() {
+	C.install_av_log_callback()
+}
+
+// avLevel maps an FFmpeg log level to a slog level
+func avLevel(level C.int) slog.Level {
+	switch {
+	case level <= C.AV_LOG_ERROR:
+		return slog.LevelError
+	case level <= C.AV_LOG_WARNING:
+		return slog.LevelWarn
+	case level <= C.AV_LOG_INFO:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+//export goAVLog
+func goAVLog(level C.int, line *C.char) {
+	logLine(ffmpegLogger, level, line)
+}
+
+//export goDecoderLog
+func goDecoderLog(level C.int, line *C.char) {
+	logLine(decoderLogger, level, line)
+}
+
+// logLine logs a line formatted by C code, dropping the trailing newline
+func logLine(l *slog.Logger, level C.int, line *C.char) {
+	lvl := avLevel(level)
+	if !l.Enabled(context.Background(), lvl) {
+		return
+	}
+	msg := strings.TrimSpace(C.GoString(line))
+	if msg == "" {
+		return
+	}
+	l.Log(context.Background(), lvl, msg)
+}
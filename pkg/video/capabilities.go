@@ -0,0 +1,82 @@
+package video
+
+/*
+#cgo pkg-config: libavcodec
+
+#include <stdlib.h>
+#include <libavcodec/avcodec.h>
+
+// has_decoder reports whether FFmpeg was built with the named decoder
+static int has_decoder(const char *name) {
+    const AVCodec *c = avcodec_find_decoder_by_name(name);
+    return c != NULL && av_codec_is_decoder(c);
+}
+*/
+import "C"
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// DecoderCapability lists the decoders FFmpeg offers for one codec
+type DecoderCapability struct {
+	Codec    string   `json:"codec"`              // e.g. h264
+	Hardware []string `json:"hardware,omitempty"` // hardware decoders such as h264_rkmpp
+	Software bool     `json:"software"`           // a software decoder is available
+}
+
+// capabilityCodecs are the codecs worth reporting, with the prefix of their hardware decoder names
+var capabilityCodecs = []struct{ codec, prefix string }{
+	{"h264", "h264"},
+	{"hevc", "hevc"},
+	{"vp9", "vp9"},
+	{"av1", "av1"},
+	{"vp8", "vp8"},
+	{"mpeg2video", "mpeg2"},
+	{"mpeg4", "mpeg4"},
+}
+
+// hardwareSuffixes are the FFmpeg hardware decoder families, as detected by init_decoder
+var hardwareSuffixes = []string{"_rkmpp", "_v4l2m2m", "_v4l2request", "_vaapi", "_nvdec", "_cuvid", "_videotoolbox"}
+
+var (
+	capabilitiesOnce sync.Once
+	capabilities     []DecoderCapability
+)
+
+// DecoderCapabilities reports which decoders the linked FFmpeg provides. A
+// listed hardware decoder may still fail to open if the device lacks the hardware.
+func DecoderCapabilities() []DecoderCapability {
+	capabilitiesOnce.Do(func() {
+		for _, c := range capabilityCodecs {
+			capability := DecoderCapability{Codec: c.codec, Software: hasDecoder(c.codec)}
+			for _, suffix := range hardwareSuffixes {
+				if name := c.prefix + suffix; hasDecoder(name) {
+					capability.Hardware = append(capability.Hardware, name)
+				}
+			}
+			capabilities = append(capabilities, capability)
+		}
+	})
+	return capabilities
+}
+
+// String summarizes a capability, e.g. "h264: h264_rkmpp, software"
+func (c DecoderCapability) String() string {
+	decoders := append([]string(nil), c.Hardware...)
+	if c.Software {
+		decoders = append(decoders, "software")
+	}
+	if len(decoders) == 0 {
+		return c.Codec + ": none"
+	}
+	return c.Codec + ": " + strings.Join(decoders, ", ")
+}
+
+func hasDecoder(name string) bool {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	return C.has_decoder(cName) != 0
+}
@@ -1,106 +1,127 @@
 package video
 
 import (
-	"log"
 	"sync"
 	"time"
 
-	"flow-frame/pkg/performance"
+	"flow-frame/pkg/metrics"
 )
 
-// SkipMode represents the current frame skipping strategy
+// SkipMode is how much decode work libavcodec may leave out. Every frame is
+// still decoded in order; higher modes trade picture quality for speed so
+// playback degrades smoothly instead of stalling.
 type SkipMode int
 
 const (
-	ModeNormal SkipMode = iota // Decode every frame (60fps target)
-	ModeSkip2                  // Decode every 2nd frame (30fps effective)
-	ModeSkip3                  // Decode every 3rd frame (20fps effective)
+	ModeNormal               SkipMode = iota // Decode everything
+	ModeSkipLoopFilterNonRef                 // Skip the deblocking loop filter on non-reference frames
+	ModeSkipLoopFilter                       // Skip the deblocking loop filter on all frames
+	ModeDiscardNonRef                        // Also discard non-reference frames (AVDISCARD_NONREF)
+
+	maxSkipMode = ModeDiscardNonRef
 )
 
 // String returns human-readable mode name
 func (m SkipMode) String() string {
 	switch m {
 	case ModeNormal:
-		return "Normal(60fps)"
-	case ModeSkip2:
-		return "Skip2(30fps)"
-	case ModeSkip3:
-		return "Skip3(20fps)"
+		return "Normal"
+	case ModeSkipLoopFilterNonRef:
+		return "SkipLoopFilter(nonref)"
+	case ModeSkipLoopFilter:
+		return "SkipLoopFilter"
+	case ModeDiscardNonRef:
+		return "DiscardNonRef"
 	default:
 		return "Unknown"
 	}
 }
 
-// FrameSkipper adaptively skips frame decoding based on performance
+var (
+	// skipModeMetric is indexed by SkipMode
+	skipModeMetric = metrics.NewStateSet("flowframe_frame_skip_mode",
+		"Current decoder skip mode of the video player", "mode",
+		"normal", "skip_loop_filter_nonref", "skip_loop_filter", "discard_nonref")
+	decodeLatency = metrics.NewGauge("flowframe_decode_latency_seconds",
+		"Smoothed time spent decoding per frame, as seen by the frame skipper")
+)
+
+// FrameSkipper is a target-latency controller: it raises the SkipMode while
+// the smoothed decode time stays above the target and lowers it again once
+// decoding is comfortably fast and frames are presented on time
 type FrameSkipper struct {
 	mode            SkipMode
 	frameCounter    uint64
+	latency         time.Duration // exponentially smoothed decode time
 	consecutiveSlow int
 	consecutiveGood int
+	holdUntil       uint64   // frameCounter before which the mode is not changed
+	minMode         SkipMode // floor set from outside, e.g. while the CPU runs hot
 
-	// Thresholds for performance classification
-	slowThreshold time.Duration // If avg decode time > this, consider "slow"
-	goodThreshold time.Duration // If avg decode time < this, consider "good"
+	// Controller settings
+	target       time.Duration // decode time the controller aims to stay under
+	lowWater     float64       // fraction of target below which decoding counts as "good"
+	smoothing    float64       // weight of a new sample in the smoothed latency
+	goodJankRate float64       // Jank rate (%) must be <= this to lower the mode
 
-	// Hysteresis counters to prevent mode thrashing
-	enterSkip2After    int // Consecutive slow frames before entering Skip2
-	enterSkip3After    int // Consecutive slow frames in Skip2 before entering Skip3
-	exitToNormalAfter  int // Consecutive good frames in Skip2 before returning to Normal
-	exitToSkip2After   int // Consecutive good frames in Skip3 before upgrading to Skip2
+	// Hysteresis to prevent mode thrashing
+	raiseAfter  int    // Consecutive slow frames before raising the mode
+	lowerAfter  int    // Consecutive good frames before lowering the mode
+	settleAfter uint64 // Frames to wait after a change before judging its effect
 
 	mu sync.RWMutex
 }
 
-// SkipDecision contains the frame skip decision and reasoning
-type SkipDecision struct {
-	ShouldDecode bool
-	ShouldSkip   bool
-	Reason       string
-	CurrentMode  SkipMode
-}
-
-// NewFrameSkipper creates a new adaptive frame skipper with sensible defaults
+// NewFrameSkipper creates a new decode-latency controller with sensible defaults
 func NewFrameSkipper() *FrameSkipper {
 	return &FrameSkipper{
-		mode:          ModeNormal,
-		slowThreshold: 30 * time.Millisecond, // 30ms = too slow for 60fps (16.67ms budget)
-		goodThreshold: 20 * time.Millisecond, // 20ms = good performance
-
-		// Hysteresis settings prevent rapid mode switching
-		enterSkip2After:    3,  // 3 consecutive slow frames → Skip2
-		enterSkip3After:    5,  // 5 consecutive slow frames → Skip3
-		exitToNormalAfter:  60, // 60 good frames (1 sec @ 60fps) → Normal
-		exitToSkip2After:   30, // 30 good frames (1.5 sec @ 20fps) → Skip2
+		mode:         ModeNormal,
+		target:       12 * time.Millisecond, // 12ms leaves room for upload and render in a 16.67ms frame
+		lowWater:     0.6,
+		smoothing:    0.1,
+		goodJankRate: 1, // 1% = smooth
+
+		raiseAfter:  10,  // 10 slow frames → less decode work
+		lowerAfter:  180, // 180 good frames (3 sec @ 60fps) → more decode work
+		settleAfter: 30,  // give the decoder half a second to show the effect
 	}
 }
 
-// ShouldDecode returns a decision on whether to decode the next frame
-// Call this before player.Update() on each frame
-func (f *FrameSkipper) ShouldDecode(report performance.PerformanceReport) SkipDecision {
+// Observe feeds the time spent decoding the latest frame into the controller
+// and returns the SkipMode the decoder should use from now on. jankRate is the
+// percentage of recent frames presented late. Call it after each
+// player.Update() that decoded a frame.
+func (f *FrameSkipper) Observe(decode time.Duration, jankRate float64) SkipMode {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	f.frameCounter++
+	if f.latency == 0 {
+		f.latency = decode
+	} else {
+		f.latency += time.Duration(f.smoothing * float64(decode-f.latency))
+	}
+	decodeLatency.Set(f.latency.Seconds())
 
-	// Update mode based on recent performance
-	f.updateModeLocked(report)
-
-	// Make decision based on current mode
-	decision := f.makeDecisionLocked()
-
-	return decision
+	f.updateModeLocked(jankRate)
+	skipModeMetric.Set(int(f.mode))
+	return f.mode
 }
 
-// updateModeLocked analyzes performance and transitions between modes
+// updateModeLocked moves the mode one step towards the target latency
 // Must be called with f.mu held
-func (f *FrameSkipper) updateModeLocked(report performance.PerformanceReport) {
-	avgDecode := time.Duration(report.AvgDecodeMs * float64(time.Millisecond))
+func (f *FrameSkipper) updateModeLocked(jankRate float64) {
+	if f.frameCounter < f.holdUntil {
+		return
+	}
 
-	// Classify current performance
-	if avgDecode > f.slowThreshold {
+	// Classify current performance; frequent jank keeps the mode from
+	// dropping even when decoding looks fast
+	goodLatency := time.Duration(float64(f.target) * f.lowWater)
+	if f.latency > f.target {
 		f.consecutiveSlow++
 		f.consecutiveGood = 0
-	} else if avgDecode < f.goodThreshold {
+	} else if f.latency < goodLatency && jankRate <= f.goodJankRate {
 		f.consecutiveGood++
 		f.consecutiveSlow = 0
 	} else {
@@ -109,103 +130,63 @@ func (f *FrameSkipper) updateModeLocked(report performance.PerformanceReport) {
 		f.consecutiveGood = 0
 	}
 
-	// State machine transitions with hysteresis
-	switch f.mode {
-	case ModeNormal:
-		if f.consecutiveSlow >= f.enterSkip2After {
-			f.mode = ModeSkip2
-			f.consecutiveSlow = 0
-			log.Printf("FrameSkipper: Performance degrading, entering Skip2 mode (30fps decode, 60fps render)")
-		}
-
-	case ModeSkip2:
-		if f.consecutiveSlow >= f.enterSkip3After {
-			// Performance still bad, go to more aggressive skipping
-			f.mode = ModeSkip3
-			f.consecutiveSlow = 0
-			log.Printf("FrameSkipper: Performance still degrading, entering Skip3 mode (20fps decode, 60fps render)")
-		} else if f.consecutiveGood >= f.exitToNormalAfter {
-			// Performance recovered, return to normal
-			f.mode = ModeNormal
-			f.consecutiveGood = 0
-			log.Printf("FrameSkipper: Performance recovered, returning to Normal mode (60fps decode)")
-		}
-
-	case ModeSkip3:
-		if f.consecutiveGood >= f.exitToSkip2After {
-			// Performance improving, upgrade to less aggressive skipping
-			f.mode = ModeSkip2
-			f.consecutiveGood = 0
-			log.Printf("FrameSkipper: Performance improving, upgrading to Skip2 mode (30fps decode)")
-		}
+	switch {
+	case f.consecutiveSlow >= f.raiseAfter && f.mode < maxSkipMode:
+		f.setModeLocked(f.mode + 1)
+		logger.Info("frame skipper: decoding too slow, reducing decode work",
+			"mode", f.mode.String(), "latency_ms", toMs(f.latency), "target_ms", toMs(f.target))
+	case f.consecutiveGood >= f.lowerAfter && f.mode > f.minMode:
+		f.setModeLocked(f.mode - 1)
+		logger.Info("frame skipper: decoding recovered, restoring decode work",
+			"mode", f.mode.String(), "latency_ms", toMs(f.latency), "target_ms", toMs(f.target))
 	}
 }
 
-// makeDecisionLocked creates a skip decision based on current mode and frame counter
+// setModeLocked switches mode and waits for the decoder to settle
 // Must be called with f.mu held
-func (f *FrameSkipper) makeDecisionLocked() SkipDecision {
-	switch f.mode {
-	case ModeNormal:
-		// Decode every frame
-		return SkipDecision{
-			ShouldDecode: true,
-			ShouldSkip:   false,
-			Reason:       "normal:decode_all",
-			CurrentMode:  ModeNormal,
-		}
-
-	case ModeSkip2:
-		// Decode every 2nd frame (30fps effective)
-		shouldDecode := f.frameCounter%2 == 0
-		reason := "skip2:decode"
-		if !shouldDecode {
-			reason = "skip2:skip"
-		}
-		return SkipDecision{
-			ShouldDecode: shouldDecode,
-			ShouldSkip:   !shouldDecode,
-			Reason:       reason,
-			CurrentMode:  ModeSkip2,
-		}
-
-	case ModeSkip3:
-		// Decode every 3rd frame (20fps effective)
-		shouldDecode := f.frameCounter%3 == 0
-		reason := "skip3:decode"
-		if !shouldDecode {
-			reason = "skip3:skip"
-		}
-		return SkipDecision{
-			ShouldDecode: shouldDecode,
-			ShouldSkip:   !shouldDecode,
-			Reason:       reason,
-			CurrentMode:  ModeSkip3,
-		}
-	}
+func (f *FrameSkipper) setModeLocked(mode SkipMode) {
+	f.mode = mode
+	f.consecutiveSlow = 0
+	f.consecutiveGood = 0
+	f.holdUntil = f.frameCounter + f.settleAfter
+}
+
+// SetMinMode sets the least decode work the controller may leave out, so decode
+// cost can be lowered before the controller sees frames getting slow. The
+// current mode is raised to it right away.
+func (f *FrameSkipper) SetMinMode(mode SkipMode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	// Fallback: always decode (safety)
-	return SkipDecision{
-		ShouldDecode: true,
-		ShouldSkip:   false,
-		Reason:       "fallback:decode",
-		CurrentMode:  f.mode,
+	mode = min(max(mode, ModeNormal), maxSkipMode)
+	if mode == f.minMode {
+		return
+	}
+	f.minMode = mode
+	if f.mode < mode {
+		f.setModeLocked(mode)
+		skipModeMetric.Set(int(f.mode))
 	}
+	logger.Info("frame skipper: minimum mode changed", "min_mode", mode.String(), "mode", f.mode.String())
 }
 
-// Reset returns the frame skipper to initial state (Normal mode)
+// Reset returns the frame skipper to initial state (Normal mode, or the minimum mode when set)
 // Call this when switching videos or collections
 func (f *FrameSkipper) Reset() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	oldMode := f.mode
-	f.mode = ModeNormal
+	f.mode = f.minMode
 	f.frameCounter = 0
+	f.latency = 0
 	f.consecutiveSlow = 0
 	f.consecutiveGood = 0
+	f.holdUntil = 0
+	skipModeMetric.Set(int(f.mode))
 
-	if oldMode != ModeNormal {
-		log.Printf("FrameSkipper: Reset to Normal mode")
+	if oldMode != f.mode {
+		logger.Debug("frame skipper: reset", "mode", f.mode.String())
 	}
 }
 
@@ -224,6 +205,8 @@ func (f *FrameSkipper) GetStats() FrameSkipperStats {
 	return FrameSkipperStats{
 		Mode:            f.mode,
 		FrameCounter:    f.frameCounter,
+		LatencyMs:       toMs(f.latency),
+		TargetMs:        toMs(f.target),
 		ConsecutiveSlow: f.consecutiveSlow,
 		ConsecutiveGood: f.consecutiveGood,
 	}
@@ -233,18 +216,27 @@ func (f *FrameSkipper) GetStats() FrameSkipperStats {
 type FrameSkipperStats struct {
 	Mode            SkipMode
 	FrameCounter    uint64
+	LatencyMs       float64 // Smoothed decode time
+	TargetMs        float64 // Decode time the controller aims for
 	ConsecutiveSlow int
 	ConsecutiveGood int
 }
 
-// SetThresholds allows customizing performance thresholds
+// SetTargetLatency sets the decode time the controller aims to stay under
 // Useful for tuning on different hardware
-func (f *FrameSkipper) SetThresholds(slowMs, goodMs float64) {
+func (f *FrameSkipper) SetTargetLatency(targetMs float64) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	f.slowThreshold = time.Duration(slowMs * float64(time.Millisecond))
-	f.goodThreshold = time.Duration(goodMs * float64(time.Millisecond))
+	if targetMs <= 0 {
+		return
+	}
+	f.target = time.Duration(targetMs * float64(time.Millisecond))
+
+	logger.Debug("frame skipper: target updated", "target_ms", targetMs)
+}
 
-	log.Printf("FrameSkipper: Thresholds updated (slow>%.1fms, good<%.1fms)", slowMs, goodMs)
+// toMs converts a duration to fractional milliseconds
+func toMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
 }
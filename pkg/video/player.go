@@ -11,6 +11,9 @@ package video
 #include <libswscale/swscale.h>
 #include <libavutil/log.h>
 
+// decoder_log is defined in avlog.c and hands the message to the video logger
+void decoder_log(int level, const char *fmt, ...);
+
 // ---------------------- C structures ----------------------------
 
 typedef struct {
@@ -39,12 +42,12 @@ int init_decoder(const char *filename, Decoder *d) {
 
     // Open file / stream
     if (avformat_open_input(&d->formatCtx, filename, NULL, NULL) != 0) {
-        fprintf(stderr, "Could not open input file '%s'\n", filename);
+        decoder_log(AV_LOG_ERROR, "Could not open input file '%s'\n", filename);
         return -1;
     }
 
     if (avformat_find_stream_info(d->formatCtx, NULL) < 0) {
-        fprintf(stderr, "Could not find stream information\n");
+        decoder_log(AV_LOG_ERROR, "Could not find stream information\n");
         return -2;
     }
 
@@ -59,20 +62,20 @@ int init_decoder(const char *filename, Decoder *d) {
     // Debug: Print available decoders
     const char *debugDecoders = getenv("DEBUG_DECODERS");
     if (debugDecoders && strcmp(debugDecoders, "1") == 0) {
-        fprintf(stderr, "=== Available decoders ===\n");
+        decoder_log(AV_LOG_INFO, "=== Available decoders ===\n");
         void *iter = NULL;
         const AVCodec *c = NULL;
         while ((c = av_codec_iterate(&iter))) {
             if (av_codec_is_decoder(c)) {
-                fprintf(stderr, "  %s (%s)\n", c->name, c->long_name ? c->long_name : "no description");
+                decoder_log(AV_LOG_INFO, "  %s (%s)\n", c->name, c->long_name ? c->long_name : "no description");
             }
         }
-        fprintf(stderr, "=== End decoder list ===\n");
+        decoder_log(AV_LOG_INFO, "=== End decoder list ===\n");
     }
 
     // If FORCE_SOFTWARE_DECODER is set, skip hardware decoder selection
     if (forceSwDecoder && strcmp(forceSwDecoder, "1") == 0) {
-        fprintf(stderr, "FORCE_SOFTWARE_DECODER=1: Skipping hardware decoder selection\n");
+        decoder_log(AV_LOG_INFO, "FORCE_SOFTWARE_DECODER=1: Skipping hardware decoder selection\n");
         codec = NULL; // Will fall back to software decoder later
     } else {
         // We'll later verify that the selected codec actually matches the
@@ -82,9 +85,9 @@ int init_decoder(const char *filename, Decoder *d) {
         if (envDecoder && envDecoder[0] != '\0') {
             codec = avcodec_find_decoder_by_name(envDecoder);
             if (!codec) {
-                fprintf(stderr, "Decoder specified by VIDEO_DECODER ('%s') not found. Falling back to defaults.\n", envDecoder);
+                decoder_log(AV_LOG_WARNING, "Decoder specified by VIDEO_DECODER ('%s') not found. Falling back to defaults.\n", envDecoder);
             } else {
-                fprintf(stderr, "Using decoder from VIDEO_DECODER: %s\n", envDecoder);
+                decoder_log(AV_LOG_INFO, "Using decoder from VIDEO_DECODER: %s\n", envDecoder);
             }
         }
     }
@@ -102,11 +105,11 @@ int init_decoder(const char *filename, Decoder *d) {
             d->videoStream = (int)i;
             enum AVCodecID stream_codec_id = d->formatCtx->streams[i]->codecpar->codec_id;
 
-            fprintf(stderr, "Stream codec ID: %d\n", stream_codec_id);
+            decoder_log(AV_LOG_VERBOSE, "Stream codec ID: %d\n", stream_codec_id);
 
             // If we already have a matching codec from environment variable, use it
             if (codec && codec->id == stream_codec_id) {
-                fprintf(stderr, "Using pre-selected decoder: %s (matches stream codec)\n", codec->name);
+                decoder_log(AV_LOG_INFO, "Using pre-selected decoder: %s (matches stream codec)\n", codec->name);
                 break;
             }
 
@@ -117,7 +120,7 @@ int init_decoder(const char *filename, Decoder *d) {
             // Build priority list based on stream codec type
             switch (stream_codec_id) {
                 case AV_CODEC_ID_HEVC: // H.265/HEVC
-                    fprintf(stderr, "Detected HEVC/H.265 stream, prioritizing HEVC decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected HEVC/H.265 stream, prioritizing HEVC decoders\n");
 #ifdef __linux__
                     // NOTE: V4L2 decoders are not working on Raspberry Pi 4
                     // Commenting out until proper kernel drivers are available
@@ -134,7 +137,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 case AV_CODEC_ID_H264: // H.264/AVC
-                    fprintf(stderr, "Detected H.264 stream, prioritizing H.264 decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected H.264 stream, prioritizing H.264 decoders\n");
 #ifdef __linux__
                     // NOTE: V4L2 decoders are not working on Raspberry Pi 4
                     // priority_decoders[decoder_count++] = "h264_v4l2request";    // V4L2 request API (not implemented on Pi 4)
@@ -151,7 +154,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 case AV_CODEC_ID_VP9: // VP9
-                    fprintf(stderr, "Detected VP9 stream, prioritizing VP9 decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected VP9 stream, prioritizing VP9 decoders\n");
 #ifdef __linux__
                     priority_decoders[decoder_count++] = "vp9_v4l2m2m";         // VP9 mem2mem
                     priority_decoders[decoder_count++] = "vp9_vaapi";           // Intel/AMD VAAPI
@@ -160,7 +163,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 case AV_CODEC_ID_VP8: // VP8
-                    fprintf(stderr, "Detected VP8 stream, prioritizing VP8 decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected VP8 stream, prioritizing VP8 decoders\n");
 #ifdef __linux__
                     priority_decoders[decoder_count++] = "vp8_v4l2m2m";         // VP8 mem2mem
                     priority_decoders[decoder_count++] = "vp8_vaapi";           // Intel/AMD VAAPI
@@ -169,7 +172,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 case AV_CODEC_ID_AV1: // AV1
-                    fprintf(stderr, "Detected AV1 stream, prioritizing AV1 decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected AV1 stream, prioritizing AV1 decoders\n");
 #ifdef __linux__
                     priority_decoders[decoder_count++] = "av1_v4l2m2m";         // AV1 mem2mem
                     priority_decoders[decoder_count++] = "av1_vaapi";           // Intel/AMD VAAPI
@@ -178,7 +181,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 case AV_CODEC_ID_MPEG2VIDEO: // MPEG-2
-                    fprintf(stderr, "Detected MPEG-2 stream, prioritizing MPEG-2 decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected MPEG-2 stream, prioritizing MPEG-2 decoders\n");
 #ifdef __linux__
                     // NOTE: V4L2 MPEG-2 decoder not working on Raspberry Pi 4
                     // priority_decoders[decoder_count++] = "mpeg2_v4l2m2m";       // MPEG-2 mem2mem (not working on Pi 4)
@@ -189,7 +192,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 case AV_CODEC_ID_MPEG4: // MPEG-4
-                    fprintf(stderr, "Detected MPEG-4 stream, prioritizing MPEG-4 decoders\n");
+                    decoder_log(AV_LOG_VERBOSE, "Detected MPEG-4 stream, prioritizing MPEG-4 decoders\n");
 #ifdef __linux__
                     priority_decoders[decoder_count++] = "mpeg4_v4l2m2m";       // MPEG-4 mem2mem
                     priority_decoders[decoder_count++] = "mpeg4_vaapi";         // Intel/AMD VAAPI
@@ -198,7 +201,7 @@ int init_decoder(const char *filename, Decoder *d) {
                     break;
 
                 default:
-                    fprintf(stderr, "Unknown or legacy codec (id=%d), using default decoder search\n", stream_codec_id);
+                    decoder_log(AV_LOG_VERBOSE, "Unknown or legacy codec (id=%d), using default decoder search\n", stream_codec_id);
                     // For unknown codecs, we'll let the default decoder search handle it
                     // V4L2 decoders commented out as they don't work on Raspberry Pi 4
 #ifdef __linux__
@@ -216,23 +219,23 @@ int init_decoder(const char *filename, Decoder *d) {
             for (int j = 0; j < decoder_count; j++) {
                 const AVCodec* candidate = avcodec_find_decoder_by_name(priority_decoders[j]);
                 if (!candidate) {
-                    fprintf(stderr, "Decoder %s not available\n", priority_decoders[j]);
+                    decoder_log(AV_LOG_VERBOSE, "Decoder %s not available\n", priority_decoders[j]);
                     continue;
                 }
 
                 if (candidate->id != stream_codec_id) {
-                    fprintf(stderr, "Skipped decoder %s (id=%d) - doesn't match stream codec %d\n",
+                    decoder_log(AV_LOG_VERBOSE, "Skipped decoder %s (id=%d) - doesn't match stream codec %d\n",
                             priority_decoders[j], candidate->id, stream_codec_id);
                     continue;
                 }
 
-                fprintf(stderr, "Trying decoder: %s (id=%d) for codec %d\n",
+                decoder_log(AV_LOG_VERBOSE, "Trying decoder: %s (id=%d) for codec %d\n",
                         candidate->name, candidate->id, stream_codec_id);
 
                 // Test if this decoder can actually be opened
                 AVCodecContext* test_ctx = avcodec_alloc_context3(candidate);
                 if (!test_ctx) {
-                    fprintf(stderr, "Failed to allocate context for decoder: %s\n", candidate->name);
+                    decoder_log(AV_LOG_WARNING, "Failed to allocate context for decoder: %s\n", candidate->name);
                     continue;
                 }
 
@@ -241,7 +244,7 @@ int init_decoder(const char *filename, Decoder *d) {
                 test_ctx->thread_count = 0;
 
                 if (avcodec_open2(test_ctx, candidate, NULL) < 0) {
-                    fprintf(stderr, "Failed to open decoder: %s (hardware not available or misconfigured)\n", candidate->name);
+                    decoder_log(AV_LOG_VERBOSE, "Failed to open decoder: %s (hardware not available or misconfigured)\n", candidate->name);
                     avcodec_free_context(&test_ctx);
                     continue;
                 }
@@ -265,7 +268,7 @@ int init_decoder(const char *filename, Decoder *d) {
                                      strstr(codec->name, "_videotoolbox") != NULL ||
                                      strstr(codec->name, "_qsv") != NULL);
 
-                fprintf(stderr, "Successfully opened decoder: %s (id=%d) for codec %d [HW=%s]\n",
+                decoder_log(AV_LOG_INFO, "Successfully opened decoder: %s (id=%d) for codec %d [HW=%s]\n",
                         codec->name, codec->id, stream_codec_id,
                         d->isHardwareAccel ? "YES" : "NO");
                 break;
@@ -273,10 +276,10 @@ int init_decoder(const char *filename, Decoder *d) {
 
             // Final fallback to any available decoder for this codec
             if (!decoder_opened) {
-                fprintf(stderr, "No priority decoder worked, trying default decoder for codec %d\n", stream_codec_id);
+                decoder_log(AV_LOG_WARNING, "No priority decoder worked, trying default decoder for codec %d\n", stream_codec_id);
                 const AVCodec* default_codec = avcodec_find_decoder(stream_codec_id);
                 if (default_codec) {
-                    fprintf(stderr, "Trying default decoder: %s\n", default_codec->name);
+                    decoder_log(AV_LOG_VERBOSE, "Trying default decoder: %s\n", default_codec->name);
                     AVCodecContext* test_ctx = avcodec_alloc_context3(default_codec);
                     if (test_ctx) {
                         avcodec_parameters_to_context(test_ctx, d->formatCtx->streams[i]->codecpar);
@@ -294,9 +297,9 @@ int init_decoder(const char *filename, Decoder *d) {
                             d->codecLongName = default_codec->long_name ? default_codec->long_name : "Unknown";
                             d->isHardwareAccel = 0; // Default fallback is always software
 
-                            fprintf(stderr, "Successfully opened default decoder: %s [HW=NO]\n", default_codec->name);
+                            decoder_log(AV_LOG_INFO, "Successfully opened default decoder: %s [HW=NO]\n", default_codec->name);
                         } else {
-                            fprintf(stderr, "Failed to open default decoder: %s\n", default_codec->name);
+                            decoder_log(AV_LOG_ERROR, "Failed to open default decoder: %s\n", default_codec->name);
                             avcodec_free_context(&test_ctx);
                         }
                     }
@@ -304,7 +307,7 @@ int init_decoder(const char *filename, Decoder *d) {
             }
 
             if (!decoder_opened || !codec || !d->codecCtx) {
-                fprintf(stderr, "Could not find any working decoder for codec id %d\n", stream_codec_id);
+                decoder_log(AV_LOG_ERROR, "Could not find any working decoder for codec id %d\n", stream_codec_id);
                 return -3;
             }
 
@@ -312,14 +315,14 @@ int init_decoder(const char *filename, Decoder *d) {
         }
     }
     if (d->videoStream == -1) {
-        fprintf(stderr, "No video stream found\n");
+        decoder_log(AV_LOG_ERROR, "No video stream found\n");
         return -3;
     }
 
     // Codec is already opened in the loop above, no need to open again
 
     // Debug: Print final decoder info
-    fprintf(stderr, "Final decoder ready: %s (id=%d) for stream %dx%d\n",
+    decoder_log(AV_LOG_VERBOSE, "Final decoder ready: %s (id=%d) for stream %dx%d\n",
             codec ? codec->name : "unknown", codec ? codec->id : -1,
             d->codecCtx->width, d->codecCtx->height);
 
@@ -381,11 +384,41 @@ int decode_frame(Decoder *d, uint8_t **rgba_data) {
     return 0; // EOF
 }
 
+// Index of the frame returned by the last decode_frame, counted from the start
+// of the stream at fps. Returns -1 when the frame has no usable timestamp.
+long long frame_index(Decoder *d, double fps) {
+    int64_t ts = d->frame->best_effort_timestamp;
+    if (ts == AV_NOPTS_VALUE) {
+        return -1;
+    }
+    AVStream *st = d->formatCtx->streams[d->videoStream];
+    if (st->start_time != AV_NOPTS_VALUE) {
+        ts -= st->start_time;
+    }
+    double index = (double)ts * av_q2d(st->time_base) * fps;
+    if (index < 0) {
+        return -1;
+    }
+    return (long long)(index + 0.5);
+}
+
+// Let libavcodec leave out decode work under load. Level 0 decodes everything,
+// 1 skips the loop filter on non-reference frames, 2 skips it on all frames and
+// 3 also discards non-reference frames. Decoders that do not support this
+// (most hardware decoders) ignore it.
+void set_discard(Decoder *d, int level) {
+    if (!d || !d->codecCtx) return;
+    d->codecCtx->skip_loop_filter = level >= 2 ? AVDISCARD_ALL : (level == 1 ? AVDISCARD_NONREF : AVDISCARD_DEFAULT);
+    d->codecCtx->skip_frame = level >= 3 ? AVDISCARD_NONREF : AVDISCARD_DEFAULT;
+}
+
+// Safe to call on a partially initialised or already closed decoder.
 void close_decoder(Decoder *d) {
     if (!d) return;
-    av_free(d->bufferRGBA);
+    av_freep(&d->bufferRGBA);
     av_frame_free(&d->frameRGBA);
     sws_freeContext(d->swsCtx);
+    d->swsCtx = NULL;
     av_frame_free(&d->frame);
     // avcodec_close is deprecated. Use avcodec_free_context instead.
     avcodec_free_context(&d->codecCtx);
@@ -436,12 +469,13 @@ import "C"
 import (
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"sync"
 	"time"
 	"unsafe"
 
+	"flow-frame/pkg/sharedTypes"
+
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -464,10 +498,9 @@ func newVideoDecoder(path string) (*videoDecoder, error) {
 
 	dec := &videoDecoder{}
 	if ret := C.init_decoder(cPath, &dec.cdec); ret != 0 {
-		if int(ret) == -5 {
-			panic(fmt.Sprintf("fatal: fallback decoder failed to open (code=%d)", int(ret)))
-		}
-		return nil, fmt.Errorf("init_decoder failed (code=%d)", int(ret))
+		// Release whatever was opened before the failure
+		C.close_decoder(&dec.cdec)
+		return nil, fmt.Errorf("init_decoder failed for %s (code=%d)", path, int(ret))
 	}
 
 	dec.width = int(dec.cdec.codecCtx.width)
@@ -491,15 +524,16 @@ func newVideoDecoder(path string) (*videoDecoder, error) {
 		hwStatus = "HARDWARE"
 	}
 
-	log.Printf("Decoder: %s [%s] %dx%d @ %.1ffps | Accel=%s | Output=RGBA",
-		dec.codecName, dec.codecLongName, dec.width, dec.height, dec.fps, hwStatus)
+	logger.Info("decoder opened", "codec", dec.codecName, "name", dec.codecLongName,
+		"width", dec.width, "height", dec.height, "fps", dec.fps, "accel", hwStatus)
 
 	return dec, nil
 }
 
 // frameData holds RGBA pixel data
 type frameData struct {
-	rgba []byte
+	rgba  []byte
+	index int // frame number from the timestamp, -1 when unknown
 }
 
 func (d *videoDecoder) nextFrame() (*frameData, error) {
@@ -515,10 +549,16 @@ func (d *videoDecoder) nextFrame() (*frameData, error) {
 	// Data points to RGBA buffer
 	bufLen := d.width * d.height * 4 // RGBA
 	return &frameData{
-		rgba: C.GoBytes(unsafe.Pointer(data), C.int(bufLen)),
+		rgba:  C.GoBytes(unsafe.Pointer(data), C.int(bufLen)),
+		index: int(C.frame_index(&d.cdec, C.double(d.fps))),
 	}, nil
 }
 
+// setSkipMode tells libavcodec how much decode work it may leave out
+func (d *videoDecoder) setSkipMode(mode SkipMode) {
+	C.set_discard(&d.cdec, C.int(mode))
+}
+
 func (d *videoDecoder) close() {
 	C.close_decoder(&d.cdec)
 }
@@ -536,6 +576,9 @@ type Player struct {
 	playbackRate float64
 	loop         bool
 	refTime      time.Time
+	loops        int                 // completed plays through the video (a bounce counts once)
+	frame        int                 // frame index within the current loop
+	fit          sharedTypes.FitMode // how the frame is scaled to the screen
 
 	// Bounce replay support
 	bounce         bool
@@ -549,6 +592,9 @@ type Player struct {
 	acc      float64   // accumulated fractional frames
 	lastTime time.Time // last wall-clock timestamp
 
+	skipMode SkipMode   // decode work libavcodec may leave out
+	stats    FrameStats // decoder work since the last TakeFrameStats
+
 	// book-keeping
 	m         sync.Mutex
 	closeOnce sync.Once
@@ -572,6 +618,7 @@ func NewPlayer(src io.ReadCloser) (*Player, error) {
 		dec:          dec,
 		playbackRate: 1.0,
 		loop:         true,
+		fit:          sharedTypes.FitContain,
 		src:          src,
 	}
 
@@ -602,6 +649,7 @@ func (p *Player) SetRenderer(renderer *sdl.Renderer) error {
 		return err
 	}
 	p.updateTexture(firstFrame)
+	p.frame = max(firstFrame.index, 0)
 
 	return nil
 }
@@ -612,6 +660,9 @@ func (p *Player) updateTexture(frame *frameData) error {
 		return fmt.Errorf("texture not initialized")
 	}
 
+	start := time.Now()
+	defer func() { p.stats.Upload += time.Since(start) }()
+
 	// Lock and copy RGBA pixel data
 	pixels, pitch, err := p.texture.Lock(nil)
 	if err != nil {
@@ -626,6 +677,35 @@ func (p *Player) updateTexture(frame *frameData) error {
 	return nil
 }
 
+// FrameStats counts decoder work since the previous TakeFrameStats call
+type FrameStats struct {
+	Decoded   int           // frames decoded and uploaded
+	Discarded int           // frames the decoder left out under SkipMode
+	Upload    time.Duration // time spent uploading frames to the texture
+}
+
+// TakeFrameStats returns the decoder work since the previous call, so callers
+// can tell upload from decode time and count discarded frames.
+func (p *Player) TakeFrameStats() FrameStats {
+	p.m.Lock()
+	defer p.m.Unlock()
+	stats := p.stats
+	p.stats = FrameStats{}
+	return stats
+}
+
+// SetSkipMode sets how much decode work libavcodec may leave out. The mode
+// survives loop restarts.
+func (p *Player) SetSkipMode(mode SkipMode) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if mode == p.skipMode {
+		return
+	}
+	p.skipMode = mode
+	p.dec.setSkipMode(mode)
+}
+
 // PreloadFirstFrame decodes and uploads the very first frame so that Draw has pixels.
 func (p *Player) PreloadFirstFrame() error {
 	p.m.Lock()
@@ -642,13 +722,26 @@ func (p *Player) PreloadFirstFrame() error {
 	return p.updateTexture(data)
 }
 
-// Play marks the reference time so that playback resumes.
+// Play marks the reference time so that playback resumes. Time spent before
+// the call (e.g. while paused) does not advance the video.
 func (p *Player) Play() {
 	p.m.Lock()
 	p.refTime = time.Now()
+	p.lastTime = p.refTime
+	p.acc = 0
 	p.m.Unlock()
 }
 
+// Position returns the playback position within the current loop.
+func (p *Player) Position() time.Duration {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.dec == nil || p.dec.fps <= 0 {
+		return 0
+	}
+	return time.Duration(float64(p.frame) / p.dec.fps * float64(time.Second))
+}
+
 // SetPlaybackRate updates the logical playback rate (currently best-effort).
 func (p *Player) SetPlaybackRate(rate float64) {
 	if rate <= 0 {
@@ -676,6 +769,21 @@ func (p *Player) SetBounceLoop(bounce bool) {
 	p.m.Unlock()
 }
 
+// SetFitMode selects how Draw scales the frame to the screen.
+func (p *Player) SetFitMode(fit sharedTypes.FitMode) {
+	p.m.Lock()
+	p.fit = fit
+	p.m.Unlock()
+}
+
+// Loops returns how many times the video has played through since it was opened.
+// With bounce looping a forward and backward pass count as one play.
+func (p *Player) Loops() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.loops
+}
+
 // HasEnded reports whether decoding has reached EOF and no bounce/loop is pending.
 func (p *Player) HasEnded() bool {
 	p.m.Lock()
@@ -707,13 +815,17 @@ func (p *Player) UpdateFrame() error {
 	dt := now.Sub(p.lastTime).Seconds()
 	p.lastTime = now
 
+	// A failed loop restart leaves the decoder closed; the caller moves on to another video
+	if p.dec.cdec.formatCtx == nil {
+		return fmt.Errorf("decoder for %s is closed", p.dec.codecName)
+	}
+
 	p.acc += dt * p.playbackRate * p.dec.fps
 
 	// Debug frame updates if environment variable is set
 	debugFrames := os.Getenv("DEBUG_FRAME_UPDATES")
 	if debugFrames == "1" && int(p.acc) > 0 {
-		log.Printf("UpdateFrame: dt=%.3fs, acc=%.3f, fps=%.1f, rate=%.2fx, steps=%d",
-			dt, p.acc, p.dec.fps, p.playbackRate, int(p.acc))
+		logger.Debug("advancing frames", "dt", dt, "acc", p.acc, "fps", p.dec.fps, "rate", p.playbackRate, "steps", int(p.acc))
 	}
 
 	// -------------- bounce reverse path ------------------
@@ -726,6 +838,7 @@ func (p *Player) UpdateFrame() error {
 
 		if p.cacheIdx < 0 {
 			// Finished reverse. Reset state, restart decoder.
+			p.loops++
 			p.playingCached = false
 			p.bounceFrames = nil
 			p.cacheIdx = 0
@@ -734,6 +847,7 @@ func (p *Player) UpdateFrame() error {
 		}
 
 		if p.cacheIdx >= 0 && p.cacheIdx < len(p.bounceFrames) {
+			p.frame = p.bounceFrames[p.cacheIdx].index
 			return p.updateTexture(p.bounceFrames[p.cacheIdx])
 		}
 		return nil
@@ -745,20 +859,36 @@ func (p *Player) UpdateFrame() error {
 		return nil // not time for next frame yet
 	}
 
+	// While the decoder discards non-reference frames they show up as gaps in
+	// the frame index. They count towards the steps, and a frame ahead of the
+	// clock waits for it. In other modes nothing is discarded, so gaps come from
+	// timestamp rounding or variable frame rates and each frame is one step.
 	var frameData *frameData
 	var err error
-	for i := 0; i < steps; i++ {
+	advanced := 0
+	for advanced < steps {
 		frameData, err = p.dec.nextFrame()
 		if err != nil {
 			break
 		}
+		n := 1
+		if p.skipMode == ModeDiscardNonRef && frameData.index > p.frame {
+			n = frameData.index - p.frame
+		}
+		p.frame = max(p.frame+1, frameData.index)
+		p.stats.Discarded += n - 1
+		advanced += n
 	}
-	p.acc -= float64(steps)
+	if advanced < steps {
+		advanced = steps
+	}
+	p.acc -= float64(advanced)
 
 	if err == io.EOF {
 		if p.bounce {
 			if len(p.bounceFrames) == 0 {
 				// Nothing cached; simple loop fallback.
+				p.loops++
 				return p.restartLocked()
 			}
 			p.playingCached = true
@@ -767,6 +897,7 @@ func (p *Player) UpdateFrame() error {
 		}
 
 		if p.loop {
+			p.loops++
 			return p.restartLocked()
 		}
 		return err
@@ -779,41 +910,63 @@ func (p *Player) UpdateFrame() error {
 	if err := p.updateTexture(frameData); err != nil {
 		return err
 	}
+	p.stats.Decoded++
 
 	// Debug successful frame upload
 	if debugFrames == "1" {
-		log.Printf("UpdateFrame: Successfully decoded and uploaded frame to texture")
+		logger.Debug("decoded and uploaded frame")
 	}
 
-	// Save for bounce
+	// Save for bounce, with the position it was shown at so the reverse pass
+	// reports the same positions even where frames were skipped
 	if p.bounce {
+		frameData.index = p.frame
 		p.bounceFrames = append(p.bounceFrames, frameData)
 	}
 
 	return nil
 }
 
-// Draw renders the current frame to the provided SDL2 renderer with letter boxing.
+// Draw renders the current frame to the provided SDL2 renderer, scaled
+// according to the fit mode (letter boxing by default).
 func (p *Player) Draw(renderer *sdl.Renderer, screenWidth, screenHeight int32) error {
 	p.m.Lock()
 	texture := p.texture
+	fit := p.fit
 	p.m.Unlock()
 
 	if texture == nil {
 		return nil
 	}
 
-	// Calculate letterboxing
 	videoWidth := int32(p.dec.width)
 	videoHeight := int32(p.dec.height)
 
+	if fit == sharedTypes.FitStretch {
+		return renderer.Copy(texture, nil, &sdl.Rect{X: 0, Y: 0, W: screenWidth, H: screenHeight})
+	}
+
+	// Contain uses the smaller scale (letterbox), cover the larger one (crop)
 	scaleW := float64(screenWidth) / float64(videoWidth)
 	scaleH := float64(screenHeight) / float64(videoHeight)
 	scale := scaleW
-	if scaleH < scaleW {
+	if (fit == sharedTypes.FitCover) == (scaleH > scaleW) {
 		scale = scaleH
 	}
 
+	if fit == sharedTypes.FitCover {
+		// Crop the source to the part of the frame that fits the screen
+		srcWidth := int32(float64(screenWidth) / scale)
+		srcHeight := int32(float64(screenHeight) / scale)
+		srcRect := sdl.Rect{
+			X: (videoWidth - srcWidth) / 2,
+			Y: (videoHeight - srcHeight) / 2,
+			W: srcWidth,
+			H: srcHeight,
+		}
+		return renderer.Copy(texture, &srcRect, &sdl.Rect{X: 0, Y: 0, W: screenWidth, H: screenHeight})
+	}
+
 	renderWidth := int32(float64(videoWidth) * scale)
 	renderHeight := int32(float64(videoHeight) * scale)
 
@@ -869,6 +1022,7 @@ func (p *Player) restartLocked() error {
 		return err
 	}
 	p.dec = dec
+	p.dec.setSkipMode(p.skipMode)
 
 	// Recreate texture with new dimensions
 	if p.texture != nil {
@@ -892,6 +1046,7 @@ func (p *Player) restartLocked() error {
 
 	// Reset playback counters so that timing resumes smoothly from the start.
 	p.acc = 0
+	p.frame = max(firstFrame.index, 0)
 	p.lastTime = time.Now()
 
 	return nil
@@ -1,10 +1,15 @@
 package videoFs
 
 import (
-	"log"
+	"errors"
 	"os"
 )
 
+// ErrNoVideos is returned by AvailableDownloadedVideos when nothing playable is on disk
+var ErrNoVideos = errors.New("no downloaded videos found")
+
+// AvailableDownloadedVideos lists the playable files already on disk, for when
+// nothing can be downloaded
 func AvailableDownloadedVideos() ([]string, error) {
 	var videos []string
 
@@ -12,19 +17,25 @@ func AvailableDownloadedVideos() ([]string, error) {
 	scanDir := func(dirPath string) {
 		entries, err := os.ReadDir(dirPath)
 		if err != nil {
-			log.Printf("Error reading %s directory: %v", dirPath, err)
+			logger.Warn("cannot read video directory", "dir", dirPath, "err", err)
 			return
 		}
 
-		// Filter for mpg files
+		// Keep files libavformat can open and we can decode
 		for _, entry := range entries {
-			if !entry.IsDir() {
-				name := entry.Name()
-				// Check if file has .mpg or .mpeg extension
-				if len(name) > 4 && (name[len(name)-4:] == ".mpg" || name[len(name)-5:] == ".mpeg") {
-					videos = append(videos, dirPath+"/"+name)
-				}
+			if entry.IsDir() || isHiddenFile(entry.Name()) {
+				continue
+			}
+			path := dirPath + "/" + entry.Name()
+			info, err := ProbeCached(path)
+			if err != nil {
+				continue // not a media file (e.g. settings or manifests)
+			}
+			if !info.Playable {
+				logger.Debug("skipping downloaded video", "file", info)
+				continue
 			}
+			videos = append(videos, path)
 		}
 	}
 
@@ -34,9 +45,9 @@ func AvailableDownloadedVideos() ([]string, error) {
 	scanDir("assets")
 
 	if len(videos) == 0 {
-		panic("No downloaded videos found")
+		return nil, ErrNoVideos
 	}
 
-	log.Printf("AvailableDownloadedVideos completed | found=%d video(s)", len(videos))
+	logger.Info("downloaded videos listed", "count", len(videos))
 	return videos, nil
 }
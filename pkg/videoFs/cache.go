@@ -0,0 +1,260 @@
+package videoFs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/sharedTypes"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Synced S3 collections are kept in <data dir>/cache/<collection id>. Cached
+// items are played in place instead of being downloaded again, and the cached
+// listing is used when S3 cannot be reached.
+
+// cacheIndexName is the file listing the cached items of a collection
+const cacheIndexName = "index.json"
+
+// cacheMu serializes index reads and writes between the player and sync
+var cacheMu sync.Mutex
+
+// cachedItem is an index entry
+type cachedItem struct {
+	sharedTypes.CollectionItem
+	File string `json:"file"` // file name inside the cache directory
+	Size int64  `json:"size"`
+	ETag string `json:"etag,omitempty"`
+}
+
+// cacheIndex lists the items of a collection that are available offline
+type cacheIndex struct {
+	Items []cachedItem `json:"items"`
+}
+
+// SyncResult summarizes a SyncCollection run
+type SyncResult struct {
+	Dir        string
+	Downloaded int
+	UpToDate   int
+	Rejected   []string // keys that downloaded but are not playable
+	Failed     []string // keys that could not be downloaded
+	Removed    int      // cached files no longer in the collection
+	Bytes      int64    // bytes downloaded
+}
+
+// CacheDir returns the cache directory of a collection
+func CacheDir(collection sharedTypes.Collection) string {
+	return appdata.Path(filepath.Join("cache", collection.Id))
+}
+
+// loadCacheIndex reads the cache index; a missing index is empty. Callers must hold cacheMu.
+func loadCacheIndex(dir string) cacheIndex {
+	var idx cacheIndex
+	data, err := os.ReadFile(filepath.Join(dir, cacheIndexName))
+	if err != nil {
+		return idx
+	}
+	if err := json.Unmarshal(data, &idx); err != nil {
+		logger.Warn("ignoring corrupt cache index", "dir", dir, "err", err)
+		return cacheIndex{}
+	}
+	return idx
+}
+
+// saveCacheIndex replaces the cache index. Callers must hold cacheMu.
+func saveCacheIndex(dir string, idx cacheIndex) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return appdata.WriteFileAtomic(filepath.Join(dir, cacheIndexName), data, 0o644)
+}
+
+// CachedItems returns the items of a collection that are cached and still on disk
+func CachedItems(collection sharedTypes.Collection) []sharedTypes.CollectionItem {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	dir := CacheDir(collection)
+	var items []sharedTypes.CollectionItem
+	for _, c := range loadCacheIndex(dir).Items {
+		if _, err := os.Stat(filepath.Join(dir, c.File)); err == nil {
+			items = append(items, c.CollectionItem)
+		}
+	}
+	return items
+}
+
+// cachedPaths maps item keys to cached files that are still on disk
+func cachedPaths(collection sharedTypes.Collection) map[string]string {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	dir := CacheDir(collection)
+	paths := make(map[string]string)
+	for _, c := range loadCacheIndex(dir).Items {
+		path := filepath.Join(dir, c.File)
+		if st, err := os.Stat(path); err == nil && st.Size() == c.Size {
+			paths[c.Key] = path
+		}
+	}
+	return paths
+}
+
+// SyncCollection downloads every item of an S3 collection into its cache
+// directory so it can play without network access. Items whose ETag and size
+// are unchanged are skipped, and cached files no longer in the collection are
+// removed. progress, when not nil, is called before each item. When ctx is
+// cancelled the items synced so far are kept and ctx's error is returned.
+func SyncCollection(ctx context.Context, collection sharedTypes.Collection, progress func(index, count int, key string)) (SyncResult, error) {
+	dir := CacheDir(collection)
+	result := SyncResult{Dir: dir}
+	if collection.IsLocal() {
+		return result, fmt.Errorf("%s is a local collection and needs no sync", collection.Title)
+	}
+
+	items, err := ListCollectionItems(collection)
+	if err != nil {
+		return result, err
+	}
+	s3Client, err := newS3Client()
+	if err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return result, err
+	}
+
+	cacheMu.Lock()
+	previous := make(map[string]cachedItem)
+	for _, c := range loadCacheIndex(dir).Items {
+		previous[c.Key] = c
+	}
+	cacheMu.Unlock()
+
+	var idx cacheIndex
+	for i, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		if progress != nil {
+			progress(i, len(items), item.Key)
+		}
+
+		head, err := s3Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{Bucket: aws.String(collection.Bucket), Key: aws.String(item.Key)})
+		if err != nil {
+			logger.Warn("sync: stat failed", "key", item.Key, "err", err)
+			result.Failed = append(result.Failed, item.Key)
+			continue
+		}
+		etag, size := aws.StringValue(head.ETag), aws.Int64Value(head.ContentLength)
+
+		if prev, ok := previous[item.Key]; ok && prev.ETag == etag && prev.Size == size {
+			if st, err := os.Stat(filepath.Join(dir, prev.File)); err == nil && st.Size() == size {
+				prev.Weight = item.Weight
+				idx.Items = append(idx.Items, prev)
+				result.UpToDate++
+				continue
+			}
+		}
+
+		file := filepath.Base(item.Key)
+		n, err := downloadToFile(ctx, s3Client, collection.Bucket, item.Key, filepath.Join(dir, file))
+		if err != nil {
+			logger.Warn("sync: download failed", "key", item.Key, "err", err)
+			downloadErrors.Inc()
+			result.Failed = append(result.Failed, item.Key)
+			continue
+		}
+		result.Bytes += n
+
+		if info, err := ProbeCached(filepath.Join(dir, file)); err != nil || !info.Playable {
+			os.Remove(filepath.Join(dir, file))
+			result.Rejected = append(result.Rejected, item.Key)
+			continue
+		}
+		idx.Items = append(idx.Items, cachedItem{CollectionItem: item, File: file, Size: n, ETag: etag})
+		result.Downloaded++
+	}
+
+	if err := ctx.Err(); err != nil {
+		// Keep what was synced so far, including cached items not reached yet
+		for key, prev := range previous {
+			if !slices.ContainsFunc(idx.Items, func(c cachedItem) bool { return c.Key == key }) {
+				idx.Items = append(idx.Items, prev)
+			}
+		}
+		cacheMu.Lock()
+		saveCacheIndex(dir, idx)
+		cacheMu.Unlock()
+		return result, err
+	}
+
+	cacheMu.Lock()
+	err = saveCacheIndex(dir, idx)
+	cacheMu.Unlock()
+	if err != nil {
+		return result, fmt.Errorf("failed to write cache index: %w", err)
+	}
+
+	result.Removed = pruneCache(dir, idx)
+	logger.Info("sync completed", "collection", collection.Title, "downloaded", result.Downloaded,
+		"up_to_date", result.UpToDate, "failed", len(result.Failed), "removed", result.Removed)
+	return result, nil
+}
+
+// downloadToFile downloads an object next to path and renames it into place
+func downloadToFile(ctx context.Context, s3Client *s3.S3, bucket, key, path string) (int64, error) {
+	obj, err := s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return 0, err
+	}
+	defer obj.Body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	n, err := io.Copy(tmp, countingReader{obj.Body})
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
+
+// pruneCache removes files that are not in the index and returns how many were removed
+func pruneCache(dir string, idx cacheIndex) int {
+	keep := map[string]bool{cacheIndexName: true}
+	for _, c := range idx.Items {
+		keep[c.File] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || keep[e.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
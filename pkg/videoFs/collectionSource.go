@@ -0,0 +1,116 @@
+package videoFs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"flow-frame/pkg/sharedTypes"
+)
+
+// isHiddenFile reports whether a file should be ignored without probing,
+// such as macOS "._" resource forks and other dotfiles
+func isHiddenFile(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// ListItems lists the playable items of a collection regardless of where it is stored.
+// When S3 cannot be reached the items of a synced collection are listed from its cache.
+func ListItems(collection sharedTypes.Collection) ([]sharedTypes.CollectionItem, error) {
+	if collection.IsLocal() {
+		return ListLocalItems(collection.Folder)
+	}
+	items, err := ListCollectionItems(collection)
+	if err != nil {
+		if cached := CachedItems(collection); len(cached) > 0 {
+			logger.Warn("collection unavailable, using cached items", "collection", collection.Title, "cached", len(cached), "err", err)
+			return cached, nil
+		}
+	}
+	return items, err
+}
+
+// FetchItems makes the given items available on local disk. Synced S3 items
+// are played from the cache and the rest downloaded into assets/tmp; local
+// items are used in place.
+func FetchItems(ctx context.Context, collection sharedTypes.Collection, items []sharedTypes.CollectionItem) ([]DownloadedItem, error) {
+	if !collection.IsLocal() {
+		return fetchS3Items(ctx, collection, items)
+	}
+
+	available := make([]DownloadedItem, 0, len(items))
+	for _, item := range items {
+		if _, err := os.Stat(item.Key); err != nil {
+			logger.Warn("local item unavailable", "key", item.Key, "err", err)
+			continue
+		}
+		available = append(available, DownloadedItem{Item: item, Path: item.Key})
+	}
+	return available, nil
+}
+
+// fetchS3Items downloads the items that are not cached, preserving the order of items
+func fetchS3Items(ctx context.Context, collection sharedTypes.Collection, items []sharedTypes.CollectionItem) ([]DownloadedItem, error) {
+	cached := cachedPaths(collection)
+	if len(cached) == 0 {
+		cacheMisses.Add(float64(len(items)))
+		return DownloadItemsFromS3(ctx, collection, items)
+	}
+
+	var missing []sharedTypes.CollectionItem
+	for _, item := range items {
+		if _, ok := cached[item.Key]; !ok {
+			missing = append(missing, item)
+		}
+	}
+	cacheHits.Add(float64(len(items) - len(missing)))
+	cacheMisses.Add(float64(len(missing)))
+	downloaded, err := DownloadItemsFromS3(ctx, collection, missing)
+	if err != nil && len(missing) == len(items) {
+		return nil, err
+	}
+	if err != nil {
+		logger.Warn("download failed, continuing with cached items", "err", err)
+	}
+
+	byKey := make(map[string]DownloadedItem, len(downloaded))
+	for _, d := range downloaded {
+		byKey[d.Item.Key] = d
+	}
+	result := make([]DownloadedItem, 0, len(items))
+	for _, item := range items {
+		if path, ok := cached[item.Key]; ok {
+			result = append(result, DownloadedItem{Item: item, Path: path})
+		} else if d, ok := byKey[item.Key]; ok {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// ListLocalItems lists the playable files directly inside dir, sorted by name.
+// Files are probed rather than matched by extension; results are cached so
+// rescans only probe new or modified files. Item keys are absolute paths.
+func ListLocalItems(dir string) ([]sharedTypes.CollectionItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []sharedTypes.CollectionItem
+	for _, entry := range entries {
+		if entry.IsDir() || isHiddenFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if !IsPlayable(path) {
+			continue
+		}
+		items = append(items, sharedTypes.CollectionItem{Key: path, Weight: 1})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items, nil
+}
@@ -0,0 +1,31 @@
+package videoFs
+
+import (
+	"flow-frame/pkg/logging"
+	"flow-frame/pkg/sharedTypes"
+)
+
+// logger is the videoFs subsystem logger
+var logger = logging.For("videoFs")
+
+// BuiltinCollections returns the S3 collections every frame offers
+func BuiltinCollections() []sharedTypes.Collection {
+	return []sharedTypes.Collection{
+		{
+			Id:          "1",
+			Title:       "Impressionism",
+			Description: "Light, color, and fleeting moments.",
+			Bucket:      "flow-frame",
+			Folder:      "calm-abstract",
+			BounceLoop:  true,
+		},
+		{
+			Id:          "2",
+			Title:       "Abstract",
+			Description: "Beyond the tangible world.",
+			Bucket:      "flow-frame",
+			Folder:      "ai-gen",
+			BounceLoop:  true,
+		},
+	}
+}
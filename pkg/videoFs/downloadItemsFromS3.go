@@ -0,0 +1,117 @@
+package videoFs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"flow-frame/pkg/events"
+	"flow-frame/pkg/sharedTypes"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// DownloadedItem pairs a collection item with the local path it can be played from.
+type DownloadedItem struct {
+	Item      sharedTypes.CollectionItem
+	Path      string
+	Temporary bool // true when Path is a downloaded copy that should be deleted after playback
+}
+
+// DownloadItemsFromS3 downloads the given items of a collection into assets/tmp, in order.
+// Items that fail to download are logged and left out of the result. Cancelling
+// ctx aborts the transfer in flight and returns ctx's error.
+func DownloadItemsFromS3(ctx context.Context, collection sharedTypes.Collection, items []sharedTypes.CollectionItem) ([]DownloadedItem, error) {
+	logger.Info("downloading items", "collection", collection.Title, "count", len(items))
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	s3Client, err := newS3Client()
+	if err != nil {
+		return nil, err
+	}
+
+	targetDir := filepath.Join("assets", "tmp")
+	if err := os.MkdirAll(targetDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	downloaded := make([]DownloadedItem, 0, len(items))
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			logger.Info("download cancelled", "downloaded", len(downloaded), "requested", len(items))
+			return downloaded, err
+		}
+		progress := &downloadProgress{DownloadProgress: events.DownloadProgress{
+			CollectionID: collection.Id,
+			Key:          item.Key,
+			Index:        i,
+			Count:        len(items),
+		}}
+		result, err := s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{Bucket: aws.String(collection.Bucket), Key: aws.String(item.Key)})
+		if err != nil {
+			logger.Warn("download failed", "key", item.Key, "err", err)
+			downloadErrors.Inc()
+			progress.finish(err)
+			continue
+		}
+		progress.TotalBytes = aws.Int64Value(result.ContentLength)
+		func() { // anonymous func to ensure Body.Close per iteration
+			defer result.Body.Close()
+
+			localPath := filepath.Join(targetDir, filepath.Base(item.Key))
+			outFile, err := os.Create(localPath)
+			if err != nil {
+				logger.Warn("cannot create file", "path", localPath, "err", err)
+				downloadErrors.Inc()
+				progress.finish(err)
+				return
+			}
+			defer outFile.Close()
+
+			if _, err := io.Copy(io.MultiWriter(outFile, progress), countingReader{result.Body}); err != nil {
+				logger.Warn("cannot write file", "path", localPath, "err", err)
+				downloadErrors.Inc()
+				progress.finish(err)
+				os.Remove(localPath) // never leave a truncated video behind
+				return
+			}
+			progress.finish(nil)
+			downloaded = append(downloaded, DownloadedItem{Item: item, Path: localPath, Temporary: true})
+		}()
+	}
+
+	logger.Info("download completed", "requested", len(items), "downloaded", len(downloaded))
+	return downloaded, nil
+}
+
+// progressInterval limits how often download progress events are published
+const progressInterval = 500 * time.Millisecond
+
+// downloadProgress is an io.Writer that counts bytes and publishes throttled progress events
+type downloadProgress struct {
+	events.DownloadProgress
+	lastPublish time.Time
+}
+
+func (p *downloadProgress) Write(b []byte) (int, error) {
+	p.Bytes += int64(len(b))
+	if time.Since(p.lastPublish) >= progressInterval {
+		p.lastPublish = time.Now()
+		events.Publish(events.TypeDownloadProgress, p.DownloadProgress)
+	}
+	return len(b), nil
+}
+
+// finish publishes the final progress event of an item
+func (p *downloadProgress) finish(err error) {
+	p.Done = true
+	if err != nil {
+		p.Error = err.Error()
+	}
+	events.Publish(events.TypeDownloadProgress, p.DownloadProgress)
+}
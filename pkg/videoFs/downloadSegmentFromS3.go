@@ -4,7 +4,6 @@ import (
 	"flow-frame/pkg/sharedTypes"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,9 +20,9 @@ import (
 // It returns a slice with the absolute local paths of the downloaded files.
 // The boolean in the second return value indicates whether we've reached the end of the collection.
 func DownloadSegmentFromS3(collection sharedTypes.Collection, startIndex, count int) ([]string, bool, error) {
-	log.Printf("DownloadSegmentFromS3 called | collection=%s | startIndex=%d | count=%d", collection.Title, startIndex, count)
+	logger.Info("downloading segment", "collection", collection.Title, "start", startIndex, "count", count)
 	if count <= 0 {
-		log.Printf("DownloadSegmentFromS3 early-return: non-positive count (%d)", count)
+		logger.Debug("nothing to download", "count", count)
 		return nil, false, nil
 	}
 
@@ -98,7 +97,8 @@ func DownloadSegmentFromS3(collection sharedTypes.Collection, startIndex, count
 		getInput := &s3.GetObjectInput{Bucket: aws.String(collection.Bucket), Key: aws.String(key)}
 		result, err := s3Client.GetObject(getInput)
 		if err != nil {
-			log.Printf("failed to download %s: %v", key, err)
+			logger.Warn("download failed", "key", key, "err", err)
+			downloadErrors.Inc()
 			continue // skip this object but keep going
 		}
 		func() { // anonymous func to ensure Body.Close per iteration
@@ -107,13 +107,15 @@ func DownloadSegmentFromS3(collection sharedTypes.Collection, startIndex, count
 			localPath := filepath.Join(targetDir, filepath.Base(key))
 			outFile, err := os.Create(localPath)
 			if err != nil {
-				log.Printf("failed to create file %s: %v", localPath, err)
+				logger.Warn("cannot create file", "path", localPath, "err", err)
+				downloadErrors.Inc()
 				return
 			}
 			defer outFile.Close()
 
-			if _, err := io.Copy(outFile, result.Body); err != nil {
-				log.Printf("failed to write file %s: %v", localPath, err)
+			if _, err := io.Copy(outFile, countingReader{result.Body}); err != nil {
+				logger.Warn("cannot write file", "path", localPath, "err", err)
+				downloadErrors.Inc()
 				return
 			}
 			paths = append(paths, localPath)
@@ -125,6 +127,6 @@ func DownloadSegmentFromS3(collection sharedTypes.Collection, startIndex, count
 		return nil, false, errors.New(fmt.Sprintf("no videos downloaded for keys slice (start %d)", startIndex))
 	}
 
-	log.Printf("DownloadSegmentFromS3 completed | requested=%d | downloaded=%d | reachedEnd=%t", count, len(paths), reachedEnd)
+	logger.Info("segment downloaded", "requested", count, "downloaded", len(paths), "reached_end", reachedEnd)
 	return paths, reachedEnd, errors.New("test")
 }
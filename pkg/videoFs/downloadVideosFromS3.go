@@ -3,7 +3,6 @@ package videoFs
 import (
 	"flow-frame/pkg/sharedTypes"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,7 +20,7 @@ Download the content from the s3 bucket
 */
 func DownloadVideosFromS3(activeCollection sharedTypes.Collection) ([]string, error) {
 	// Verbose logging to trace S3 downloads
-	log.Printf("DownloadVideosFromS3 called | collection=%s", activeCollection.Title)
+	logger.Info("downloading collection", "collection", activeCollection.Title)
 	// Load credentials and region from environment variables
 	region := os.Getenv("AWS_DEFAULT_REGION")
 	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
@@ -75,7 +74,8 @@ func DownloadVideosFromS3(activeCollection sharedTypes.Collection) ([]string, er
 
 			result, err := s3Client.GetObject(getInput)
 			if err != nil {
-				log.Printf("failed to download %s: %v", *obj.Key, err)
+				logger.Warn("download failed", "key", *obj.Key, "err", err)
+				downloadErrors.Inc()
 				continue // skip this file but continue processing others
 			}
 			defer result.Body.Close()
@@ -83,14 +83,16 @@ func DownloadVideosFromS3(activeCollection sharedTypes.Collection) ([]string, er
 			localPath := filepath.Join(targetDir, filepath.Base(*obj.Key))
 			outFile, err := os.Create(localPath)
 			if err != nil {
-				log.Printf("failed to create file %s: %v", localPath, err)
+				logger.Warn("cannot create file", "path", localPath, "err", err)
+				downloadErrors.Inc()
 				continue
 			}
 
-			_, err = io.Copy(outFile, result.Body)
+			_, err = io.Copy(outFile, countingReader{result.Body})
 			outFile.Close()
 			if err != nil {
-				log.Printf("failed to write file %s: %v", localPath, err)
+				logger.Warn("cannot write file", "path", localPath, "err", err)
+				downloadErrors.Inc()
 				continue
 			}
 
@@ -103,6 +105,6 @@ func DownloadVideosFromS3(activeCollection sharedTypes.Collection) ([]string, er
 		return nil, err
 	}
 
-	log.Printf("DownloadVideosFromS3 completed | downloaded=%d file(s) from collection %s", len(filePaths), activeCollection.Title)
+	logger.Info("collection downloaded", "collection", activeCollection.Title, "files", len(filePaths))
 	return filePaths, nil
 }
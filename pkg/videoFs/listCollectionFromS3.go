@@ -0,0 +1,119 @@
+package videoFs
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	"flow-frame/pkg/sharedTypes"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ManifestName is the optional per-collection manifest stored next to the videos.
+const ManifestName = "manifest.json"
+
+// collectionManifest describes per-item metadata that cannot be derived from the listing.
+type collectionManifest struct {
+	Items []sharedTypes.CollectionItem `json:"items"`
+}
+
+// newS3Client builds an S3 client from the AWS_* environment variables.
+func newS3Client() (*s3.S3, error) {
+	region := os.Getenv("AWS_DEFAULT_REGION")
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+
+	if region == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("missing one or more required environment variables: AWS_DEFAULT_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY")
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.New(sess), nil
+}
+
+// ListCollectionItems lists the playable objects of a collection in S3 listing order.
+// When the folder contains a manifest.json, its per-item weights are applied; the
+// manifest itself is never returned as an item.
+func ListCollectionItems(collection sharedTypes.Collection) ([]sharedTypes.CollectionItem, error) {
+	s3Client, err := newS3Client()
+	if err != nil {
+		return nil, err
+	}
+
+	listInput := &s3.ListObjectsV2Input{
+		Bucket: aws.String(collection.Bucket),
+		Prefix: aws.String(collection.Folder),
+	}
+
+	var items []sharedTypes.CollectionItem
+	manifestKey := ""
+	if err := s3Client.ListObjectsV2Pages(listInput, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			if obj.Key == nil || strings.HasSuffix(*obj.Key, "/") {
+				continue // skip empty keys or "directories"
+			}
+			if path.Base(*obj.Key) == ManifestName {
+				manifestKey = *obj.Key
+				continue
+			}
+			items = append(items, sharedTypes.CollectionItem{Key: *obj.Key, Weight: 1})
+		}
+		return !lastPage
+	}); err != nil {
+		return nil, err
+	}
+
+	if manifestKey != "" {
+		if err := applyManifest(s3Client, collection.Bucket, manifestKey, items); err != nil {
+			// A broken manifest should not take the collection offline.
+			logger.Warn("ignoring collection manifest", "key", manifestKey, "err", err)
+		}
+	}
+
+	logger.Info("collection listed", "collection", collection.Title, "items", len(items), "manifest", manifestKey != "")
+	return items, nil
+}
+
+// applyManifest downloads the collection manifest and copies its weights onto matching items.
+// Manifest entries may reference items by full key or by base name.
+func applyManifest(s3Client *s3.S3, bucket, key string, items []sharedTypes.CollectionItem) error {
+	result, err := s3Client.GetObject(&s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return err
+	}
+	defer result.Body.Close()
+
+	var manifest collectionManifest
+	if err := json.NewDecoder(result.Body).Decode(&manifest); err != nil {
+		return err
+	}
+
+	weights := make(map[string]float64, len(manifest.Items))
+	for _, entry := range manifest.Items {
+		if entry.Weight > 0 {
+			weights[entry.Key] = entry.Weight
+		}
+	}
+
+	for i := range items {
+		if w, ok := weights[items[i].Key]; ok {
+			items[i].Weight = w
+		} else if w, ok := weights[path.Base(items[i].Key)]; ok {
+			items[i].Weight = w
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package videoFs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flow-frame/pkg/sharedTypes"
+)
+
+// DefaultLibraryDirs is used when LOCAL_LIBRARY_DIRS is not set
+var DefaultLibraryDirs = []string{filepath.Join("assets", "library")}
+
+const (
+	// libraryRescanInterval bounds how long a newly plugged drive can go unnoticed
+	libraryRescanInterval = 10 * time.Second
+	// libraryDebounce coalesces bursts of file events (e.g. a folder being copied)
+	libraryDebounce = 2 * time.Second
+)
+
+// LibraryDirsFromEnv returns the configured library directories from the
+// colon-separated LOCAL_LIBRARY_DIRS variable, or the defaults
+func LibraryDirsFromEnv() []string {
+	value := os.Getenv("LOCAL_LIBRARY_DIRS")
+	if value == "" {
+		return DefaultLibraryDirs
+	}
+
+	var dirs []string
+	for _, dir := range strings.Split(value, ":") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// LocalLibrary discovers video folders in configured directories and on
+// removable drives, exposing each folder as a local collection
+type LocalLibrary struct {
+	dirs []string // configured library directories
+
+	mu          sync.RWMutex
+	collections []sharedTypes.Collection
+	signature   string // identifies the last published scan to suppress no-op updates
+
+	changed chan struct{} // signalled (non-blocking) whenever collections change
+}
+
+// NewLocalLibrary creates a library over the given directories
+func NewLocalLibrary(dirs []string) *LocalLibrary {
+	return &LocalLibrary{
+		dirs:    dirs,
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Start performs an initial scan and keeps the library up to date until ctx is cancelled
+func (l *LocalLibrary) Start(ctx context.Context) {
+	for _, dir := range l.dirs {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			logger.Warn("local library: cannot create folder", "dir", dir, "err", err)
+		}
+	}
+
+	l.rescan()
+
+	events := make(chan struct{}, 1)
+	go watchDirectories(ctx, l.watchRoots(), events)
+	go l.run(ctx, events)
+}
+
+// Collections returns the currently discovered local collections
+func (l *LocalLibrary) Collections() []sharedTypes.Collection {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]sharedTypes.Collection(nil), l.collections...)
+}
+
+// Changed is signalled whenever the set of local collections changes
+func (l *LocalLibrary) Changed() <-chan struct{} {
+	return l.changed
+}
+
+// run rescans on file events (debounced) and periodically for removable drives
+func (l *LocalLibrary) run(ctx context.Context, events <-chan struct{}) {
+	ticker := time.NewTicker(libraryRescanInterval)
+	defer ticker.Stop()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+			debounce = time.After(libraryDebounce)
+		case <-debounce:
+			debounce = nil
+			l.rescan()
+		case <-ticker.C:
+			mountRemovableDrives()
+			l.rescan()
+		}
+	}
+}
+
+// RemovableDrives returns the mount points of plugged-in USB drives
+func RemovableDrives() []string {
+	return removableMounts()
+}
+
+// watchRoots returns every directory whose changes should trigger a rescan
+func (l *LocalLibrary) watchRoots() []string {
+	return append(append([]string(nil), l.dirs...), removableMediaRoots()...)
+}
+
+// rescan walks all library roots and publishes the result when it changed
+func (l *LocalLibrary) rescan() {
+	var collections []sharedTypes.Collection
+	for _, dir := range l.dirs {
+		collections = append(collections, scanLibraryRoot(dir, "")...)
+	}
+	for _, mount := range removableMounts() {
+		collections = append(collections, scanLibraryRoot(mount, "USB")...)
+	}
+
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Folder < collections[j].Folder })
+
+	// The signature covers overrides too so edits to collection.json are picked up
+	var sig strings.Builder
+	for _, c := range collections {
+		encoded, _ := json.Marshal(c)
+		sig.Write(encoded)
+		sig.WriteByte('\n')
+	}
+
+	l.mu.Lock()
+	if sig.String() == l.signature {
+		l.mu.Unlock()
+		return
+	}
+	l.collections = collections
+	l.signature = sig.String()
+	l.mu.Unlock()
+
+	logger.Info("local library scanned", "collections", len(collections))
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+// scanLibraryRoot turns root and each of its direct subfolders that contain
+// videos into a collection. label, when set, is prefixed to the description.
+func scanLibraryRoot(root, label string) []sharedTypes.Collection {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil
+	}
+
+	folders := []string{abs}
+	if entries, err := os.ReadDir(abs); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				folders = append(folders, filepath.Join(abs, entry.Name()))
+			}
+		}
+	}
+
+	var collections []sharedTypes.Collection
+	for _, folder := range folders {
+		items, err := ListLocalItems(folder)
+		if err != nil || len(items) == 0 {
+			continue
+		}
+
+		description := fmt.Sprintf("%d local video(s)", len(items))
+		if label != "" {
+			description = fmt.Sprintf("%s · %d video(s)", label, len(items))
+		}
+
+		collection := sharedTypes.Collection{
+			Id:          "local:" + folder,
+			Title:       filepath.Base(folder),
+			Description: description,
+			Folder:      folder,
+			Source:      sharedTypes.SourceLocal,
+		}
+		applyFolderOverrides(&collection)
+		collections = append(collections, collection)
+	}
+	return collections
+}
+
+// FolderOverridesName is an optional JSON file in a local collection folder
+// holding playback overrides, e.g. {"speed": 0.5, "interval": "3 loops", "fit": "cover"}
+const FolderOverridesName = "collection.json"
+
+// applyFolderOverrides reads the playback overrides of a local collection folder, if any
+func applyFolderOverrides(collection *sharedTypes.Collection) {
+	path := filepath.Join(collection.Folder, FolderOverridesName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var overrides struct {
+		Speed      float64               `json:"speed"`
+		Interval   *sharedTypes.Interval `json:"interval"`
+		Loop       sharedTypes.LoopMode  `json:"loop"`
+		Fit        sharedTypes.FitMode   `json:"fit"`
+		BounceLoop bool                  `json:"bounceLoop"`
+	}
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		logger.Warn("local library: ignoring invalid collection", "path", path, "err", err)
+		return
+	}
+
+	collection.Speed = overrides.Speed
+	collection.Interval = overrides.Interval
+	collection.Loop = overrides.Loop
+	collection.Fit = overrides.Fit
+	collection.BounceLoop = overrides.BounceLoop
+}
@@ -0,0 +1,41 @@
+//go:build darwin
+// +build darwin
+
+package videoFs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// watchDirectories is a no-op on macOS; the library relies on periodic rescans
+func watchDirectories(ctx context.Context, roots []string, events chan<- struct{}) {
+	<-ctx.Done()
+}
+
+// removableMediaRoots lists the directory macOS mounts external volumes under
+func removableMediaRoots() []string {
+	return []string{"/Volumes"}
+}
+
+// removableMounts returns every mounted volume except the boot disk
+func removableMounts() []string {
+	entries, err := os.ReadDir("/Volumes")
+	if err != nil {
+		return nil
+	}
+
+	var mounts []string
+	for _, entry := range entries {
+		path := filepath.Join("/Volumes", entry.Name())
+		if target, err := os.Readlink(path); err == nil && target == "/" {
+			continue // boot volume symlink
+		}
+		mounts = append(mounts, path)
+	}
+	return mounts
+}
+
+// mountRemovableDrives is a no-op on macOS, which mounts drives automatically
+func mountRemovableDrives() {}
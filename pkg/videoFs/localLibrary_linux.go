@@ -0,0 +1,186 @@
+//go:build linux
+// +build linux
+
+package videoFs
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask covers everything that can add or remove videos or folders
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+
+// watchDirectories signals events whenever anything changes in roots or
+// their direct subdirectories, using inotify. It returns when ctx is done.
+func watchDirectories(ctx context.Context, roots []string, events chan<- struct{}) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		logger.Warn("local library: inotify unavailable, relying on periodic rescans", "err", err)
+		return
+	}
+	// A non-blocking fd wrapped by os.NewFile uses the runtime poller, so
+	// closing the file unblocks the pending Read below.
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	watched := make(map[int32]string)
+	addWatch := func(dir string) {
+		wd, err := syscall.InotifyAddWatch(fd, dir, inotifyMask)
+		if err != nil {
+			return // directory may not exist (yet)
+		}
+		watched[int32(wd)] = dir
+	}
+	for _, root := range roots {
+		addWatch(root)
+		if entries, err := os.ReadDir(root); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					addWatch(filepath.Join(root, entry.Name()))
+				}
+			}
+		}
+	}
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("local library: inotify read failed", "err", err)
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			name := strings.TrimRight(string(nameBytes), "\x00")
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			// Follow newly created folders so files copied into them are seen
+			if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				if parent, ok := watched[event.Wd]; ok && name != "" {
+					addWatch(filepath.Join(parent, name))
+				}
+			}
+		}
+
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// removableMediaRoots lists the directories desktop automounters mount drives under
+func removableMediaRoots() []string {
+	return []string{"/media", "/run/media"}
+}
+
+// removableMounts returns the mount points of removable drives
+func removableMounts() []string {
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var mounts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		// /proc/mounts escapes spaces in paths as \040
+		mountPoint := strings.ReplaceAll(fields[1], `\040`, " ")
+
+		underMediaRoot := false
+		for _, root := range removableMediaRoots() {
+			if strings.HasPrefix(mountPoint, root+"/") {
+				underMediaRoot = true
+				break
+			}
+		}
+		if underMediaRoot || isUSBBlockDevice(filepath.Base(fields[0])) {
+			mounts = append(mounts, mountPoint)
+		}
+	}
+	return mounts
+}
+
+// isUSBBlockDevice reports whether a block device (disk or partition) sits on a USB bus
+func isUSBBlockDevice(name string) bool {
+	sysPath, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", name))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(sysPath, "/usb")
+}
+
+// mountRemovableDrives mounts any unmounted USB storage via udisks so that
+// frames without a desktop automounter still pick up plugged-in drives
+func mountRemovableDrives() {
+	disks, err := os.ReadDir("/sys/block")
+	if err != nil {
+		return
+	}
+
+	mounted := mountedDevices()
+	for _, disk := range disks {
+		if !isUSBBlockDevice(disk.Name()) {
+			continue
+		}
+
+		// Prefer partitions; fall back to the whole disk for unpartitioned media
+		var candidates []string
+		entries, _ := os.ReadDir(filepath.Join("/sys/block", disk.Name()))
+		for _, entry := range entries {
+			if _, err := os.Stat(filepath.Join("/sys/block", disk.Name(), entry.Name(), "partition")); err == nil {
+				candidates = append(candidates, entry.Name())
+			}
+		}
+		if len(candidates) == 0 {
+			candidates = []string{disk.Name()}
+		}
+
+		for _, dev := range candidates {
+			if mounted["/dev/"+dev] {
+				continue
+			}
+			cmd := exec.Command("udisksctl", "mount", "--no-user-interaction", "-b", "/dev/"+dev)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				logger.Warn("local library: mount failed", "device", "/dev/"+dev, "err", err, "output", strings.TrimSpace(string(output)))
+			} else {
+				logger.Info("local library: "+strings.TrimSpace(string(output)))
+			}
+		}
+	}
+}
+
+// mountedDevices returns the set of device nodes listed in /proc/mounts
+func mountedDevices() map[string]bool {
+	devices := make(map[string]bool)
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return devices
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			devices[fields[0]] = true
+		}
+	}
+	return devices
+}
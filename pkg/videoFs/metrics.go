@@ -0,0 +1,25 @@
+package videoFs
+
+import (
+	"io"
+
+	"flow-frame/pkg/metrics"
+)
+
+var (
+	downloadBytes  = metrics.NewCounter("flowframe_download_bytes_total", "Bytes downloaded from S3")
+	downloadErrors = metrics.NewCounter("flowframe_download_errors_total", "Videos that failed to download from S3")
+	cacheHits      = metrics.NewCounter("flowframe_cache_hits_total", "Videos of S3 collections played from the cache")
+	cacheMisses    = metrics.NewCounter("flowframe_cache_misses_total", "Videos of S3 collections that were not cached and had to be downloaded")
+)
+
+// countingReader counts the bytes read from a download into downloadBytes
+type countingReader struct {
+	r io.Reader
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	downloadBytes.Add(float64(n))
+	return n, err
+}
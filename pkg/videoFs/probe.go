@@ -0,0 +1,261 @@
+package videoFs
+
+/*
+#cgo pkg-config: libavformat libavcodec libavutil
+
+#include <stdlib.h>
+#include <string.h>
+#include <libavformat/avformat.h>
+#include <libavcodec/avcodec.h>
+#include <libavutil/display.h>
+#include <libavutil/pixdesc.h>
+
+typedef struct {
+    char   container[64];
+    char   videoCodec[64];
+    char   audioCodec[64];
+    int    hasVideo;
+    int    decoderAvailable;
+    int    width;
+    int    height;
+    double durationSec;
+    double frameRate;
+    double rotation;       // degrees, counter-clockwise as reported by the display matrix
+    int    bitDepth;
+    int    colorTransfer;  // enum AVColorTransferCharacteristic
+    int    colorPrimaries; // enum AVColorPrimaries
+} ProbeResult;
+
+static void copy_name(char *dst, size_t size, const char *src) {
+    if (!src) {
+        dst[0] = '\0';
+        return;
+    }
+    strncpy(dst, src, size - 1);
+    dst[size - 1] = '\0';
+}
+
+// Read the display matrix rotation of a stream, if any
+static double stream_rotation(AVStream *st) {
+    const int32_t *matrix = NULL;
+#if LIBAVCODEC_VERSION_INT >= AV_VERSION_INT(60, 29, 100)
+    const AVPacketSideData *sd = av_packet_side_data_get(st->codecpar->coded_side_data,
+                                                         st->codecpar->nb_coded_side_data,
+                                                         AV_PKT_DATA_DISPLAYMATRIX);
+    if (sd) {
+        matrix = (const int32_t *)sd->data;
+    }
+#else
+    matrix = (const int32_t *)av_stream_get_side_data(st, AV_PKT_DATA_DISPLAYMATRIX, NULL);
+#endif
+    if (!matrix) {
+        return 0;
+    }
+    double r = av_display_rotation_get(matrix);
+    return r != r ? 0 : r; // NaN guard
+}
+
+// Probe a media file without decoding it. Returns 0 on success, negative if
+// the file could not be opened or parsed as media at all.
+int probe_media(const char *filename, ProbeResult *r) {
+    AVFormatContext *fmt = NULL;
+    memset(r, 0, sizeof(*r));
+
+    if (avformat_open_input(&fmt, filename, NULL, NULL) != 0) {
+        return -1;
+    }
+    if (avformat_find_stream_info(fmt, NULL) < 0) {
+        avformat_close_input(&fmt);
+        return -2;
+    }
+
+    copy_name(r->container, sizeof(r->container), fmt->iformat ? fmt->iformat->name : NULL);
+    if (fmt->duration != AV_NOPTS_VALUE && fmt->duration > 0) {
+        r->durationSec = (double)fmt->duration / AV_TIME_BASE;
+    }
+
+    int videoIdx = av_find_best_stream(fmt, AVMEDIA_TYPE_VIDEO, -1, -1, NULL, 0);
+    if (videoIdx >= 0) {
+        AVStream *st = fmt->streams[videoIdx];
+        AVCodecParameters *par = st->codecpar;
+
+        r->hasVideo = 1;
+        r->decoderAvailable = avcodec_find_decoder(par->codec_id) != NULL;
+        copy_name(r->videoCodec, sizeof(r->videoCodec), avcodec_get_name(par->codec_id));
+        r->width = par->width;
+        r->height = par->height;
+        r->colorTransfer = par->color_trc;
+        r->colorPrimaries = par->color_primaries;
+        r->rotation = stream_rotation(st);
+
+        const AVPixFmtDescriptor *desc = av_pix_fmt_desc_get((enum AVPixelFormat)par->format);
+        if (desc) {
+            r->bitDepth = desc->comp[0].depth;
+        }
+
+        AVRational fr = av_guess_frame_rate(fmt, st, NULL);
+        if (fr.den != 0) {
+            r->frameRate = av_q2d(fr);
+        }
+    }
+
+    int audioIdx = av_find_best_stream(fmt, AVMEDIA_TYPE_AUDIO, -1, -1, NULL, 0);
+    if (audioIdx >= 0) {
+        copy_name(r->audioCodec, sizeof(r->audioCodec), avcodec_get_name(fmt->streams[audioIdx]->codecpar->codec_id));
+    }
+
+    avformat_close_input(&fmt);
+    return 0;
+}
+
+static int trc_smpte2084(void)  { return AVCOL_TRC_SMPTE2084; }
+static int trc_arib_b67(void)   { return AVCOL_TRC_ARIB_STD_B67; }
+static int pri_bt2020(void)     { return AVCOL_PRI_BT2020; }
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// MediaInfo describes a media file as reported by libavformat
+type MediaInfo struct {
+	Path       string
+	Container  string        // demuxer name, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	VideoCodec string        // e.g. "h264", "hevc"
+	AudioCodec string        // empty when there is no audio stream
+	Width      int           // coded width in pixels
+	Height     int           // coded height in pixels
+	Duration   time.Duration // zero when unknown
+	FrameRate  float64       // frames per second, zero when unknown
+	Rotation   int           // clockwise display rotation in degrees (0, 90, 180 or 270)
+	BitDepth   int           // luma bit depth
+	HDR10      bool          // SMPTE ST 2084 (PQ) transfer
+	HLG        bool          // ARIB STD-B67 (HLG) transfer
+	WideGamut  bool          // BT.2020 colour primaries
+	Playable   bool          // true when the frame can decode this file
+	Reason     string        // why the file is not playable
+}
+
+// IsHDR reports whether the file uses an HDR transfer function
+func (m MediaInfo) IsHDR() bool {
+	return m.HDR10 || m.HLG
+}
+
+// String returns a one-line summary for logs
+func (m MediaInfo) String() string {
+	if !m.Playable {
+		return fmt.Sprintf("%s: unplayable (%s)", m.Path, m.Reason)
+	}
+	return fmt.Sprintf("%s: %s %s %dx%d @ %.2ffps %s rot=%d hdr=%v",
+		m.Path, m.Container, m.VideoCodec, m.Width, m.Height, m.FrameRate, m.Duration.Round(time.Second), m.Rotation, m.IsHDR())
+}
+
+// Probe opens path with libavformat and describes its streams without decoding.
+// An error is returned only when the file cannot be read as media at all; files
+// that open but cannot be played are reported with Playable=false and a Reason.
+func Probe(path string) (MediaInfo, error) {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	var r C.ProbeResult
+	if ret := C.probe_media(cPath, &r); ret != 0 {
+		return MediaInfo{Path: path, Reason: "not a media file"}, fmt.Errorf("probe %s failed (code=%d)", path, int(ret))
+	}
+
+	info := MediaInfo{
+		Path:       path,
+		Container:  C.GoString(&r.container[0]),
+		VideoCodec: C.GoString(&r.videoCodec[0]),
+		AudioCodec: C.GoString(&r.audioCodec[0]),
+		Width:      int(r.width),
+		Height:     int(r.height),
+		Duration:   time.Duration(float64(r.durationSec) * float64(time.Second)),
+		FrameRate:  float64(r.frameRate),
+		Rotation:   normaliseRotation(float64(r.rotation)),
+		BitDepth:   int(r.bitDepth),
+		HDR10:      r.colorTransfer == C.int(C.trc_smpte2084()),
+		HLG:        r.colorTransfer == C.int(C.trc_arib_b67()),
+		WideGamut:  r.colorPrimaries == C.int(C.pri_bt2020()),
+	}
+
+	switch {
+	case r.hasVideo == 0:
+		info.Reason = "no video stream"
+	case r.decoderAvailable == 0:
+		info.Reason = fmt.Sprintf("no decoder for %s", info.VideoCodec)
+	case info.Width <= 0 || info.Height <= 0:
+		info.Reason = "unknown frame size"
+	case isImageContainer(info.Container):
+		info.Reason = "still image"
+	default:
+		info.Playable = true
+	}
+
+	return info, nil
+}
+
+// isImageContainer reports whether libavformat opened the file with an image demuxer
+func isImageContainer(container string) bool {
+	return container == "image2" || strings.HasSuffix(container, "_pipe")
+}
+
+// normaliseRotation converts the display matrix angle (counter-clockwise,
+// -180..180) into a clockwise rotation snapped to a multiple of 90 degrees
+func normaliseRotation(ccw float64) int {
+	cw := int(math.Round(-ccw/90)) * 90
+	cw %= 360
+	if cw < 0 {
+		cw += 360
+	}
+	return cw
+}
+
+// cachedProbe is a probe result remembered for an unchanged file
+type cachedProbe struct {
+	size    int64
+	modTime time.Time
+	info    MediaInfo
+	err     error
+}
+
+var (
+	probeCacheMu sync.Mutex
+	probeCache   = make(map[string]cachedProbe)
+)
+
+// ProbeCached is Probe with results remembered until the file's size or
+// modification time changes, so repeated library scans stay cheap
+func ProbeCached(path string) (MediaInfo, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return MediaInfo{Path: path, Reason: "file not found"}, err
+	}
+
+	probeCacheMu.Lock()
+	cached, ok := probeCache[path]
+	probeCacheMu.Unlock()
+	if ok && cached.size == st.Size() && cached.modTime.Equal(st.ModTime()) {
+		return cached.info, cached.err
+	}
+
+	info, err := Probe(path)
+
+	probeCacheMu.Lock()
+	probeCache[path] = cachedProbe{size: st.Size(), modTime: st.ModTime(), info: info, err: err}
+	probeCacheMu.Unlock()
+
+	return info, err
+}
+
+// IsPlayable probes path (cached) and reports whether it can be played
+func IsPlayable(path string) bool {
+	info, err := ProbeCached(path)
+	return err == nil && info.Playable
+}
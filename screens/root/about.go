@@ -0,0 +1,47 @@
+package root
+
+import (
+	"fmt"
+	"strings"
+
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/sysinfo"
+	"flow-frame/pkg/video"
+	"flow-frame/widgets/settings"
+)
+
+// aboutInfo collects the version and device details shown in the About menu
+func aboutInfo() settings.AboutInfo {
+	build := buildinfo.Get()
+	details := []string{}
+	if build.Commit != "" {
+		commit := build.Commit
+		if build.Modified {
+			commit += "+dirty"
+		}
+		details = append(details, commit)
+	}
+	if build.Date != "" {
+		details = append(details, build.Date)
+	}
+	details = append(details, build.GoVersion)
+
+	uptime := sysinfo.FormatUptime(sysinfo.Uptime())
+	if system := sysinfo.SystemUptime(); system > 0 {
+		uptime += fmt.Sprintf(" (system %s)", sysinfo.FormatUptime(system))
+	}
+
+	info := settings.AboutInfo{
+		Version: build.Version,
+		Build:   strings.Join(details, ", "),
+		Device:  sysinfo.Model(),
+		Uptime:  uptime,
+	}
+	for _, c := range video.DecoderCapabilities() {
+		info.HardwareDecoders = append(info.HardwareDecoders, c.Hardware...)
+		if c.Software {
+			info.SoftwareDecoders = append(info.SoftwareDecoders, c.Codec)
+		}
+	}
+	return info
+}
@@ -0,0 +1,265 @@
+package root
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"flow-frame/pkg/api"
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/mqtt"
+	"flow-frame/pkg/performance"
+	"flow-frame/pkg/sysinfo"
+	"flow-frame/pkg/video"
+	"flow-frame/widgets/settings"
+)
+
+const (
+	// statusPublishInterval is how often the status published to the API and MQTT is refreshed
+	statusPublishInterval = 500 * time.Millisecond
+	// maxCommandsPerFrame bounds the work done for remote commands in a single frame
+	maxCommandsPerFrame = 8
+)
+
+// startAPI starts the control API when it is configured
+func (rg *RootScreen) startAPI() {
+	addr, token, ok := api.ConfigFromEnv()
+	if !ok {
+		return
+	}
+
+	rg.api = api.NewServer(addr, token)
+	rg.api.Handle("/api/v1/diagnostics", rg.handleDiagnostics)
+	rg.publishStatus()
+	if err := rg.api.Start(); err != nil {
+		log.Printf("Warning: Failed to start control API: %v", err)
+		rg.api = nil
+	}
+}
+
+// stopAPI shuts the control API down. Requests waiting for the main thread
+// would hold up the shutdown until the command timeout, so the server is
+// stopped in the background while queued commands are turned away here.
+func (rg *RootScreen) stopAPI() {
+	server := rg.api
+	rg.api = nil
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- server.Stop() }()
+	for {
+		select {
+		case err := <-stopped:
+			if err != nil {
+				log.Printf("Error stopping control API: %v", err)
+			}
+			return
+		case cmd := <-server.Commands():
+			cmd.Reply(api.ErrBusy)
+		}
+	}
+}
+
+// startMQTT connects to the MQTT broker when one is configured
+func (rg *RootScreen) startMQTT() {
+	cfg, ok := mqtt.ConfigFromEnv()
+	if !ok {
+		return
+	}
+
+	rg.mqtt = mqtt.NewClient(cfg)
+	rg.publishStatus()
+	rg.mqtt.Start()
+}
+
+// processRemoteCommands drains commands queued by the API and MQTT on the main thread and refreshes the status
+func (rg *RootScreen) processRemoteCommands() {
+	if rg.api == nil && rg.mqtt == nil {
+		return
+	}
+
+	// A nil channel is never ready, so a disabled source is simply skipped
+	var apiCommands, mqttCommands <-chan api.Command
+	if rg.api != nil {
+		apiCommands = rg.api.Commands()
+	}
+	if rg.mqtt != nil {
+		mqttCommands = rg.mqtt.Commands()
+	}
+
+	for i := 0; i < maxCommandsPerFrame; i++ {
+		var cmd api.Command
+		select {
+		case cmd = <-apiCommands:
+		case cmd = <-mqttCommands:
+		default:
+			i = maxCommandsPerFrame
+			continue
+		}
+
+		err := rg.executeCommand(cmd)
+		if err != nil {
+			log.Printf("remote: %s failed: %v", cmd.Kind, err)
+		}
+		// Reflect the change before the waiting request reads the status
+		rg.publishStatus()
+		cmd.Reply(err)
+	}
+
+	if time.Since(rg.lastStatusPublish) >= statusPublishInterval {
+		rg.publishStatus()
+	}
+}
+
+// executeCommand applies a single API or MQTT command
+func (rg *RootScreen) executeCommand(cmd api.Command) error {
+	switch cmd.Kind {
+	case api.CommandSkip:
+		rg.video.Skip()
+	case api.CommandPause:
+		rg.video.SetPaused(true)
+	case api.CommandResume:
+		rg.video.SetPaused(false)
+	case api.CommandPower:
+		rg.video.SetDisplayOn(cmd.On)
+	case api.CommandSelectCollection:
+		return rg.video.SelectCollection(cmd.CollectionID)
+	case api.CommandUpdateSettings:
+		return rg.applySettingsPatch(cmd.Settings)
+	default:
+		return fmt.Errorf("unknown command %q", cmd.Kind)
+	}
+	return nil
+}
+
+// applySettingsPatch validates a settings change with the registry, applies and saves it
+func (rg *RootScreen) applySettingsPatch(patch api.SettingsPatch) error {
+	updated := rg.settings
+	var keys []string
+	if patch.Speed != nil {
+		updated.PlaybackSpeed = *patch.Speed
+		keys = append(keys, "playbackSpeed")
+	}
+	if patch.Interval != nil {
+		updated.PlaybackInterval = *patch.Interval
+		keys = append(keys, "playbackInterval")
+	}
+	if patch.Brightness != nil {
+		updated.Brightness = *patch.Brightness
+		keys = append(keys, "brightness")
+	}
+
+	for _, key := range keys {
+		setting, _ := settings.Lookup(key)
+		if setting.Validate(&updated) {
+			return fmt.Errorf("invalid %s", key)
+		}
+	}
+
+	rg.settings = updated
+	for _, key := range keys {
+		rg.applySetting(key)
+	}
+	if rg.popupVisible && rg.settingsWidget.CurrentMenu() == settings.MainMenu {
+		rg.openMenu(settings.MainMenu)
+	}
+
+	if err := settings.Save(rg.settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
+
+// publishStatus refreshes the status snapshot served by the API and published over MQTT
+func (rg *RootScreen) publishStatus() {
+	rg.lastStatusPublish = time.Now()
+	status := rg.status()
+
+	if rg.api != nil {
+		rg.api.PublishStatus(status)
+	}
+	if rg.mqtt != nil {
+		rg.mqtt.PublishStatus(status)
+	}
+}
+
+// status describes the current state of the frame
+func (rg *RootScreen) status() api.Status {
+	active := rg.video.ActiveCollection()
+	position, loops := rg.video.PlaybackPosition()
+	codec := rg.video.GetCodecInfo()
+	mem := performance.GetSystemMemory()
+	thermal := rg.video.Thermal()
+
+	collections := rg.video.Collections()
+	summaries := make([]api.CollectionStatus, len(collections))
+	for i, c := range collections {
+		summaries[i] = collectionStatus(c.Id, c.Title, c.IsLocal())
+	}
+
+	return api.Status{
+		Collection: collectionStatus(active.Id, active.Title, active.IsLocal()),
+		Video: api.VideoStatus{
+			Path:          videoName(rg.video.CurrentVideoPath()),
+			Position:      position.Seconds(),
+			Loops:         loops,
+			Codec:         codec.Name,
+			HardwareAccel: codec.IsHardwareAccel,
+			Width:         codec.Width,
+			Height:        codec.Height,
+			FPS:           codec.FPS,
+		},
+		DisplayOn:  rg.video.DisplayOn(),
+		Paused:     rg.video.Paused(),
+		Speed:      rg.video.EffectiveSpeed(),
+		Interval:   rg.video.EffectiveInterval().String(),
+		Brightness: rg.video.Brightness(),
+		Memory: api.MemoryStatus{
+			Pressure:    strings.ToLower(performance.GetMemoryPressure().String()),
+			AvailableMB: mem.AvailableMB,
+			TotalMB:     mem.TotalMB,
+		},
+		Thermal: api.ThermalStatus{
+			State:     strings.ToLower(rg.video.ThermalLevel().String()),
+			TempC:     thermal.MaxTempC(),
+			Throttled: thermal.Throttled,
+		},
+		Collections: summaries,
+		Build:       buildinfo.Get(),
+		Device:      deviceStatus(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// deviceStatus describes the hardware for the API
+func deviceStatus() api.DeviceStatus {
+	capabilities := video.DecoderCapabilities()
+	decoders := make([]api.DecoderStatus, len(capabilities))
+	for i, c := range capabilities {
+		decoders[i] = api.DecoderStatus{Codec: c.Codec, Hardware: c.Hardware, Software: c.Software}
+	}
+	return api.DeviceStatus{
+		Model:        sysinfo.Model(),
+		Uptime:       sysinfo.Uptime().Seconds(),
+		SystemUptime: sysinfo.SystemUptime().Seconds(),
+		Decoders:     decoders,
+	}
+}
+
+// collectionStatus describes a collection for the API
+func collectionStatus(id, title string, local bool) api.CollectionStatus {
+	source := "s3"
+	if local {
+		source = "local"
+	}
+	return api.CollectionStatus{ID: id, Title: title, Source: source}
+}
+
+// videoName returns the file name reported for the current video, empty when nothing plays
+func videoName(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Base(path)
+}
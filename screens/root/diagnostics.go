@@ -0,0 +1,106 @@
+package root
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/diagnostics"
+	"flow-frame/pkg/videoFs"
+	"flow-frame/widgets/settings"
+)
+
+const (
+	// diagnosticsTimeout bounds generating a bundle from the System menu
+	diagnosticsTimeout = time.Minute
+	// diagnosticsKept is how many bundles are kept in the data directory
+	diagnosticsKept = 3
+)
+
+// diagnosticsResult is the outcome of generating a bundle in the background
+type diagnosticsResult struct {
+	path string
+	usb  bool
+	err  error
+}
+
+// generateDiagnostics writes a diagnostics bundle to a plugged-in USB drive,
+// or to the data directory when there is none. The state owned by the main
+// thread is captured here; the rest is collected in the background.
+func (rg *RootScreen) generateDiagnostics() {
+	if rg.diagnostics != nil {
+		return
+	}
+	extra := []diagnostics.File{
+		diagnostics.JSONFile("status.json", rg.status()),
+		diagnostics.JSONFile("settings.json", rg.settings),
+	}
+	done := make(chan diagnosticsResult, 1)
+	rg.diagnostics = done
+	rg.settingsWidget.SetStatusMessage("Generating diagnostics...")
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+		defer cancel()
+
+		// Try each drive in turn; a read-only drive should not lose the bundle
+		for _, drive := range videoFs.RemovableDrives() {
+			path, err := diagnostics.WriteFile(ctx, drive, extra...)
+			if err == nil {
+				done <- diagnosticsResult{path: path, usb: true}
+				return
+			}
+			log.Printf("Warning: cannot write diagnostics to %s: %v", drive, err)
+		}
+		dir := appdata.Path("diagnostics")
+		path, err := diagnostics.WriteFile(ctx, dir, extra...)
+		if err == nil {
+			diagnostics.Prune(dir, diagnosticsKept)
+		}
+		done <- diagnosticsResult{path: path, err: err}
+	}()
+}
+
+// pollDiagnostics shows where the bundle went once it has been written
+func (rg *RootScreen) pollDiagnostics() {
+	select {
+	case result := <-rg.diagnostics:
+		rg.diagnostics = nil
+		switch {
+		case result.err != nil:
+			log.Printf("Error: generating diagnostics failed: %v", result.err)
+			rg.settingsWidget.SetStatusMessage("Error: Failed to generate diagnostics")
+		case result.usb:
+			log.Printf("Diagnostics written to %s", result.path)
+			rg.settingsWidget.SetStatusMessage("✓ Diagnostics saved to USB drive: " + filepath.Base(result.path))
+		case rg.api != nil:
+			log.Printf("Diagnostics written to %s", result.path)
+			rg.settingsWidget.SetStatusMessage("✓ No USB drive found, download diagnostics from /api/v1/diagnostics")
+		default:
+			log.Printf("Diagnostics written to %s", result.path)
+			rg.settingsWidget.SetStatusMessage("✓ No USB drive found, diagnostics saved to " + result.path)
+		}
+	default:
+	}
+}
+
+// handleDiagnostics serves a freshly generated bundle over the control API
+func (rg *RootScreen) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Runs on the API goroutine: use the published status and the saved settings
+	extra := []diagnostics.File{
+		diagnostics.JSONFile("status.json", rg.api.Status()),
+		diagnostics.JSONFile("settings.json", settings.DefaultStore().Read()),
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+diagnostics.FileName()+`"`)
+	if err := diagnostics.Write(r.Context(), w, extra...); err != nil {
+		log.Printf("Warning: sending diagnostics failed: %v", err)
+	}
+}
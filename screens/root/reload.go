@@ -0,0 +1,46 @@
+package root
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"flow-frame/pkg/videoFs"
+)
+
+// wifiMonitorStopTimeout bounds how long Close waits for an nmcli call in progress
+const wifiMonitorStopTimeout = 3 * time.Second
+
+// startLibrary starts watching the configured library directories
+func (rg *RootScreen) startLibrary() {
+	ctx, cancel := context.WithCancel(context.Background())
+	rg.library = videoFs.NewLocalLibrary(videoFs.LibraryDirsFromEnv())
+	rg.library.Start(ctx)
+	rg.libraryCancel = cancel
+	rg.video.SetLocalCollections(rg.library.Collections())
+}
+
+// Reload picks up configuration changes after the resolved config has been
+// exported to the environment again: the library is rescanned with the
+// configured directories, the active collection re-listed, and the API and
+// MQTT client restarted with their current settings.
+func (rg *RootScreen) Reload() {
+	log.Println("Reloading configuration and catalog")
+
+	if rg.libraryCancel != nil {
+		rg.libraryCancel()
+	}
+	rg.startLibrary()
+	rg.video.ReloadCatalog()
+
+	if rg.api != nil {
+		rg.stopAPI()
+	}
+	rg.startAPI()
+
+	if rg.mqtt != nil {
+		rg.mqtt.Stop()
+		rg.mqtt = nil
+	}
+	rg.startMQTT()
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"flow-frame/pkg/captiveportal"
+	"flow-frame/pkg/events"
 	"flow-frame/pkg/input"
 	"flow-frame/pkg/sharedTypes"
+	"flow-frame/pkg/videoFs"
 	"flow-frame/screens/videoPlayer"
 	"flow-frame/ui"
 	"flow-frame/widgets/collections"
+	"flow-frame/widgets/nocontent"
 	captiveportalwidget "flow-frame/widgets/captiveportal"
 	"flow-frame/widgets/settings"
 	"flow-frame/widgets/tabs"
 	"log"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -24,12 +28,20 @@ func NewRootScreen(window *sdl.Window, renderer *sdl.Renderer) *RootScreen {
 	// Load settings first
 	userSettings := settings.Load()
 
+	// Generate the shuffle seed once so shuffled order is stable across restarts
+	if userSettings.ShuffleSeed == 0 {
+		userSettings.ShuffleSeed = time.Now().UnixNano()
+		if err := settings.Save(userSettings); err != nil {
+			log.Printf("Warning: Failed to save shuffle seed: %v", err)
+		}
+	}
+
 	// Create context for WiFi monitor
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create the root screen
 	rg := &RootScreen{
-		video:             videoPlayer.NewVideoPlayerScreen(),
+		video:             videoPlayer.NewVideoPlayerScreen(playlistOptionsFromSettings(userSettings)),
 		window:            window,
 		renderer:          renderer,
 		popupVisible:      false,
@@ -39,6 +51,8 @@ func NewRootScreen(window *sdl.Window, renderer *sdl.Renderer) *RootScreen {
 		showCaptivePortal: false,
 		wifiMonitorCtx:    ctx,
 		wifiMonitorCancel: cancel,
+		wifiMonitorDone:   make(chan struct{}),
+		wifiUp:            make(chan struct{}, 1),
 	}
 
 	// Initialize UI components
@@ -51,6 +65,7 @@ func NewRootScreen(window *sdl.Window, renderer *sdl.Renderer) *RootScreen {
 	rg.tabsWidget = tabs.NewWidget()
 	rg.collectionsWidget = collections.NewWidget()
 	rg.settingsWidget = settings.NewWidget()
+	rg.noContentWidget = nocontent.NewWidget()
 
 	// Configure video player with SDL2 renderer
 	if err := rg.video.SetRenderer(renderer); err != nil {
@@ -60,10 +75,20 @@ func NewRootScreen(window *sdl.Window, renderer *sdl.Renderer) *RootScreen {
 	// Apply loaded settings to video player
 	rg.video.SetPlaybackSpeed(userSettings.PlaybackSpeed)
 	rg.video.SetPlaybackInterval(userSettings.PlaybackInterval)
+	rg.video.SetBrightness(userSettings.Brightness)
 
 	// Start WiFi monitoring in background
 	go rg.monitorWiFiConnection()
 
+	// Discover local collections from watched folders and removable drives
+	rg.startLibrary()
+
+	// Start the LAN control API when FLOW_FRAME_API_ADDR and FLOW_FRAME_API_TOKEN are set
+	rg.startAPI()
+
+	// Connect to the MQTT broker when MQTT_BROKER is set
+	rg.startMQTT()
+
 	return rg
 }
 
@@ -75,6 +100,27 @@ func (rg *RootScreen) Update() error {
 	_, _, buttons := sdl.GetMouseState()
 	rg.mouseButtons = buttons
 
+	// Pick up local library changes on the main thread
+	select {
+	case <-rg.library.Changed():
+		rg.video.SetLocalCollections(rg.library.Collections())
+	default:
+	}
+
+	// Re-list the active collection once Wi-Fi is up, e.g. when it failed at boot
+	select {
+	case <-rg.wifiUp:
+		rg.video.ReloadCatalog()
+	default:
+	}
+
+	// Apply remote control commands on the main thread
+	rg.processRemoteCommands()
+
+	// Show the progress of a requested update check
+	rg.pollUpdateCheck()
+	rg.pollDiagnostics()
+
 	// Handle input based on current state
 	if rg.popupVisible {
 		rg.handleUIInput()
@@ -117,9 +163,27 @@ func (rg *RootScreen) handleUIInput() {
 
 		// ESC to cancel password input
 		if rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_ESCAPE) {
-			items := settings.BuildWiFiMenuItems()
-			rg.settingsWidget.SetItems(items)
-			rg.settingsWidget.SetCurrentMenu(settings.WiFiMenu)
+			rg.openMenu(settings.WiFiMenu)
+		}
+		return
+	}
+
+	// Numeric stepper for custom setting values
+	if rg.tabsWidget.ActiveTab() == tabs.SettingsTab && rg.settingsWidget.CurrentMenu() == settings.StepperMenu {
+		if rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_LEFT) || rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_DOWN) {
+			rg.settingsWidget.StepStepper(-1)
+		}
+		if rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_RIGHT) || rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_UP) {
+			rg.settingsWidget.StepStepper(1)
+		}
+		if rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_RETURN) ||
+			rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_SPACE) ||
+			rg.mouseTracker.IsPressed(rg.mouseButtons, sdl.ButtonRMask()) ||
+			rg.mouseTracker.IsPressed(rg.mouseButtons, sdl.ButtonLMask()) {
+			rg.confirmStepper()
+		}
+		if rg.keyTracker.IsPressed(rg.keyState, sdl.SCANCODE_ESCAPE) {
+			rg.openMenu(rg.stepperSetting.Menu())
 		}
 		return
 	}
@@ -188,6 +252,13 @@ func (rg *RootScreen) Draw() error {
 		return err
 	}
 
+	// Explain how to add content while there is nothing to play
+	if !rg.video.HasContent() && rg.video.DisplayOn() && rg.fonts != nil {
+		if err := rg.noContentWidget.Render(rg.renderer, w, h, rg.fonts, videoFs.LibraryDirsFromEnv(), rg.video.ContentError()); err != nil {
+			log.Printf("Error rendering no content screen: %v", err)
+		}
+	}
+
 	// Draw captive portal overlay if active (takes precedence over normal UI)
 	if rg.showCaptivePortal && rg.captivePortalWidget != nil {
 		if err := rg.captivePortalWidget.Render(rg.renderer, w, h, rg.fonts); err != nil {
@@ -201,7 +272,9 @@ func (rg *RootScreen) Draw() error {
 	}
 
 	// Present the complete frame
+	presentStart := time.Now()
 	rg.renderer.Present()
+	rg.video.FramePresented(time.Since(presentStart))
 	return nil
 }
 
@@ -246,6 +319,10 @@ func (rg *RootScreen) drawUI(screenWidth, screenHeight int32) error {
 			if err := rg.settingsWidget.DrawPasswordInput(rg.renderer, uiX, contentY, uiWidth, contentHeight, rg.fonts.Large, rg.fonts.Medium, rg.fonts.Small); err != nil {
 				return err
 			}
+		} else if rg.settingsWidget.CurrentMenu() == settings.StepperMenu {
+			if err := rg.settingsWidget.DrawStepper(rg.renderer, uiX, contentY, uiWidth, contentHeight, rg.fonts.Large, rg.fonts.Medium, rg.fonts.Small); err != nil {
+				return err
+			}
 		} else {
 			if err := rg.settingsWidget.Draw(rg.renderer, uiX, contentY, uiWidth, contentHeight, rg.fonts.Large, rg.fonts.Medium, rg.fonts.Small); err != nil {
 				return err
@@ -292,9 +369,7 @@ func (rg *RootScreen) showUI() {
 	rg.collectionsWidget.SetCards(cards)
 
 	// Create settings items
-	items := settings.BuildMainMenuItems(rg.video.PlaybackSpeed(), rg.video.PlaybackInterval())
-	rg.settingsWidget.SetItems(items)
-	rg.settingsWidget.SetCurrentMenu(settings.MainMenu)
+	rg.openMenu(settings.MainMenu)
 
 	// Show UI with Collections tab active
 	rg.tabsWidget.SetActiveTab(tabs.CollectionsTab)
@@ -305,11 +380,14 @@ func (rg *RootScreen) showUI() {
 func (rg *RootScreen) mapCollectionToCard(vc sharedTypes.Collection) collections.Card {
 	var colorStart, colorEnd [3]uint8
 
-	switch vc.Title {
-	case "Impressionism":
+	switch {
+	case vc.IsLocal():
+		colorStart = [3]uint8{20, 184, 166}
+		colorEnd = [3]uint8{15, 118, 110}
+	case vc.Title == "Impressionism":
 		colorStart = [3]uint8{41, 98, 255}
 		colorEnd = [3]uint8{13, 71, 161}
-	case "Abstract":
+	case vc.Title == "Abstract":
 		colorStart = [3]uint8{156, 39, 176}
 		colorEnd = [3]uint8{74, 20, 140}
 	default:
@@ -350,130 +428,147 @@ func (rg *RootScreen) handleCollectionSelection() {
 
 // handleSettingsSelection processes settings selection
 func (rg *RootScreen) handleSettingsSelection() {
-	selectedItem := rg.settingsWidget.SelectedItem()
-
-	switch rg.settingsWidget.CurrentMenu() {
-	case settings.MainMenu:
-		rg.handleMainMenuSelection(rg.settingsWidget.Selected())
-	case settings.SpeedMenu:
-		rg.handleSpeedMenuSelection(selectedItem.Title)
-	case settings.IntervalMenu:
-		rg.handleIntervalMenuSelection(selectedItem.Title)
-	case settings.SystemMenu:
-		rg.handleSystemMenuSelection(selectedItem.Title)
-	case settings.WiFiMenu:
-		rg.handleWiFiMenuSelection(selectedItem.Title)
-	case settings.WiFiPasswordMenu:
+	menu := rg.settingsWidget.CurrentMenu()
+	if menu == settings.WiFiPasswordMenu {
 		rg.handlePasswordInput()
+		return
 	}
-}
 
-// handleMainMenuSelection handles main settings menu selections
-func (rg *RootScreen) handleMainMenuSelection(index int) {
-	switch index {
-	case 0: // Playback Speed
-		items := settings.BuildSpeedMenuItems(rg.video.PlaybackSpeed())
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.SpeedMenu)
-	case 1: // Playback Interval
-		items := settings.BuildIntervalMenuItems(rg.video.PlaybackInterval())
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.IntervalMenu)
-	case 2: // System Settings
-		items := settings.BuildSystemMenuItems()
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.SystemMenu)
+	selectedItem := rg.settingsWidget.SelectedItem()
+	switch {
+	case selectedItem.Action == settings.ActionBack:
+		rg.openMenu(parentMenu(menu))
+	case selectedItem.Action == settings.ActionInfo:
+		// Informational rows cannot be selected
+	case selectedItem.Action == settings.ActionRestart:
+		rg.checkForUpdates()
+	case selectedItem.Action == settings.ActionDiagnostics:
+		rg.generateDiagnostics()
+	case selectedItem.Action == settings.ActionStepper:
+		rg.startStepper(menu, rg.settingsWidget.Selected())
+	case selectedItem.Menu != "":
+		rg.openMenu(selectedItem.Menu)
+	case menu == settings.WiFiMenu:
+		rg.handleWiFiMenuSelection(selectedItem.Title)
+	default:
+		if setting, ok := settings.SettingForMenu(menu); ok {
+			rg.handleSettingSelection(setting, rg.settingsWidget.Selected())
+		}
 	}
 }
 
-// handleSpeedMenuSelection handles speed menu selections
-func (rg *RootScreen) handleSpeedMenuSelection(label string) {
-	if label == "Back" {
-		// Return to settings main menu
-		items := settings.BuildMainMenuItems(rg.video.PlaybackSpeed(), rg.video.PlaybackInterval())
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.MainMenu)
-		return
-	}
-
-	if speed, err := settings.ParseSpeedFromLabel(label); err == nil {
-		rg.video.SetPlaybackSpeed(speed)
-		rg.settings.PlaybackSpeed = speed
-		if err := settings.Save(rg.settings); err != nil {
-			log.Printf("Warning: Failed to save playback speed setting: %v", err)
-			rg.settingsWidget.SetStatusMessage("Error: Failed to save setting")
-		} else {
-			rg.settingsWidget.SetStatusMessage("✓ Playback speed updated")
+// openMenu shows the given settings menu
+func (rg *RootScreen) openMenu(menu settings.MenuType) {
+	var items []settings.Item
+	switch menu {
+	case settings.MainMenu:
+		items = settings.BuildMainMenuItems(rg.settings)
+	case settings.SystemMenu:
+		items = settings.BuildSystemMenuItems()
+	case settings.WiFiMenu:
+		items = settings.BuildWiFiMenuItems()
+	case settings.AboutMenu:
+		items = settings.BuildAboutMenuItems(aboutInfo())
+	default:
+		setting, ok := settings.SettingForMenu(menu)
+		if !ok {
+			log.Printf("openMenu: unknown menu %q", menu)
+			return
 		}
+		items = setting.MenuItems(rg.settings)
+	}
+	rg.settingsWidget.SetItems(items)
+	rg.settingsWidget.SetCurrentMenu(menu)
+}
 
-		// Refresh the menu to show updated checkmark
-		items := settings.BuildSpeedMenuItems(rg.video.PlaybackSpeed())
-		rg.settingsWidget.SetItems(items)
+// parentMenu returns the menu that Back returns to from menu
+func parentMenu(menu settings.MenuType) settings.MenuType {
+	switch menu {
+	case settings.WiFiMenu, settings.WiFiPasswordMenu, settings.AboutMenu:
+		return settings.SystemMenu
+	default:
+		return settings.MainMenu
 	}
 }
 
-// handleIntervalMenuSelection handles interval menu selections
-func (rg *RootScreen) handleIntervalMenuSelection(label string) {
-	if label == "Back" {
-		// Return to settings main menu
-		items := settings.BuildMainMenuItems(rg.video.PlaybackSpeed(), rg.video.PlaybackInterval())
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.MainMenu)
+// handleSettingSelection applies a choice from a registered setting's menu, then saves
+func (rg *RootScreen) handleSettingSelection(setting settings.Setting, index int) {
+	if err := setting.Select(&rg.settings, index); err != nil {
+		log.Printf("handleSettingSelection: %v", err)
 		return
 	}
+	rg.applySetting(setting.Key())
 
-	cleanLabel := settings.CleanIntervalLabel(label)
-	rg.video.SetPlaybackInterval(cleanLabel)
-	rg.settings.PlaybackInterval = cleanLabel
 	if err := settings.Save(rg.settings); err != nil {
-		log.Printf("Warning: Failed to save playback interval setting: %v", err)
+		log.Printf("Warning: Failed to save %s setting: %v", setting.Key(), err)
 		rg.settingsWidget.SetStatusMessage("Error: Failed to save setting")
 	} else {
-		rg.settingsWidget.SetStatusMessage("✓ Playback interval updated")
+		rg.settingsWidget.SetStatusMessage("✓ " + setting.Label() + " updated")
 	}
 
-	// Refresh the menu to show updated checkmark
-	items := settings.BuildIntervalMenuItems(rg.video.PlaybackInterval())
-	rg.settingsWidget.SetItems(items)
+	// Refresh the menu to show updated checkmark, keeping the selection in place
+	rg.settingsWidget.SetItems(setting.MenuItems(rg.settings))
 }
 
-// handleSystemMenuSelection handles system menu selections
-func (rg *RootScreen) handleSystemMenuSelection(label string) {
-	if label == "Back" {
-		// Return to settings main menu
-		items := settings.BuildMainMenuItems(rg.video.PlaybackSpeed(), rg.video.PlaybackInterval())
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.MainMenu)
+// startStepper opens the numeric stepper behind the item at index of a setting's menu
+func (rg *RootScreen) startStepper(menu settings.MenuType, index int) {
+	setting, ok := settings.SettingForMenu(menu)
+	if !ok {
+		return
+	}
+	stepper, value, ok := setting.Stepper(rg.settings, index)
+	if !ok {
 		return
 	}
+	rg.stepperSetting = setting
+	rg.stepperIndex = index
+	rg.settingsWidget.StartStepper(stepper, value)
+	rg.settingsWidget.SetCurrentMenu(settings.StepperMenu)
+}
 
-	if label == "WiFi Networks" {
-		items := settings.BuildWiFiMenuItems()
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.WiFiMenu)
+// confirmStepper applies the stepper value, saves it and returns to the setting's menu
+func (rg *RootScreen) confirmStepper() {
+	setting := rg.stepperSetting
+	if err := setting.SetCustom(&rg.settings, rg.stepperIndex, rg.settingsWidget.StepperValue()); err != nil {
+		log.Printf("confirmStepper: %v", err)
 		return
 	}
+	rg.applySetting(setting.Key())
 
-	if label == "Restart and check for updates" {
-		rg.restartSystem()
+	rg.openMenu(setting.Menu())
+	if err := settings.Save(rg.settings); err != nil {
+		log.Printf("Warning: Failed to save %s setting: %v", setting.Key(), err)
+		rg.settingsWidget.SetStatusMessage("Error: Failed to save setting")
+	} else {
+		rg.settingsWidget.SetStatusMessage("✓ " + setting.Label() + " updated")
 	}
 }
 
-// handleWiFiMenuSelection handles WiFi menu selections
-func (rg *RootScreen) handleWiFiMenuSelection(label string) {
-	if label == "Back" {
-		// Return to system menu
-		items := settings.BuildSystemMenuItems()
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.SystemMenu)
-		return
+// applySetting pushes a changed setting to the components that use it
+func (rg *RootScreen) applySetting(key string) {
+	switch key {
+	case "playbackSpeed":
+		rg.video.SetPlaybackSpeed(rg.settings.PlaybackSpeed)
+	case "playbackInterval":
+		rg.video.SetPlaybackInterval(rg.settings.PlaybackInterval)
+	case "playbackOrder", "avoidRepeat":
+		rg.video.SetPlaylistOptions(playlistOptionsFromSettings(rg.settings))
+	case "brightness":
+		rg.video.SetBrightness(rg.settings.Brightness)
 	}
+}
 
-	// Skip error and "no networks" messages
-	if label == "Error scanning networks" || label == "No networks found" {
-		return
+// playlistOptionsFromSettings maps persisted settings to video player playlist options
+func playlistOptionsFromSettings(s settings.Settings) videoPlayer.PlaylistOptions {
+	return videoPlayer.PlaylistOptions{
+		Order:       videoPlayer.PlaybackOrder(s.PlaybackOrder),
+		Seed:        s.ShuffleSeed,
+		AvoidRepeat: s.AvoidRepeat,
 	}
+}
 
+// handleWiFiMenuSelection handles WiFi menu selections
+func (rg *RootScreen) handleWiFiMenuSelection(label string) {
 	if label != "" {
 		log.Printf("Attempting to connect to WiFi network: %s", label)
 
@@ -501,7 +596,7 @@ func (rg *RootScreen) handleWiFiMenuSelection(label string) {
 		}()
 
 		// Return to system menu after initiating connection
-		rg.handleWiFiMenuSelection("Back")
+		rg.openMenu(settings.SystemMenu)
 	}
 }
 
@@ -539,9 +634,7 @@ func (rg *RootScreen) handlePasswordInput() {
 		}()
 	case "<CANCEL>":
 		// Return to WiFi menu
-		items := settings.BuildWiFiMenuItems()
-		rg.settingsWidget.SetItems(items)
-		rg.settingsWidget.SetCurrentMenu(settings.WiFiMenu)
+		rg.openMenu(settings.WiFiMenu)
 	default:
 		// Add the selected character to the password
 		rg.settingsWidget.AddCharToPassword()
@@ -569,11 +662,55 @@ func (rg *RootScreen) hideUI() {
 	rg.settingsWidget.SetCurrentMenu(settings.MainMenu)
 }
 
+// StatusText summarizes what the frame is doing, for the service manager
+func (rg *RootScreen) StatusText() string {
+	if rg.showCaptivePortal && rg.captivePortal != nil {
+		return fmt.Sprintf("Waiting for Wi-Fi setup on %s", rg.captivePortal.GetSSID())
+	}
+	if !rg.video.DisplayOn() {
+		return "Display off"
+	}
+	if !rg.video.HasContent() {
+		return "No content: " + rg.video.ContentError()
+	}
+
+	state := "Playing"
+	if rg.video.Paused() {
+		state = "Paused"
+	}
+	status := fmt.Sprintf("%s %s: %s", state, rg.video.ActiveCollection().Title, filepath.Base(rg.video.CurrentVideoPath()))
+	if rg.video.IsPrefetchPending() {
+		status += " (downloading)"
+	}
+	return status
+}
+
 // Close cleans up resources
 func (rg *RootScreen) Close() {
-	// Stop WiFi monitor
+	// Stop WiFi monitor and wait for it so it cannot bring the portal back up
 	if rg.wifiMonitorCancel != nil {
 		rg.wifiMonitorCancel()
+		select {
+		case <-rg.wifiMonitorDone:
+		case <-time.After(wifiMonitorStopTimeout):
+			log.Println("Warning: WiFi monitor did not stop in time")
+		}
+	}
+	if rg.libraryCancel != nil {
+		rg.libraryCancel()
+	}
+
+	// Abort downloads and release the decoder
+	rg.video.Close()
+
+	// Stop control API
+	if rg.api != nil {
+		rg.stopAPI()
+	}
+
+	// Disconnect from the MQTT broker; the frame is marked offline
+	if rg.mqtt != nil {
+		rg.mqtt.Stop()
 	}
 
 	// Stop and cleanup captive portal
@@ -592,10 +729,16 @@ func (rg *RootScreen) Close() {
 	if rg.fonts != nil {
 		rg.fonts.Close()
 	}
+
+	// Flush settings; they are saved on every change, this covers anything not yet on disk
+	if err := settings.Save(rg.settings); err != nil {
+		log.Printf("Error saving settings: %v", err)
+	}
 }
 
 // monitorWiFiConnection monitors WiFi connection status and manages captive portal
 func (rg *RootScreen) monitorWiFiConnection() {
+	defer close(rg.wifiMonitorDone)
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -620,6 +763,7 @@ func (rg *RootScreen) checkAndManageCaptivePortal() {
 		log.Printf("Error checking WiFi connection: %v", err)
 		return
 	}
+	rg.publishWiFiState(connected, ssid)
 
 	if connected {
 		// WiFi is connected
@@ -648,6 +792,24 @@ func (rg *RootScreen) checkAndManageCaptivePortal() {
 	}
 }
 
+// publishWiFiState emits an event when the Wi-Fi connection comes up, drops or changes network
+func (rg *RootScreen) publishWiFiState(connected bool, ssid string) {
+	if rg.wifiKnown && connected == rg.wifiConnected && ssid == rg.wifiSSID {
+		return
+	}
+	rg.wifiKnown, rg.wifiConnected, rg.wifiSSID = true, connected, ssid
+
+	typ := events.TypeWiFiDisconnected
+	if connected {
+		typ = events.TypeWiFiConnected
+		select {
+		case rg.wifiUp <- struct{}{}:
+		default:
+		}
+	}
+	events.Publish(typ, events.WiFiState{Connected: connected, SSID: ssid})
+}
+
 // startCaptivePortal initializes and starts the captive portal
 func (rg *RootScreen) startCaptivePortal() error {
 	// Create portal if it doesn't exist
@@ -2,14 +2,19 @@ package root
 
 import (
 	"context"
+	"flow-frame/pkg/api"
+	"flow-frame/pkg/mqtt"
 	"flow-frame/pkg/captiveportal"
 	"flow-frame/pkg/input"
+	"flow-frame/pkg/videoFs"
 	"flow-frame/widgets/settings"
 	"flow-frame/screens/videoPlayer"
 	"flow-frame/ui"
 	"flow-frame/widgets/collections"
+	"flow-frame/widgets/nocontent"
 	captiveportalwidget "flow-frame/widgets/captiveportal"
 	"flow-frame/widgets/tabs"
+	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
@@ -27,6 +32,7 @@ type RootScreen struct {
 	tabsWidget        *tabs.Widget
 	collectionsWidget *collections.Widget
 	settingsWidget    *settings.Widget
+	noContentWidget   *nocontent.Widget // shown while the video player has nothing to play
 	popupVisible      bool
 
 	// Captive portal for WiFi setup
@@ -35,10 +41,34 @@ type RootScreen struct {
 	showCaptivePortal   bool
 	wifiMonitorCtx      context.Context
 	wifiMonitorCancel   context.CancelFunc
+	wifiMonitorDone     chan struct{} // closed when monitorWiFiConnection returns
+	// Last Wi-Fi state seen by the monitor; only touched by monitorWiFiConnection
+	wifiKnown     bool
+	wifiConnected bool
+	wifiSSID      string
+	wifiUp        chan struct{} // signalled by the monitor when Wi-Fi connects
+
+	// Local media library (watched folders and USB drives)
+	library       *videoFs.LocalLibrary
+	libraryCancel context.CancelFunc // stops the library watcher, e.g. to restart it with new directories
+
+	// LAN control API and MQTT client; nil when disabled
+	api               *api.Server
+	mqtt              *mqtt.Client
+	lastStatusPublish time.Time
 
 	// Persisted user preferences
 	settings settings.Settings
 
+	// Setting and menu index being edited with the numeric stepper
+	stepperSetting settings.Setting
+	stepperIndex   int
+
+	// Update check requested from the System menu; nil when none is running
+	updateCheck *updateCheck
+	// Diagnostics bundle being written for the System menu; nil when none is
+	diagnostics chan diagnosticsResult
+
 	// Input tracking
 	keyState []uint8
 	// Mouse button state bitmask from sdl.GetMouseState
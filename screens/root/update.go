@@ -0,0 +1,117 @@
+package root
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"time"
+
+	"flow-frame/pkg/updater"
+)
+
+const (
+	// updateUnit runs the updater as root
+	updateUnit = "flow-frame-update-manager.service"
+	// updatePollInterval is how often the progress of a check is read
+	updatePollInterval = 500 * time.Millisecond
+	// updateStartTimeout is how long the update service may take to pick up a request
+	updateStartTimeout = 30 * time.Second
+)
+
+// updateCheck tracks a check for updates requested from the System menu
+type updateCheck struct {
+	slots     *updater.Slots
+	started   time.Time
+	lastPoll  time.Time
+	restartAt time.Time // when the result has been shown long enough
+}
+
+// checkForUpdates asks the update service for an immediate check. The
+// service skips the maintenance window for requested checks; its progress is
+// shown in the settings menu and the frame restarts once it is done.
+func (rg *RootScreen) checkForUpdates() {
+	if rg.updateCheck != nil {
+		return
+	}
+	slots := updater.NewSlots()
+	if err := slots.RequestCheck(); err != nil {
+		log.Printf("checkForUpdates: failed to request check: %v", err)
+		rg.settingsWidget.SetStatusMessage("Error: Failed to start update check")
+		return
+	}
+	log.Println("Requesting an update check...")
+	rg.updateCheck = &updateCheck{slots: slots, started: time.Now()}
+	rg.settingsWidget.SetStatusMessage("Checking for updates...")
+
+	go func() {
+		if err := exec.Command("sudo", "systemctl", "start", "--no-block", updateUnit).Run(); err != nil {
+			log.Printf("Error starting %s: %v", updateUnit, err)
+		}
+	}()
+}
+
+// pollUpdateCheck shows the progress of a requested check and restarts the
+// frame once it has finished
+func (rg *RootScreen) pollUpdateCheck() {
+	check := rg.updateCheck
+	if check == nil || time.Since(check.lastPoll) < updatePollInterval {
+		return
+	}
+	check.lastPoll = time.Now()
+
+	if !check.restartAt.IsZero() {
+		if time.Now().After(check.restartAt) {
+			rg.updateCheck = nil
+			rg.restartSystem()
+		}
+		return
+	}
+
+	p, err := check.slots.Progress()
+	if err != nil {
+		log.Printf("pollUpdateCheck: %v", err)
+		return
+	}
+	if p.Time.Before(check.started) || p.Phase == updater.PhaseRequested {
+		if time.Since(check.started) > updateStartTimeout {
+			rg.updateCheck = nil
+			rg.settingsWidget.SetStatusMessage("Update check failed: the update service did not start")
+		}
+		return
+	}
+
+	rg.settingsWidget.SetStatusMessage(updateStatus(p))
+	switch p.Phase {
+	case updater.PhaseFailed:
+		rg.updateCheck = nil
+	case updater.PhaseInstalled, updater.PhaseUpToDate, updater.PhaseDeferred:
+		check.restartAt = time.Now().Add(3 * time.Second)
+	}
+}
+
+// updateStatus describes the progress of an update check for the settings menu
+func updateStatus(p updater.Progress) string {
+	switch p.Phase {
+	case updater.PhaseChecking:
+		return "Checking for updates..."
+	case updater.PhaseDownloading:
+		if p.Total > 0 {
+			return fmt.Sprintf("Downloading %s... %d%%", p.Version, p.Done*100/p.Total)
+		}
+		return fmt.Sprintf("Downloading %s... %.1f MB", p.Version, float64(p.Done)/(1<<20))
+	case updater.PhaseInstalling:
+		return fmt.Sprintf("Installing %s...", p.Version)
+	case updater.PhaseInstalled:
+		return fmt.Sprintf("Installed %s, restarting...", p.Version)
+	case updater.PhaseUpToDate:
+		if p.Version != "" {
+			return fmt.Sprintf("Up to date (%s), restarting...", p.Version)
+		}
+		return "Up to date, restarting..."
+	case updater.PhaseDeferred:
+		return p.Message + ", restarting..."
+	case updater.PhaseFailed:
+		return "Update check failed: " + p.Message
+	}
+	return p.Phase
+}
@@ -0,0 +1,303 @@
+package safeMode
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	"flow-frame/pkg/captiveportal"
+	"flow-frame/pkg/input"
+	"flow-frame/pkg/safemode"
+	"flow-frame/pkg/updater"
+	"flow-frame/ui"
+	captiveportalwidget "flow-frame/widgets/captiveportal"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// wifiCheckInterval is how often the Wi-Fi connection is checked
+const wifiCheckInterval = 5 * time.Second
+
+// Screen is shown instead of the player after repeated failed starts. It plays
+// no content, lists what went wrong, offers Wi-Fi setup through the captive
+// portal and lets the user retry a normal start or check for updates.
+type Screen struct {
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	fonts    *ui.Fonts
+
+	diagnostics []string // environment check results, one per line
+
+	// Captive portal; the monitor goroutine drives the portal and hands the
+	// QR code to the main thread, which owns the widget texture
+	portal       *captiveportal.Portal
+	portalWidget *captiveportalwidget.Widget
+	portalCh     chan *portalInfo // nil when the portal was stopped
+	monitorStop  context.CancelFunc
+	monitorDone  chan struct{}
+
+	// Feedback for the last action, set from the goroutine running it
+	actionMu     sync.Mutex
+	actionStatus string
+	actionBusy   bool
+
+	keyTracker input.KeyPressTracker
+}
+
+// portalInfo is what the widget needs to show a running portal
+type portalInfo struct {
+	qrPNG []byte
+	ssid  string
+	url   string
+}
+
+// NewScreen creates the safe mode screen. diagnostics are shown as-is.
+func NewScreen(window *sdl.Window, renderer *sdl.Renderer, diagnostics []string) *Screen {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Screen{
+		window:      window,
+		renderer:    renderer,
+		diagnostics: diagnostics,
+		portalCh:    make(chan *portalInfo, 1),
+		monitorStop: cancel,
+		monitorDone: make(chan struct{}),
+		keyTracker:  input.NewKeyPressTracker(),
+	}
+
+	fonts, err := ui.LoadFonts()
+	if err != nil {
+		log.Printf("Warning: Failed to initialize fonts: %v", err)
+	}
+	s.fonts = fonts
+
+	go s.monitorWiFi(ctx)
+	return s
+}
+
+// Update handles input and picks up captive portal changes
+func (s *Screen) Update() error {
+	select {
+	case info := <-s.portalCh:
+		s.showPortal(info)
+	default:
+	}
+
+	keyState := sdl.GetKeyboardState()
+	switch {
+	case s.keyTracker.IsPressed(keyState, sdl.SCANCODE_RETURN), s.keyTracker.IsPressed(keyState, sdl.SCANCODE_SPACE):
+		s.runAction("Restarting normally...", func() error {
+			safemode.ExitSafeMode()
+			return exec.Command("sudo", "systemctl", "restart", "flow-frame").Run()
+		})
+	case s.keyTracker.IsPressed(keyState, sdl.SCANCODE_U):
+		s.runAction("Checking for updates...", func() error {
+			// The update manager is a oneshot unit; starting it again runs a check.
+			// Requested checks install right away instead of waiting for the
+			// maintenance window.
+			if err := updater.NewSlots().RequestCheck(); err != nil {
+				log.Printf("safe mode: failed to request update check: %v", err)
+			}
+			if err := exec.Command("sudo", "systemctl", "start", "flow-frame-update-manager.service").Run(); err != nil {
+				return fmt.Errorf("update check failed: %w", err)
+			}
+			return exec.Command("sudo", "systemctl", "restart", "flow-frame").Run()
+		})
+	}
+	return nil
+}
+
+// runAction runs a slow command in the background unless one is already running
+func (s *Screen) runAction(status string, action func() error) {
+	s.actionMu.Lock()
+	defer s.actionMu.Unlock()
+	if s.actionBusy {
+		return
+	}
+	s.actionBusy = true
+	s.actionStatus = status
+	log.Printf("safe mode: %s", status)
+
+	go func() {
+		err := action()
+		s.actionMu.Lock()
+		defer s.actionMu.Unlock()
+		s.actionBusy = false
+		if err != nil {
+			log.Printf("safe mode: action failed: %v", err)
+			s.actionStatus = "Failed: " + err.Error()
+		}
+	}()
+}
+
+// showPortal creates or removes the QR code widget on the main thread
+func (s *Screen) showPortal(info *portalInfo) {
+	if s.portalWidget != nil {
+		s.portalWidget.Destroy()
+		s.portalWidget = nil
+	}
+	if info == nil {
+		return
+	}
+	widget, err := captiveportalwidget.NewWidget(s.renderer, info.qrPNG, info.ssid, info.url)
+	if err != nil {
+		log.Printf("Error creating captive portal widget: %v", err)
+		return
+	}
+	s.portalWidget = widget
+}
+
+// Draw renders the diagnostic report and, without Wi-Fi, the captive portal
+func (s *Screen) Draw() error {
+	w, h := s.window.GetSize()
+	s.renderer.SetDrawColor(15, 23, 42, 255)
+	s.renderer.Clear()
+
+	if s.fonts != nil {
+		s.drawReport()
+	}
+	if s.portalWidget != nil && s.fonts != nil {
+		if err := s.portalWidget.Render(s.renderer, w, h, s.fonts); err != nil {
+			log.Printf("Error rendering captive portal widget: %v", err)
+		}
+	}
+
+	s.renderer.Present()
+	return nil
+}
+
+// drawReport lists why safe mode was entered, the environment checks and the available actions
+func (s *Screen) drawReport() {
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	gray := sdl.Color{R: 148, G: 163, B: 184, A: 255}
+	amber := sdl.Color{R: 251, G: 191, B: 36, A: 255}
+
+	x, y := int32(60), int32(50)
+	line := func(text string, color sdl.Color, size int32) {
+		font := s.fonts.Small
+		switch size {
+		case 2:
+			font = s.fonts.Large
+		case 1:
+			font = s.fonts.Medium
+		}
+		ui.RenderText(s.renderer, text, x, y, color, font)
+		y += int32(font.Height()) + 8
+	}
+
+	line("Safe mode", amber, 2)
+	line(fmt.Sprintf("The frame failed to start %d times in a row. No videos are played and settings were reset to defaults.", safemode.FailedStarts()), white, 0)
+	for _, name := range safemode.QuarantinedFiles() {
+		line("Skipped from now on: "+name, gray, 0)
+	}
+	y += 20
+
+	line("Diagnostics", white, 1)
+	for _, d := range s.diagnostics {
+		line(d, gray, 0)
+	}
+	y += 20
+
+	line("Enter: start normally    U: check for updates and restart", white, 1)
+	s.actionMu.Lock()
+	status := s.actionStatus
+	s.actionMu.Unlock()
+	if status != "" {
+		line(status, amber, 0)
+	}
+}
+
+// StatusText summarizes the screen for the service manager
+func (s *Screen) StatusText() string {
+	if s.portalWidget != nil {
+		return "Safe mode, waiting for Wi-Fi setup"
+	}
+	return fmt.Sprintf("Safe mode after %d failed starts", safemode.FailedStarts())
+}
+
+// Reload has nothing to reload; safe mode ignores configuration changes
+func (s *Screen) Reload() {}
+
+// Close stops the Wi-Fi monitor and captive portal and releases resources
+func (s *Screen) Close() {
+	s.monitorStop()
+	select {
+	case <-s.monitorDone:
+	case <-time.After(3 * time.Second):
+		log.Println("Warning: WiFi monitor did not stop in time")
+	}
+	if s.portal != nil {
+		if err := s.portal.Stop(); err != nil {
+			log.Printf("Error stopping captive portal: %v", err)
+		}
+	}
+	if s.portalWidget != nil {
+		s.portalWidget.Destroy()
+	}
+	if s.fonts != nil {
+		s.fonts.Close()
+	}
+}
+
+// monitorWiFi starts the captive portal while there is no Wi-Fi and stops it once connected
+func (s *Screen) monitorWiFi(ctx context.Context) {
+	defer close(s.monitorDone)
+	ticker := time.NewTicker(wifiCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkWiFi(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkWiFi starts or stops the portal to match the connection state
+func (s *Screen) checkWiFi(ctx context.Context) {
+	connected, _, err := captiveportal.CheckWiFiConnection()
+	if err != nil {
+		log.Printf("Error checking WiFi connection: %v", err)
+		return
+	}
+	running := s.portal != nil && s.portal.IsRunning()
+
+	switch {
+	case connected && running:
+		if err := s.portal.Stop(); err != nil {
+			log.Printf("Error stopping captive portal: %v", err)
+		}
+		s.sendPortal(ctx, nil)
+	case !connected && !running:
+		if s.portal == nil {
+			portal, err := captiveportal.NewPortal()
+			if err != nil {
+				log.Printf("Error creating captive portal: %v", err)
+				return
+			}
+			s.portal = portal
+		}
+		if err := s.portal.Start(); err != nil {
+			log.Printf("Error starting captive portal: %v", err)
+			return
+		}
+		qrPNG, err := s.portal.GetQRCodePNG()
+		if err != nil {
+			log.Printf("Error getting QR code: %v", err)
+			return
+		}
+		s.sendPortal(ctx, &portalInfo{qrPNG: qrPNG, ssid: s.portal.GetSSID(), url: s.portal.GetURL()})
+	}
+}
+
+// sendPortal hands a portal change to the main thread unless the screen is closing
+func (s *Screen) sendPortal(ctx context.Context, info *portalInfo) {
+	select {
+	case s.portalCh <- info:
+	case <-ctx.Done():
+	}
+}
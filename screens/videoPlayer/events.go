@@ -0,0 +1,75 @@
+package videoPlayer
+
+import (
+	"strings"
+	"time"
+
+	"flow-frame/pkg/events"
+	"flow-frame/pkg/performance"
+)
+
+// pressureCheckInterval is how often memory pressure is sampled for transition events
+const pressureCheckInterval = 2 * time.Second
+
+// publishVideoStarted announces the video that just started playing
+func (g *VideoPlayerScreen) publishVideoStarted() {
+	info := g.player.GetCodecInfo()
+	events.Publish(events.TypeVideoStarted, events.VideoStarted{
+		Path:          g.downloadedVideos[g.currentVideo].path,
+		CollectionID:  g.collections[g.activeCollection].Id,
+		Codec:         info.Name,
+		HardwareAccel: info.IsHardwareAccel,
+		Width:         info.Width,
+		Height:        info.Height,
+		FPS:           info.FPS,
+	})
+}
+
+// publishVideoEnded announces that the current video is about to be replaced
+func (g *VideoPlayerScreen) publishVideoEnded() {
+	if g.player == nil || len(g.downloadedVideos) == 0 {
+		return
+	}
+	events.Publish(events.TypeVideoEnded, events.VideoEnded{
+		Path:          g.downloadedVideos[g.currentVideo].path,
+		CollectionID:  g.collections[g.activeCollection].Id,
+		Loops:         g.player.Loops(),
+		PlayedSeconds: time.Since(g.playStartTime).Seconds(),
+	})
+}
+
+// publishVideoFailed announces a video that was skipped because it could not be played
+func (g *VideoPlayerScreen) publishVideoFailed(v queuedVideo, err error) {
+	events.Publish(events.TypeVideoFailed, events.VideoFailed{
+		Path:         v.path,
+		CollectionID: g.collections[g.activeCollection].Id,
+		Key:          v.key,
+		Error:        err.Error(),
+	})
+}
+
+// publishStateTransitions emits events when the frame skip mode or memory pressure level changes
+func (g *VideoPlayerScreen) publishStateTransitions() {
+	if mode := g.frameSkipper.GetMode(); mode != g.lastSkipMode {
+		events.Publish(events.TypeFrameSkipModeChanged, events.FrameSkipModeChanged{
+			From: g.lastSkipMode.String(),
+			To:   mode.String(),
+		})
+		g.lastSkipMode = mode
+	}
+
+	if time.Since(g.lastPressureCheck) < pressureCheckInterval {
+		return
+	}
+	g.lastPressureCheck = time.Now()
+
+	pressure := performance.GetMemoryPressure()
+	if pressure != g.memoryPressure {
+		events.Publish(events.TypeMemoryPressureChanged, events.MemoryPressureChanged{
+			From:        strings.ToLower(g.memoryPressure.String()),
+			To:          strings.ToLower(pressure.String()),
+			AvailableMB: performance.GetAvailableMemoryMB(),
+		})
+		g.memoryPressure = pressure
+	}
+}
@@ -0,0 +1,338 @@
+package videoPlayer
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"os"
+
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/sharedTypes"
+)
+
+// PlaybackOrder selects how the playlist walks through a collection
+type PlaybackOrder string
+
+const (
+	OrderSequential PlaybackOrder = "sequential" // S3 listing order, wrapping at the end
+	OrderShuffle    PlaybackOrder = "shuffle"    // seeded permutation per pass through the collection
+	OrderWeighted   PlaybackOrder = "weighted"   // independent picks using per-item manifest weights
+)
+
+// playlistStateName is the file in the data directory storing the last played
+// cursor of every collection
+const playlistStateName = "playlist.json"
+
+// PlaylistOptions configures the playlist engine
+type PlaylistOptions struct {
+	Order       PlaybackOrder
+	Seed        int64 // seed for shuffle/weighted picks; the same seed replays the same order
+	AvoidRepeat int   // never repeat any of the last N played items (when the collection allows it)
+}
+
+// playlistCursor is the complete, serialisable position of a playlist
+type playlistCursor struct {
+	Order    PlaybackOrder `json:"order"`
+	Seed     int64         `json:"seed"`
+	Cycle    int           `json:"cycle"`              // completed passes through the collection
+	Position int           `json:"position"`           // next slot within the current pass
+	Draws    int64         `json:"draws"`              // weighted picks made so far
+	Shuffled []int         `json:"shuffled,omitempty"` // item indices of the current shuffle pass
+	Recent   []string      `json:"recent,omitempty"`   // most recently drawn keys, oldest first
+}
+
+// clone returns a deep copy so queued cursors are not mutated by later draws
+func (c playlistCursor) clone() playlistCursor {
+	c.Shuffled = append([]int(nil), c.Shuffled...)
+	c.Recent = append([]string(nil), c.Recent...)
+	return c
+}
+
+// Playlist decides which collection item plays next
+type Playlist struct {
+	collectionID string
+	items        []sharedTypes.CollectionItem
+	opts         PlaylistOptions
+	cursor       playlistCursor
+	unplayable   map[string]bool // keys that failed probing and are never drawn again
+}
+
+// NewPlaylist creates a playlist for a collection, resuming from the persisted
+// cursor when it was produced with the same order and seed
+func NewPlaylist(collectionID string, items []sharedTypes.CollectionItem, opts PlaylistOptions) *Playlist {
+	if opts.Order == "" {
+		opts.Order = OrderSequential
+	}
+	if opts.AvoidRepeat < 0 {
+		opts.AvoidRepeat = 0
+	}
+
+	p := &Playlist{
+		collectionID: collectionID,
+		items:        items,
+		opts:         opts,
+		cursor:       playlistCursor{Order: opts.Order, Seed: opts.Seed},
+	}
+
+	if saved, ok := loadPlaylistCursors()[collectionID]; ok && saved.Order == opts.Order && saved.Seed == opts.Seed {
+		p.cursor = saved
+		if len(items) > 0 && p.cursor.Position >= len(items) {
+			p.cursor.Position = 0
+			p.cursor.Cycle++
+		}
+		log.Printf("Playlist[%s]: resuming %s order at cycle %d, position %d", collectionID, opts.Order, p.cursor.Cycle, p.cursor.Position)
+	}
+
+	return p
+}
+
+// Len returns the number of items in the playlist
+func (p *Playlist) Len() int {
+	return len(p.items)
+}
+
+// Options returns the options the playlist was created with
+func (p *Playlist) Options() PlaylistOptions {
+	return p.opts
+}
+
+// Items returns the collection items backing the playlist
+func (p *Playlist) Items() []sharedTypes.CollectionItem {
+	return p.items
+}
+
+// Relist replaces the items with a fresh listing of the collection, keeping
+// the position, no-repeat history and unplayable flags. A shuffled pass holds
+// item indices, so it is restarted when the listing changed.
+func (p *Playlist) Relist(items []sharedTypes.CollectionItem) {
+	changed := len(items) != len(p.items)
+	for i := 0; !changed && i < len(items); i++ {
+		changed = items[i].Key != p.items[i].Key
+	}
+	p.items = items
+	if !changed {
+		return
+	}
+
+	p.cursor.Shuffled = nil
+	if p.cursor.Position >= len(items) {
+		p.cursor.Position = 0
+		p.cursor.Cycle++
+	}
+}
+
+// MarkUnplayable flags items that failed probing so later draws skip them
+func (p *Playlist) MarkUnplayable(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if p.unplayable == nil {
+		p.unplayable = make(map[string]bool)
+	}
+	for _, key := range keys {
+		log.Printf("Playlist[%s]: flagging unplayable item %s", p.collectionID, key)
+		p.unplayable[key] = true
+	}
+}
+
+// Unplayable returns the keys flagged by MarkUnplayable
+func (p *Playlist) Unplayable() []string {
+	keys := make([]string, 0, len(p.unplayable))
+	for key := range p.unplayable {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Next draws the next playable item. The returned cursor is the playlist position right
+// after this item and should be committed once the item actually starts playing.
+func (p *Playlist) Next() (sharedTypes.CollectionItem, playlistCursor, bool) {
+	n := len(p.items)
+	for attempts := 0; attempts < n; attempts++ {
+		item := p.draw(n)
+		if !p.unplayable[item.Key] {
+			// Skipped picks stay out of the no-repeat window so it only holds played items
+			p.remember(item.Key)
+			return item, p.cursor.clone(), true
+		}
+	}
+	return sharedTypes.CollectionItem{}, playlistCursor{}, false
+}
+
+// draw advances the cursor by one pick according to the playback order
+func (p *Playlist) draw(n int) sharedTypes.CollectionItem {
+	var idx int
+	switch p.opts.Order {
+	case OrderShuffle:
+		if len(p.cursor.Shuffled) != n {
+			p.cursor.Shuffled = p.shuffledPass()
+		}
+		idx = p.cursor.Shuffled[p.cursor.Position]
+		p.advance(n)
+	case OrderWeighted:
+		idx = p.weightedPick()
+		p.cursor.Draws++
+		p.advance(n)
+	default:
+		idx = p.cursor.Position % n
+		p.advance(n)
+	}
+
+	return p.items[idx]
+}
+
+// Commit persists a cursor previously returned by Next
+func (p *Playlist) Commit(cursor playlistCursor) {
+	if err := savePlaylistCursor(p.collectionID, cursor); err != nil {
+		log.Printf("Playlist[%s]: failed to persist position: %v", p.collectionID, err)
+	}
+}
+
+// advance moves to the next slot, starting a new pass at the end of the collection
+func (p *Playlist) advance(n int) {
+	p.cursor.Position++
+	if p.cursor.Position >= n {
+		p.cursor.Position = 0
+		p.cursor.Cycle++
+		p.cursor.Shuffled = nil
+	}
+}
+
+// remember records a drawn key for the no-repeat window
+func (p *Playlist) remember(key string) {
+	window := p.window()
+	if window == 0 {
+		p.cursor.Recent = nil
+		return
+	}
+	p.cursor.Recent = append(p.cursor.Recent, key)
+	if len(p.cursor.Recent) > window {
+		p.cursor.Recent = p.cursor.Recent[len(p.cursor.Recent)-window:]
+	}
+}
+
+// window returns the effective no-repeat window, which must leave at least one candidate
+func (p *Playlist) window() int {
+	window := p.opts.AvoidRepeat
+	if window > len(p.items)-1 {
+		window = len(p.items) - 1
+	}
+	if window < 0 {
+		window = 0
+	}
+	return window
+}
+
+// blocked reports whether key is among the last `lookback` drawn items
+func (p *Playlist) blocked(key string, lookback int) bool {
+	recent := p.cursor.Recent
+	if lookback < len(recent) {
+		recent = recent[len(recent)-lookback:]
+	}
+	for _, k := range recent {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether a weighted pick must skip key
+func (p *Playlist) excluded(key string, window int) bool {
+	return p.unplayable[key] || p.blocked(key, window)
+}
+
+// shuffledPass builds the permutation for the current pass. The permutation is
+// derived from the seed and pass number only, then adjusted so the next slots
+// do not repeat the last played items. After a Relist the pass is rebuilt part
+// way through, so the adjustment starts at the current position, and skipped
+// unplayable items do not count towards the window.
+func (p *Playlist) shuffledPass() []int {
+	n := len(p.items)
+	rng := rand.New(rand.NewSource(p.opts.Seed + int64(p.cursor.Cycle)*7919))
+	perm := rng.Perm(n)
+
+	lookback := p.window()
+	for i := p.cursor.Position; i < n && lookback > 0; i++ {
+		if p.blocked(p.items[perm[i]].Key, lookback) {
+			for j := n - 1; j > i; j-- {
+				if !p.blocked(p.items[perm[j]].Key, lookback) {
+					perm[i], perm[j] = perm[j], perm[i]
+					break
+				}
+			}
+		}
+		if !p.unplayable[p.items[perm[i]].Key] {
+			lookback--
+		}
+	}
+	return perm
+}
+
+// weightedPick draws an item index proportionally to its weight, excluding the
+// no-repeat window and unplayable items. Each draw uses its own source so the sequence is
+// reproducible from the seed and draw count alone.
+func (p *Playlist) weightedPick() int {
+	rng := rand.New(rand.NewSource(p.opts.Seed + (p.cursor.Draws+1)*104729))
+	window := p.window()
+
+	total := 0.0
+	for _, item := range p.items {
+		if !p.excluded(item.Key, window) {
+			total += itemWeight(item)
+		}
+	}
+
+	target := rng.Float64() * total
+	last := -1
+	for i, item := range p.items {
+		if p.excluded(item.Key, window) {
+			continue
+		}
+		last = i
+		target -= itemWeight(item)
+		if target < 0 {
+			return i
+		}
+	}
+	if last < 0 {
+		return 0
+	}
+	return last // floating point rounding on the final candidate
+}
+
+// itemWeight returns the pick weight of an item, defaulting to 1
+func itemWeight(item sharedTypes.CollectionItem) float64 {
+	if item.Weight <= 0 {
+		return 1
+	}
+	return item.Weight
+}
+
+// loadPlaylistCursors reads all persisted cursors, returning an empty map on any error
+func loadPlaylistCursors() map[string]playlistCursor {
+	cursors := map[string]playlistCursor{}
+
+	path := appdata.Path(playlistStateName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cursors
+	}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		log.Printf("Playlist: ignoring unreadable state file %s: %v", path, err)
+		return map[string]playlistCursor{}
+	}
+	return cursors
+}
+
+// savePlaylistCursor stores the cursor of a single collection
+func savePlaylistCursor(collectionID string, cursor playlistCursor) error {
+	cursors := loadPlaylistCursors()
+	cursors[collectionID] = cursor
+
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	if err != nil {
+		return err
+	}
+	return appdata.WriteFileAtomic(appdata.Path(playlistStateName), data, 0o644)
+}
@@ -0,0 +1,250 @@
+package videoPlayer
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	"flow-frame/pkg/appdata"
+	"flow-frame/pkg/sharedTypes"
+)
+
+// The data directory is resolved once per process, so every test shares one
+// temporary directory and starts from an empty playlist state file
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "playlist")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv(appdata.EnvDataDir, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// resetPlaylistState removes the persisted cursors left by an earlier test
+func resetPlaylistState(t *testing.T) {
+	t.Helper()
+	if err := os.Remove(appdata.Path(playlistStateName)); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+}
+
+func testItems(n int) []sharedTypes.CollectionItem {
+	items := make([]sharedTypes.CollectionItem, n)
+	for i := range items {
+		items[i] = sharedTypes.CollectionItem{Key: fmt.Sprintf("video-%02d.mp4", i)}
+	}
+	return items
+}
+
+// drawKeys draws n items and returns their keys and the cursor after the last one
+func drawKeys(t *testing.T, p *Playlist, n int) ([]string, playlistCursor) {
+	t.Helper()
+	keys := make([]string, 0, n)
+	var cursor playlistCursor
+	for range n {
+		item, c, ok := p.Next()
+		if !ok {
+			t.Fatalf("Next failed after %d draws", len(keys))
+		}
+		keys = append(keys, item.Key)
+		cursor = c
+	}
+	return keys, cursor
+}
+
+// assertNoRepeats checks that no key appears twice within any avoid+1 consecutive draws
+func assertNoRepeats(t *testing.T, keys []string, avoid int) {
+	t.Helper()
+	for i := range keys {
+		for j := max(0, i-avoid); j < i; j++ {
+			if keys[i] == keys[j] {
+				t.Fatalf("draw %d repeats %s from draw %d (avoid %d): %v", i, keys[i], j, avoid, keys)
+			}
+		}
+	}
+}
+
+var orders = []PlaybackOrder{OrderSequential, OrderShuffle, OrderWeighted}
+
+func TestPlaylistSameSeedSameOrder(t *testing.T) {
+	resetPlaylistState(t)
+	for _, order := range orders {
+		opts := PlaylistOptions{Order: order, Seed: 42, AvoidRepeat: 3}
+		a, _ := drawKeys(t, NewPlaylist("a", testItems(10), opts), 50)
+		b, _ := drawKeys(t, NewPlaylist("b", testItems(10), opts), 50)
+		if !slices.Equal(a, b) {
+			t.Errorf("%s: same seed gave different orders:\n%v\n%v", order, a, b)
+		}
+
+		opts.Seed = 43
+		c, _ := drawKeys(t, NewPlaylist("c", testItems(10), opts), 50)
+		if order != OrderSequential && slices.Equal(a, c) {
+			t.Errorf("%s: seeds 42 and 43 gave the same order", order)
+		}
+	}
+}
+
+func TestPlaylistSequential(t *testing.T) {
+	resetPlaylistState(t)
+	p := NewPlaylist("seq", testItems(3), PlaylistOptions{})
+	keys, _ := drawKeys(t, p, 7)
+	want := []string{"video-00.mp4", "video-01.mp4", "video-02.mp4", "video-00.mp4", "video-01.mp4", "video-02.mp4", "video-00.mp4"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+}
+
+func TestPlaylistShufflePasses(t *testing.T) {
+	resetPlaylistState(t)
+	const n = 8
+	p := NewPlaylist("shuffle", testItems(n), PlaylistOptions{Order: OrderShuffle, Seed: 7, AvoidRepeat: 3})
+	keys, _ := drawKeys(t, p, 10*n)
+
+	// Every pass plays each item exactly once
+	for pass := range 10 {
+		got := slices.Clone(keys[pass*n : (pass+1)*n])
+		slices.Sort(got)
+		if got = slices.Compact(got); len(got) != n {
+			t.Errorf("pass %d plays %d distinct items, want %d", pass, len(got), n)
+		}
+	}
+	assertNoRepeats(t, keys, 3)
+}
+
+func TestPlaylistNoRepeatAcrossPasses(t *testing.T) {
+	resetPlaylistState(t)
+	for _, order := range []PlaybackOrder{OrderShuffle, OrderWeighted} {
+		for seed := range int64(50) {
+			for _, n := range []int{2, 4, 5, 9} {
+				p := NewPlaylist("window", testItems(n), PlaylistOptions{Order: order, Seed: seed, AvoidRepeat: 3})
+				keys, _ := drawKeys(t, p, 6*n)
+				// The window shrinks to leave at least one candidate
+				assertNoRepeats(t, keys, min(3, n-1))
+			}
+		}
+	}
+}
+
+func TestPlaylistWeighted(t *testing.T) {
+	resetPlaylistState(t)
+	items := testItems(3)
+	items[0].Weight = 8 // the others default to 1
+	p := NewPlaylist("weighted", items, PlaylistOptions{Order: OrderWeighted, Seed: 1})
+	keys, _ := drawKeys(t, p, 1000)
+
+	heavy := 0
+	for _, key := range keys {
+		if key == items[0].Key {
+			heavy++
+		}
+	}
+	if heavy < 700 || heavy > 900 {
+		t.Errorf("item with weight 8 of 10 drawn %d of 1000 times", heavy)
+	}
+}
+
+func TestPlaylistSkipsUnplayable(t *testing.T) {
+	resetPlaylistState(t)
+	for _, order := range orders {
+		p := NewPlaylist("unplayable", testItems(4), PlaylistOptions{Order: order, Seed: 3, AvoidRepeat: 1})
+		p.MarkUnplayable("video-01.mp4")
+		keys, _ := drawKeys(t, p, 20)
+		if slices.Contains(keys, "video-01.mp4") {
+			t.Errorf("%s: unplayable item drawn: %v", order, keys)
+		}
+
+		p.MarkUnplayable("video-00.mp4", "video-02.mp4", "video-03.mp4")
+		if _, _, ok := p.Next(); ok {
+			t.Errorf("%s: Next succeeded with every item unplayable", order)
+		}
+	}
+}
+
+func TestPlaylistResume(t *testing.T) {
+	for _, order := range orders {
+		t.Run(string(order), func(t *testing.T) {
+			resetPlaylistState(t)
+			opts := PlaylistOptions{Order: order, Seed: 99, AvoidRepeat: 3}
+			full, _ := drawKeys(t, NewPlaylist("resume", testItems(7), opts), 30)
+
+			// Stop mid-pass, then restart from the committed cursor
+			p := NewPlaylist("resume", testItems(7), opts)
+			played, cursor := drawKeys(t, p, 11)
+			p.Commit(cursor)
+			resumed, _ := drawKeys(t, NewPlaylist("resume", testItems(7), opts), 19)
+
+			if got := append(played, resumed...); !slices.Equal(got, full) {
+				t.Errorf("resumed sequence differs:\n got %v\nwant %v", got, full)
+			}
+		})
+	}
+}
+
+func TestPlaylistResumeNeedsSameSeed(t *testing.T) {
+	resetPlaylistState(t)
+	opts := PlaylistOptions{Order: OrderShuffle, Seed: 5}
+	p := NewPlaylist("reseed", testItems(6), opts)
+	_, cursor := drawKeys(t, p, 4)
+	p.Commit(cursor)
+
+	opts.Seed = 6
+	fresh, _ := drawKeys(t, NewPlaylist("other", testItems(6), opts), 6)
+	reseeded, _ := drawKeys(t, NewPlaylist("reseed", testItems(6), opts), 6)
+	if !slices.Equal(fresh, reseeded) {
+		t.Errorf("cursor of another seed was resumed:\n got %v\nwant %v", reseeded, fresh)
+	}
+}
+
+func TestPlaylistRelist(t *testing.T) {
+	resetPlaylistState(t)
+	opts := PlaylistOptions{Order: OrderShuffle, Seed: 11, AvoidRepeat: 3}
+	full, _ := drawKeys(t, NewPlaylist("relist", testItems(6), opts), 20)
+
+	// The same listing again does not disturb the sequence
+	p := NewPlaylist("relist", testItems(6), opts)
+	first, _ := drawKeys(t, p, 4)
+	p.Relist(testItems(6))
+	rest, _ := drawKeys(t, p, 16)
+	if got := append(first, rest...); !slices.Equal(got, full) {
+		t.Errorf("sequence changed by an identical listing:\n got %v\nwant %v", got, full)
+	}
+
+	// A new item is picked up and the no-repeat window survives the new pass
+	p.MarkUnplayable("video-02.mp4")
+	p.Relist(testItems(7))
+	keys, _ := drawKeys(t, p, 14)
+	if !slices.Contains(keys, "video-06.mp4") {
+		t.Errorf("item added by Relist never drawn: %v", keys)
+	}
+	if slices.Contains(keys, "video-02.mp4") {
+		t.Errorf("unplayable flag lost by Relist: %v", keys)
+	}
+	assertNoRepeats(t, append(rest[len(rest)-3:], keys...), 3)
+
+	// A shorter listing restarts the pass instead of indexing past the end
+	p.Relist(testItems(2))
+	keys, _ = drawKeys(t, p, 4)
+	for _, key := range keys {
+		if key != "video-00.mp4" && key != "video-01.mp4" {
+			t.Errorf("item removed by Relist drawn: %v", keys)
+		}
+	}
+}
+
+func TestPlaylistEmpty(t *testing.T) {
+	resetPlaylistState(t)
+	for _, order := range orders {
+		p := NewPlaylist("empty", nil, PlaylistOptions{Order: order, Seed: 1, AvoidRepeat: 3})
+		if _, _, ok := p.Next(); ok {
+			t.Errorf("%s: Next succeeded on an empty playlist", order)
+		}
+		p.Relist(testItems(2))
+		if _, _, ok := p.Next(); !ok {
+			t.Errorf("%s: Next failed after Relist filled the playlist", order)
+		}
+	}
+}
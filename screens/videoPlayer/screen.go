@@ -1,20 +1,37 @@
 package videoPlayer
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
+	"flow-frame/pkg/diagnostics"
+	"flow-frame/pkg/events"
 	"flow-frame/pkg/video"
 	"flow-frame/pkg/performance"
+	"flow-frame/pkg/safemode"
 	"flow-frame/pkg/sharedTypes"
 	"flow-frame/pkg/videoFs"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+// contentRetryInterval is how often the no content screen looks for something to play
+const contentRetryInterval = 30 * time.Second
+
+// catalogRefreshInterval is how often the active collection is re-listed to
+// pick up videos added since; catalogRetryInterval applies while the listing
+// is empty or failed, e.g. before Wi-Fi came up
+const (
+	catalogRefreshInterval = 15 * time.Minute
+	catalogRetryInterval   = 30 * time.Second
+)
+
 // calculatePrefetchBuffer determines optimal prefetch count based on available memory
 // Conservative approach: only prefetch when we have sufficient RAM
 func calculatePrefetchBuffer() int {
@@ -67,77 +84,168 @@ func clearDownloadedVideos() {
 	}
 }
 
-// NewVideoPlayerScreen creates and initializes a new video player screen
-func NewVideoPlayerScreen() *VideoPlayerScreen {
-	// Clean up any existing downloaded videos
-	clearDownloadedVideos()
+// drawItems picks the next count items from the playlist, skipping items that
+// are already buffered so the same local file is never queued twice
+func drawItems(playlist *Playlist, count int, buffered []queuedVideo) []plannedItem {
+	if playlist == nil || count <= 0 {
+		return nil
+	}
 
-	// Define available video collections matching the UI design
-	collections := []sharedTypes.Collection{
-		{
-			Id:          "1",
-			Title:       "Impressionism",
-			Description: "Light, color, and fleeting moments.",
-			Bucket:      "flow-frame",
-			Folder:      "calm-abstract",
-			BounceLoop:  true,
-		},
-		{
-			Id:          "2",
-			Title:       "Abstract",
-			Description: "Beyond the tangible world.",
-			Bucket:      "flow-frame",
-			Folder:      "ai-gen",
-			BounceLoop:  true,
-		},
+	taken := make(map[string]bool, len(buffered))
+	for _, v := range buffered {
+		taken[filepath.Base(v.path)] = true
 	}
 
-	// Download initial videos from the first collection
-	// Use dynamic prefetch based on available memory
-	initialPrefetch := getPrefetchBuffer()
-	if initialPrefetch == 0 {
-		initialPrefetch = 1 // Always download at least 1 video to start
+	var planned []plannedItem
+	for attempts := 0; len(planned) < count && attempts < count+playlist.Len(); attempts++ {
+		item, cursor, ok := playlist.Next()
+		if !ok {
+			break
+		}
+		name := filepath.Base(item.Key)
+		if taken[name] {
+			continue
+		}
+		taken[name] = true
+		planned = append(planned, plannedItem{item: item, cursor: cursor})
 	}
-	log.Printf("NewVideoPlayerScreen: Initial prefetch count = %d", initialPrefetch)
+	return planned
+}
 
-	initialVideos, endOfCollection, err := videoFs.DownloadSegmentFromS3(collections[0], 0, initialPrefetch)
-	if err != nil || len(initialVideos) == 0 {
-		// Fall back to checking whats pre existing
-		initialVideos, err = videoFs.AvailableDownloadedVideos()
+// downloadPlanned downloads playlist picks and attaches their cursors to the resulting files.
+// Files that cannot be played are removed and their keys returned as rejected.
+func downloadPlanned(ctx context.Context, collection sharedTypes.Collection, planned []plannedItem) ([]queuedVideo, []string, error) {
+	items := make([]sharedTypes.CollectionItem, len(planned))
+	for i, p := range planned {
+		items[i] = p.item
 	}
 
-	// Open and initialize the first video
-	file, err := os.Open(initialVideos[0])
+	downloaded, err := videoFs.FetchItems(ctx, collection, items)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
-	player, err := video.NewPlayer(file)
+	// Downloads preserve order but may skip failed items
+	vids := make([]queuedVideo, 0, len(downloaded))
+	var rejected []string
+	j := 0
+	for _, d := range downloaded {
+		for j < len(planned) && planned[j].item.Key != d.Item.Key {
+			j++
+		}
+		if j == len(planned) {
+			break
+		}
+		cursor := planned[j].cursor
+		j++
+
+		if safemode.IsQuarantined(d.Path) {
+			log.Printf("downloadPlanned: skipping %s, it crashed the player before", d.Item.Key)
+			rejected = append(rejected, d.Item.Key)
+			if d.Temporary {
+				os.Remove(d.Path)
+			}
+			continue
+		}
+
+		info, err := videoFs.ProbeCached(d.Path)
+		if err != nil || !info.Playable {
+			log.Printf("downloadPlanned: skipping unplayable %s: %s", d.Item.Key, probeReason(info, err))
+			rejected = append(rejected, d.Item.Key)
+			if d.Temporary {
+				os.Remove(d.Path)
+			}
+			continue
+		}
+		log.Printf("downloadPlanned: %s", info)
+		vids = append(vids, queuedVideo{path: d.Path, key: d.Item.Key, temporary: d.Temporary, cursor: &cursor})
+	}
+	return vids, rejected, nil
+}
+
+// probeReason describes why a probed file was rejected
+func probeReason(info videoFs.MediaInfo, err error) string {
 	if err != nil {
-		panic(err)
+		return err.Error()
 	}
+	return info.Reason
+}
 
-	// Configure player settings based on collection metadata
-	player.SetBounceLoop(collections[0].BounceLoop)
+// loadCollection lists a collection and downloads its first picks, skipping
+// the given item keys. With fallback, files already on disk are used when
+// nothing could be downloaded; the returned playlist is then never nil.
+func loadCollection(ctx context.Context, collection sharedTypes.Collection, opts PlaylistOptions, skip []string, fallback bool) ([]queuedVideo, *Playlist, error) {
+	items, err := videoFs.ListItems(collection)
+	if err != nil && !fallback {
+		return nil, nil, err
+	}
+	playlist := NewPlaylist(collection.Id, items, opts)
+	playlist.MarkUnplayable(skip...)
 
-	// Set up S3 index for future downloads
-	var nextS3Index int
-	if endOfCollection {
-		nextS3Index = 0
+	var vids []queuedVideo
+	if err != nil {
+		log.Printf("loadCollection: failed to list %s: %v", collection.Title, err)
 	} else {
-		nextS3Index = len(initialVideos)
+		// Use dynamic prefetch based on available memory
+		prefetchCount := getPrefetchBuffer()
+		if prefetchCount == 0 {
+			prefetchCount = 1 // Always download at least 1 video
+		}
+		log.Printf("loadCollection: downloading %d video(s) from %s", prefetchCount, collection.Title)
+
+		var rejected []string
+		vids, rejected, err = downloadPlanned(ctx, collection, drawItems(playlist, prefetchCount, nil))
+		playlist.MarkUnplayable(rejected...)
+	}
+	if len(vids) > 0 || !fallback {
+		return vids, playlist, err
+	}
+
+	// Fall back to checking whats pre existing
+	localVideos, localErr := videoFs.AvailableDownloadedVideos()
+	for _, path := range localVideos {
+		if !safemode.IsQuarantined(path) {
+			vids = append(vids, queuedVideo{path: path})
+		}
 	}
+	if len(vids) == 0 {
+		if err == nil {
+			err = localErr
+		}
+		if err == nil {
+			err = videoFs.ErrNoVideos
+		}
+		return nil, playlist, err
+	}
+	return vids, playlist, nil
+}
+
+// NewVideoPlayerScreen creates and initializes a new video player screen.
+// Without anything to play it starts on the no content screen.
+func NewVideoPlayerScreen(opts PlaylistOptions) *VideoPlayerScreen {
+	// Clean up any existing downloaded videos
+	clearDownloadedVideos()
+
+	// Available video collections matching the UI design
+	collections := videoFs.BuiltinCollections()
+
+	// Cancelled by Close so shutdown does not wait for downloads
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Download initial videos from the first collection
+	initialVideos, playlist, loadErr := loadCollection(ctx, collections[0], opts, nil, true)
 
 	// Create the screen instance
 	g := &VideoPlayerScreen{
-		player:              player,
 		downloadedVideos:    initialVideos,
-		playbackSpeed:       1.0,          // normal speed
-		playbackInterval:    "Every hour", // default interval
+		playbackSpeed:       1.0,                           // normal speed
+		playbackInterval:    sharedTypes.Every(time.Hour), // default interval
+		brightness:          1.0,
 		activeCollection:    0,
 		requestedCollection: 0,
 		collections:         collections,
-		nextS3Index:         nextS3Index,
+		playlist:            playlist,
+		playlistOptions:     opts,
 		currentVideo:        0,
 		playStartTime:       time.Now(),
 		perfMonitor:         performance.NewMonitor(120), // Track last 120 frames (2 seconds at 60fps)
@@ -146,9 +254,19 @@ func NewVideoPlayerScreen() *VideoPlayerScreen {
 		prefetchPending:     false,
 		switchResultCh:      make(chan switchResult, 1),
 		switchPending:       false,
+		catalogResultCh:     make(chan catalogResult, 1),
+		catalogListedAt:     time.Now(),
+		catalogFailed:       playlist.Len() == 0,
+		ctx:                 ctx,
+		cancelDownloads:     cancel,
 	}
 
-	player.Play()
+	// Open the first video that plays
+	if len(initialVideos) == 0 {
+		g.showNoContent(loadErr)
+	} else {
+		g.playFromBuffer()
+	}
 	return g
 }
 
@@ -171,6 +289,13 @@ func (g *VideoPlayerScreen) SetRenderer(renderer *sdl.Renderer) error {
 			log.Printf("Renderer: %s (accelerated=%s, vsync=%v, maxTexture=%dx%d)",
 				info.Name, accelStatus, hasVSync,
 				info.MaxTextureWidth, info.MaxTextureHeight)
+			diagnostics.Record(diagnostics.KindRenderer, map[string]any{
+				"name":             info.Name,
+				"accelerated":      isAccelerated,
+				"vsync":            hasVSync,
+				"maxTextureWidth":  info.MaxTextureWidth,
+				"maxTextureHeight": info.MaxTextureHeight,
+			})
 		}
 
 		// Log initial memory state
@@ -188,32 +313,50 @@ func (g *VideoPlayerScreen) Update(keyState []uint8) error {
 	// Track total frame time
 	frameStart := time.Now()
 
-	// Get skip decision from frame skipper based on recent performance
-	decision := g.frameSkipper.ShouldDecode(g.perfMonitor.GetReport())
-
-	// Conditionally decode based on performance
-	if decision.ShouldDecode {
-		// Decode new frame
+	// Decode whatever the media clock has made due; while paused the current frame stays on screen
+	switch {
+	case g.player == nil:
+		// Nothing to decode; keep looking for something to play
+		g.retryContent()
+	case g.paused:
+	default:
 		decodeStart := time.Now()
-		if err := g.player.Update(); err != nil {
-			g.err = err
-		}
+		err := g.player.Update()
 		decodeTime := time.Since(decodeStart)
-		g.perfMonitor.RecordFrameDecode(decodeTime)
-	} else {
-		// Skip decode - GPU will render last decoded frame
-		// This maintains smooth 60fps render with reduced decode rate
-		g.perfMonitor.RecordFrameDropped()
+
+		// Upload happens inside Update; report it separately from decode
+		stats := g.player.TakeFrameStats()
+		for i := 0; i < stats.Discarded; i++ {
+			g.perfMonitor.RecordFrameDropped()
+		}
+		if stats.Decoded > 0 {
+			decodeTime -= stats.Upload
+			g.perfMonitor.RecordFrameDecode(decodeTime)
+			g.perfMonitor.RecordFrameUpload(stats.Upload)
+
+			// Under load the decoder leaves out work rather than frames being skipped whole
+			mode := g.frameSkipper.Observe(decodeTime, g.perfMonitor.JankRate())
+			if err == nil {
+				g.player.SetSkipMode(mode)
+			}
+		}
+		if err != nil {
+			g.failCurrentVideo(err)
+		}
 	}
 
 	// Handle input - right arrow to skip to next video
 	g.handleInput(keyState)
 
 	// Apply current playback speed
-	g.player.SetPlaybackRate(g.playbackSpeed)
+	if g.player != nil {
+		g.player.SetPlaybackRate(g.effectiveSpeed())
+	}
 
 	// Handle automatic video switching based on interval
-	g.handleIntervalSwitching()
+	if !g.paused {
+		g.handleIntervalSwitching()
+	}
 
 	// Process background prefetch operations
 	g.handlePrefetchResults()
@@ -221,6 +364,10 @@ func (g *VideoPlayerScreen) Update(keyState []uint8) error {
 	// Process collection switching
 	g.handleCollectionSwitching()
 
+	// Pick up a refreshed listing of the active collection
+	g.handleCatalogResults()
+	g.refreshCatalog()
+
 	// Record total frame time (will add render time in Draw)
 	totalFrameTime := time.Since(frameStart)
 	g.perfMonitor.RecordTotalFrameTime(totalFrameTime)
@@ -228,9 +375,12 @@ func (g *VideoPlayerScreen) Update(keyState []uint8) error {
 	// Log performance metrics periodically
 	g.logPerformanceMetrics()
 
-	if g.err != nil {
-		return g.err
-	}
+	// Lower decode cost as the CPU heats up
+	g.checkThermal()
+
+	// Announce frame skip and memory pressure transitions
+	g.publishStateTransitions()
+
 	return nil
 }
 
@@ -255,31 +405,67 @@ func (g *VideoPlayerScreen) handleInput(keyState []uint8) {
 
 // handleIntervalSwitching checks if it's time to switch videos based on the interval setting
 func (g *VideoPlayerScreen) handleIntervalSwitching() {
-	dur := intervalToDuration(g.playbackInterval)
-	if dur > 0 && time.Since(g.playStartTime) >= dur {
+	if g.player == nil {
+		return
+	}
+	interval := g.effectiveInterval()
+	if loops := interval.LoopCount(); loops > 0 {
+		if g.player.Loops() >= loops {
+			log.Printf("Update: switching to next video after %d loop(s)", loops)
+			g.nextVideo()
+		}
+		return
+	}
+	if interval.Duration > 0 && time.Since(g.playStartTime) >= interval.Duration {
 		log.Printf("Update: switching to next video due to interval")
 		g.nextVideo()
 	}
 }
 
+// effectiveSpeed returns the active collection's speed override or the user setting
+func (g *VideoPlayerScreen) effectiveSpeed() float64 {
+	if speed := g.collections[g.activeCollection].Speed; speed > 0 {
+		return speed
+	}
+	return g.playbackSpeed
+}
+
+// effectiveInterval returns the active collection's interval override or the user setting
+func (g *VideoPlayerScreen) effectiveInterval() sharedTypes.Interval {
+	if iv := g.collections[g.activeCollection].Interval; iv != nil && iv.Valid() {
+		return *iv
+	}
+	return g.playbackInterval
+}
+
+// configurePlayer applies a collection's loop and fit modes to a player
+func configurePlayer(player *video.Player, collection sharedTypes.Collection) {
+	player.SetBounceLoop(collection.LoopMode() == sharedTypes.LoopBounce)
+	player.SetFitMode(collection.FitMode())
+}
+
 // handlePrefetchResults processes completed background prefetch operations
 func (g *VideoPlayerScreen) handlePrefetchResults() {
 	select {
 	case res := <-g.prefetchResultCh:
+		done := events.PrefetchPending{CollectionID: res.collectionID, Downloaded: len(res.vids)}
 		if res.err != nil {
-			g.err = res.err
-		} else if res.collectionIdx == g.activeCollection {
+			done.Error = res.err.Error()
+		}
+		events.Publish(events.TypePrefetchPending, done)
+
+		if res.err != nil {
+			log.Printf("prefetch: download failed, will retry: %v", res.err)
+		} else if res.collectionID == g.collections[g.activeCollection].Id {
+			if g.playlist != nil {
+				g.playlist.MarkUnplayable(res.rejected...)
+			}
 			if len(res.vids) > 0 {
 				g.downloadedVideos = append(g.downloadedVideos, res.vids...)
-				g.nextS3Index += len(res.vids)
 				log.Printf("prefetch: appended %d video(s) to buffer", len(res.vids))
 			}
-			if res.endOfCollection {
-				g.nextS3Index = 0
-				log.Printf("prefetch: reached end of collection - will wrap to start")
-			}
 		} else {
-			log.Printf("prefetch: discarding outdated results for collection %d", res.collectionIdx)
+			log.Printf("prefetch: discarding outdated results for collection %s", res.collectionID)
 		}
 		g.prefetchPending = false
 
@@ -297,50 +483,70 @@ func (g *VideoPlayerScreen) handlePrefetchResults() {
 func (g *VideoPlayerScreen) handleCollectionSwitching() {
 	// Start collection switch if requested
 	if g.requestedCollection != g.activeCollection && !g.switchPending {
-		g.switchPending = true
-		idx := g.requestedCollection
-		log.Printf("Update: starting collection download for %s", g.collections[idx].Title)
-
-		go func(collection sharedTypes.Collection, collectionIdx int) {
-			// Use dynamic prefetch for collection switch
-			prefetchCount := getPrefetchBuffer()
-			if prefetchCount == 0 {
-				prefetchCount = 1 // Always download at least 1 video
-			}
-			vids, end, err := videoFs.DownloadSegmentFromS3(collection, 0, prefetchCount)
-			g.switchResultCh <- switchResult{
-				vids:            vids,
-				endOfCollection: end,
-				err:             err,
-				collectionIdx:   collectionIdx,
-			}
-		}(g.collections[idx], idx)
+		g.startCollectionLoad(g.requestedCollection)
 	}
 
 	// Process completed collection switches
 	select {
 	case sw := <-g.switchResultCh:
-		if sw.err != nil {
-			g.err = sw.err
-		} else if sw.collectionIdx != g.requestedCollection {
-			log.Printf("switch: discarding outdated results for collection %d", sw.collectionIdx)
-		} else if len(sw.vids) == 0 {
-			g.err = errors.New("no videos downloaded from S3 for new collection")
-		} else {
-			if err := g.applyNewCollection(sw.collectionIdx, sw.vids, sw.endOfCollection); err != nil {
-				g.err = err
+		g.switchPending = false
+		switch {
+		case sw.collectionID != g.collections[g.requestedCollection].Id:
+			log.Printf("switch: discarding outdated results for collection %s", sw.collectionID)
+		case sw.err != nil || len(sw.vids) == 0:
+			err := sw.err
+			if err == nil {
+				err = errors.New("no playable videos in collection")
+			}
+			log.Printf("switch: failed to load %s, staying on %s: %v",
+				g.collections[g.requestedCollection].Title, g.collections[g.activeCollection].Title, err)
+			g.requestedCollection = g.activeCollection
+			if g.player == nil {
+				g.showNoContent(err)
 			}
+		default:
+			g.applyNewCollection(g.requestedCollection, sw.vids, sw.playlist)
 		}
-		g.switchPending = false
 	default:
 		// No switch results available
 	}
 }
 
+// startCollectionLoad lists and downloads a collection in the background;
+// handleCollectionSwitching applies the result. While nothing is playing,
+// files already on disk are accepted as well.
+func (g *VideoPlayerScreen) startCollectionLoad(idx int) {
+	g.switchPending = true
+	g.requestedCollection = idx
+	collection := g.collections[idx]
+	log.Printf("Update: starting collection download for %s", collection.Title)
+
+	// Keep items that already failed from being picked again
+	var skip []string
+	if idx == g.activeCollection && g.playlist != nil {
+		skip = g.playlist.Unplayable()
+	}
+	fallback := g.player == nil
+
+	go func(collection sharedTypes.Collection, opts PlaylistOptions) {
+		vids, playlist, err := loadCollection(g.ctx, collection, opts, skip, fallback)
+		g.switchResultCh <- switchResult{
+			vids:         vids,
+			playlist:     playlist,
+			err:          err,
+			collectionID: collection.Id,
+		}
+	}(collection, g.playlistOptions)
+}
+
 // Draw renders the current video frame using SDL2
+// Nothing is drawn while there is no content; see HasContent.
 func (g *VideoPlayerScreen) Draw(renderer *sdl.Renderer, screenWidth, screenHeight int32) error {
-	if g.err != nil {
-		return g.err
+	// Keep the screen black while the display is turned off
+	if g.displayOff {
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.FillRect(&sdl.Rect{X: 0, Y: 0, W: screenWidth, H: screenHeight})
+		return nil
 	}
 
 	// Track render time
@@ -349,32 +555,26 @@ func (g *VideoPlayerScreen) Draw(renderer *sdl.Renderer, screenWidth, screenHeig
 	if g.player != nil {
 		err = g.player.Draw(renderer, screenWidth, screenHeight)
 	}
+
+	// Dim the picture for reduced brightness
+	if g.brightness < 1 {
+		renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+		renderer.SetDrawColor(0, 0, 0, uint8((1-g.brightness)*255))
+		renderer.FillRect(&sdl.Rect{X: 0, Y: 0, W: screenWidth, H: screenHeight})
+	}
 	renderTime := time.Since(renderStart)
 	g.perfMonitor.RecordFrameRender(renderTime)
 
 	return err
 }
 
-// intervalToDuration converts interval strings to time.Duration
-func intervalToDuration(label string) time.Duration {
-	switch label {
-	case "Every minute":
-		return time.Minute
-	case "Every hour":
-		return time.Hour
-	case "Every 12 hours":
-		return 12 * time.Hour
-	case "Every day":
-		return 24 * time.Hour
-	case "Every week":
-		return 7 * 24 * time.Hour
-	default:
-		return time.Hour
-	}
-}
-
 // nextVideo advances to the next video in the queue
 func (g *VideoPlayerScreen) nextVideo() {
+	// Without a player retryContent starts whatever arrives
+	if g.player == nil {
+		return
+	}
+
 	// Queue request if prefetch is in progress
 	if g.prefetchPending {
 		g.queuedNextCalls = 1
@@ -382,41 +582,46 @@ func (g *VideoPlayerScreen) nextVideo() {
 		return
 	}
 
-	// Clear any previous errors
-	g.err = nil
-
-	if len(g.downloadedVideos) == 0 {
-		log.Printf("nextVideo: no videos in buffer")
+	// Keep looping the current video rather than running out of content
+	if len(g.downloadedVideos) <= 1 {
+		log.Printf("nextVideo: no other video in buffer, keeping current one")
+		g.startPrefetch()
 		return
 	}
 
 	// Clean up the current video
+	g.publishVideoEnded()
+	g.endVideoStats()
 	g.cleanupCurrentVideo()
 
-	// Advance to next video
-	if len(g.downloadedVideos) == 0 {
-		g.err = errors.New("buffer unexpectedly empty after cleanup")
-		return
-	}
-
 	// Start playing the next video
-	if err := g.startNextVideo(); err != nil {
-		g.err = err
-		return
-	}
-
-	// Reset frame skipper for new video (fresh performance profile)
-	g.frameSkipper.Reset()
+	g.playFromBuffer()
 
 	// Start background prefetch for the next video
 	g.startPrefetch()
 }
 
+// failCurrentVideo skips a video that stopped decoding and moves on to the next one
+func (g *VideoPlayerScreen) failCurrentVideo(err error) {
+	g.skipVideo(g.downloadedVideos[g.currentVideo], err)
+	g.endVideoStats()
+	g.cleanupCurrentVideo()
+	g.playFromBuffer()
+	g.startPrefetch()
+}
+
+// skipVideo reports a video that cannot be played and keeps the playlist from picking it again
+func (g *VideoPlayerScreen) skipVideo(v queuedVideo, err error) {
+	log.Printf("Skipping %s: %v", v.path, err)
+	if v.key != "" && g.playlist != nil {
+		g.playlist.MarkUnplayable(v.key)
+	}
+	g.publishVideoFailed(v, err)
+}
+
 // cleanupCurrentVideo removes the currently playing video from disk and buffer
 // Performs aggressive cleanup to free memory immediately
 func (g *VideoPlayerScreen) cleanupCurrentVideo() {
-	playedPath := g.downloadedVideos[g.currentVideo]
-
 	// Log memory before cleanup
 	memBefore := performance.GetSystemMemory()
 
@@ -426,15 +631,8 @@ func (g *VideoPlayerScreen) cleanupCurrentVideo() {
 		g.player = nil // Ensure GC can collect
 	}
 
-	// Remove the video file from disk
-	if err := os.Remove(playedPath); err != nil {
-		log.Printf("cleanupCurrentVideo: failed to remove %s: %v", playedPath, err)
-	} else {
-		log.Printf("cleanupCurrentVideo: removed %s", playedPath)
-	}
-
-	// Remove from buffer
-	g.downloadedVideos = append(g.downloadedVideos[:g.currentVideo], g.downloadedVideos[g.currentVideo+1:]...)
+	// Remove from disk and buffer
+	g.removeBuffered(g.currentVideo)
 	g.currentVideo = 0 // Always use index 0 after removal
 
 	// Hint to GC that now is a good time to run
@@ -448,42 +646,154 @@ func (g *VideoPlayerScreen) cleanupCurrentVideo() {
 		freed, memBefore.AvailableMB, memAfter.AvailableMB)
 }
 
-// startNextVideo initializes playback of the next video in the buffer
-func (g *VideoPlayerScreen) startNextVideo() error {
-	nextPath := g.downloadedVideos[g.currentVideo]
-	log.Printf("nextVideo: playing %s", nextPath)
+// removeBuffered drops a video from the buffer. Downloaded copies are deleted;
+// local library files stay in place.
+func (g *VideoPlayerScreen) removeBuffered(i int) {
+	v := g.downloadedVideos[i]
+	if v.temporary {
+		if err := os.Remove(v.path); err != nil {
+			log.Printf("removeBuffered: failed to remove %s: %v", v.path, err)
+		} else {
+			log.Printf("removeBuffered: removed %s", v.path)
+		}
+	}
+	g.downloadedVideos = append(g.downloadedVideos[:i], g.downloadedVideos[i+1:]...)
+}
 
-	file, err := os.Open(nextPath)
+// openVideo opens a file for playback with the active collection's settings
+func (g *VideoPlayerScreen) openVideo(path string) (*video.Player, error) {
+	safemode.Opening(path)
+	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	newPlayer, err := video.NewPlayer(file)
+	player, err := video.NewPlayer(file)
 	if err != nil {
-		return err
+		file.Close()
+		return nil, err
 	}
 
 	// Configure player settings
-	newPlayer.SetBounceLoop(g.collections[g.activeCollection].BounceLoop)
+	configurePlayer(player, g.collections[g.activeCollection])
 
 	// Set up SDL2 renderer
 	if g.renderer != nil {
-		if err := newPlayer.SetRenderer(g.renderer); err != nil {
-			return err
+		if err := player.SetRenderer(g.renderer); err != nil {
+			player.Close()
+			return nil, err
 		}
 	}
+	diagnostics.Record(diagnostics.KindCodecs, map[string]any{"path": path, "codec": player.GetCodecInfo()})
+	return player, nil
+}
 
-	// Start playback
-	g.player = newPlayer
+// openFromBuffer opens the first buffered video the decoder accepts. Videos
+// that fail are skipped and dropped; the last error is returned when none is left.
+func (g *VideoPlayerScreen) openFromBuffer() error {
+	err := videoFs.ErrNoVideos
+	for len(g.downloadedVideos) > 0 {
+		v := g.downloadedVideos[0]
+		log.Printf("openFromBuffer: opening %s", v.path)
+		player, openErr := g.openVideo(v.path)
+		if openErr == nil {
+			g.player = player
+			g.currentVideo = 0
+			return nil
+		}
+		err = openErr
+		g.skipVideo(v, err)
+		g.removeBuffered(0)
+	}
+	return err
+}
+
+// beginPlayback starts the freshly opened player
+func (g *VideoPlayerScreen) beginPlayback() {
+	current := g.downloadedVideos[g.currentVideo]
 	g.player.Play()
 	g.playStartTime = time.Now()
+	g.contentError = ""
+	g.commitPlaylistPosition(current)
+	g.publishVideoStarted()
+
+	// Reset frame skipper for new video (fresh performance profile)
+	g.frameSkipper.Reset()
+	g.perfMonitor.StartVideo(current.path)
 
 	// Log codec information for the new video
 	info := g.player.GetCodecInfo()
 	log.Printf("Video: %s | Codec: %s [HW=%v] | %dx%d @ %.1ffps",
-		nextPath, info.Name, info.IsHardwareAccel, info.Width, info.Height, info.FPS)
+		current.path, info.Name, info.IsHardwareAccel, info.Width, info.Height, info.FPS)
+}
 
-	return nil
+// playFromBuffer plays the first usable buffered video, or shows the no content
+// screen when none is left
+func (g *VideoPlayerScreen) playFromBuffer() {
+	if err := g.openFromBuffer(); err != nil {
+		g.showNoContent(err)
+		return
+	}
+	g.beginPlayback()
+}
+
+// showNoContent records why nothing can be played; the root screen then shows
+// setup instructions until retryContent finds a video
+func (g *VideoPlayerScreen) showNoContent(err error) {
+	if err == nil {
+		err = videoFs.ErrNoVideos
+	}
+	g.contentRetryAt = time.Now().Add(contentRetryInterval)
+	log.Printf("No content to play, retrying in %v: %v", contentRetryInterval, err)
+
+	// Announce the change, not every failed retry
+	if g.contentError == err.Error() {
+		return
+	}
+	g.contentError = err.Error()
+	events.Publish(events.TypeContentUnavailable, events.ContentUnavailable{
+		CollectionID: g.collections[g.activeCollection].Id,
+		Error:        g.contentError,
+	})
+}
+
+// retryContent looks for something to play while the no content screen is shown
+func (g *VideoPlayerScreen) retryContent() {
+	// A prefetch or collection load delivered videos meanwhile
+	if len(g.downloadedVideos) > 0 {
+		g.playFromBuffer()
+		g.startPrefetch()
+		return
+	}
+	if g.switchPending || g.prefetchPending || time.Now().Before(g.contentRetryAt) {
+		return
+	}
+	g.contentRetryAt = time.Now().Add(contentRetryInterval)
+
+	// Prefer a local collection, e.g. a USB drive that was just plugged in
+	idx := g.activeCollection
+	if !g.collections[idx].IsLocal() {
+		for i, c := range g.collections {
+			if c.IsLocal() {
+				idx = i
+				break
+			}
+		}
+	}
+	g.startCollectionLoad(idx)
+}
+
+// HasContent reports whether a video is loaded; otherwise the no content screen is shown
+func (g *VideoPlayerScreen) HasContent() bool {
+	return g.player != nil
+}
+
+// ContentError returns why nothing is playing, or "" while a video plays
+func (g *VideoPlayerScreen) ContentError() string {
+	if g.player != nil {
+		return ""
+	}
+	return g.contentError
 }
 
 // startPrefetch begins background download of the next video
@@ -517,22 +827,38 @@ func (g *VideoPlayerScreen) startPrefetch() {
 			missing, memInfo.AvailableMB)
 	}
 
+	// Pick the upcoming items on the main thread; the playlist is not goroutine-safe
+	planned := drawItems(g.playlist, missing, g.downloadedVideos)
+	if len(planned) == 0 {
+		return
+	}
+
 	g.prefetchPending = true
-	collIdx := g.activeCollection
-	startIdx := g.nextS3Index
+	events.Publish(events.TypePrefetchPending, events.PrefetchPending{
+		CollectionID: g.collections[g.activeCollection].Id,
+		Pending:      true,
+		Requested:    len(planned),
+	})
 
 	log.Printf("startPrefetch: Downloading %d video(s) [buffer=%d, avail=%dMB, pressure=%s]",
-		missing, targetBuffer, memInfo.AvailableMB, pressure.String())
+		len(planned), targetBuffer, memInfo.AvailableMB, pressure.String())
 
-	go func(collection sharedTypes.Collection, collectionIdx, start, count int) {
-		vids, end, err := videoFs.DownloadSegmentFromS3(collection, start, count)
+	go func(collection sharedTypes.Collection, planned []plannedItem) {
+		vids, rejected, err := downloadPlanned(g.ctx, collection, planned)
 		g.prefetchResultCh <- prefetchResult{
-			vids:            vids,
-			endOfCollection: end,
-			err:             err,
-			collectionIdx:   collectionIdx,
+			vids:         vids,
+			rejected:     rejected,
+			err:          err,
+			collectionID: collection.Id,
 		}
-	}(g.collections[collIdx], collIdx, startIdx, missing)
+	}(g.collections[g.activeCollection], planned)
+}
+
+// commitPlaylistPosition persists the playlist position of a video that just started playing
+func (g *VideoPlayerScreen) commitPlaylistPosition(v queuedVideo) {
+	if v.cursor != nil && g.playlist != nil {
+		g.playlist.Commit(*v.cursor)
+	}
 }
 
 // SetPlaybackSpeed updates the video playback speed
@@ -545,9 +871,141 @@ func (g *VideoPlayerScreen) SetPlaybackSpeed(speed float64) {
 }
 
 // SetPlaybackInterval updates the automatic video switching interval
-func (g *VideoPlayerScreen) SetPlaybackInterval(label string) {
-	log.Printf("SetPlaybackInterval: set to '%s'", label)
-	g.playbackInterval = label
+func (g *VideoPlayerScreen) SetPlaybackInterval(interval sharedTypes.Interval) {
+	if !interval.Valid() {
+		log.Printf("SetPlaybackInterval: ignoring invalid interval %q", interval)
+		return
+	}
+	log.Printf("SetPlaybackInterval: set to %s", interval.Label())
+	g.playbackInterval = interval
+}
+
+// SetPlaylistOptions changes the playback order. Already buffered videos still
+// play, but upcoming picks follow the new order.
+func (g *VideoPlayerScreen) SetPlaylistOptions(opts PlaylistOptions) {
+	log.Printf("SetPlaylistOptions: order=%s avoidRepeat=%d", opts.Order, opts.AvoidRepeat)
+	g.playlistOptions = opts
+
+	if g.playlist != nil {
+		next := NewPlaylist(g.collections[g.activeCollection].Id, g.playlist.Items(), opts)
+		next.MarkUnplayable(g.playlist.Unplayable()...)
+		g.playlist = next
+	}
+
+	// Buffered cursors belong to the previous order and must not be persisted
+	for i := range g.downloadedVideos {
+		g.downloadedVideos[i].cursor = nil
+	}
+}
+
+// SetBrightness sets the picture brightness between 0 (black) and 1 (unchanged)
+func (g *VideoPlayerScreen) SetBrightness(brightness float64) {
+	if brightness < 0 || brightness > 1 {
+		return
+	}
+	log.Printf("SetBrightness: %.0f%%", brightness*100)
+	g.brightness = brightness
+}
+
+// Brightness returns the picture brightness between 0 and 1
+func (g *VideoPlayerScreen) Brightness() float64 {
+	return g.brightness
+}
+
+// Skip advances to the next video as if the right arrow key was pressed
+func (g *VideoPlayerScreen) Skip() {
+	g.nextVideo()
+}
+
+// SetPaused freezes or resumes playback. The rotation interval does not run while paused.
+func (g *VideoPlayerScreen) SetPaused(paused bool) {
+	if paused == g.paused {
+		return
+	}
+	g.paused = paused
+	if paused {
+		g.pausedAt = time.Now()
+		log.Printf("SetPaused: playback paused")
+		return
+	}
+
+	g.playStartTime = g.playStartTime.Add(time.Since(g.pausedAt))
+	if g.player != nil {
+		g.player.Play()
+	}
+	log.Printf("SetPaused: playback resumed")
+}
+
+// Paused reports whether playback is paused
+func (g *VideoPlayerScreen) Paused() bool {
+	return g.paused
+}
+
+// SetDisplayOn blanks the screen and pauses playback, or restores both.
+// A pause that was already active before the display went off is kept.
+func (g *VideoPlayerScreen) SetDisplayOn(on bool) {
+	if on != g.displayOff {
+		return
+	}
+	g.displayOff = !on
+	if !on {
+		g.pausedByDisplay = !g.paused
+		g.SetPaused(true)
+		log.Printf("SetDisplayOn: display off")
+		return
+	}
+
+	if g.pausedByDisplay {
+		g.SetPaused(false)
+	}
+	g.pausedByDisplay = false
+	log.Printf("SetDisplayOn: display on")
+}
+
+// DisplayOn reports whether the picture is shown
+func (g *VideoPlayerScreen) DisplayOn() bool {
+	return !g.displayOff
+}
+
+// ActiveCollection returns the collection currently playing
+func (g *VideoPlayerScreen) ActiveCollection() sharedTypes.Collection {
+	return g.collections[g.activeCollection]
+}
+
+// CurrentVideoPath returns the file of the video on screen
+func (g *VideoPlayerScreen) CurrentVideoPath() string {
+	if g.player != nil && g.currentVideo < len(g.downloadedVideos) {
+		return g.downloadedVideos[g.currentVideo].path
+	}
+	return ""
+}
+
+// PlaybackPosition returns the position within the current loop and the number of completed loops
+func (g *VideoPlayerScreen) PlaybackPosition() (time.Duration, int) {
+	if g.player == nil {
+		return 0, 0
+	}
+	return g.player.Position(), g.player.Loops()
+}
+
+// EffectiveSpeed returns the playback speed in use, including collection overrides
+func (g *VideoPlayerScreen) EffectiveSpeed() float64 {
+	return g.effectiveSpeed()
+}
+
+// EffectiveInterval returns the rotation interval in use, including collection overrides
+func (g *VideoPlayerScreen) EffectiveInterval() sharedTypes.Interval {
+	return g.effectiveInterval()
+}
+
+// SelectCollection requests a switch to the collection with the given id
+func (g *VideoPlayerScreen) SelectCollection(id string) error {
+	idx := indexOfCollection(g.collections, id)
+	if idx < 0 {
+		return fmt.Errorf("unknown collection %q", id)
+	}
+	g.SetRequestedCollection(idx)
+	return nil
 }
 
 // SetRequestedCollection requests a switch to a different video collection
@@ -560,19 +1018,139 @@ func (g *VideoPlayerScreen) SetRequestedCollection(idx int) {
 	g.requestedCollection = idx
 }
 
+// SetLocalCollections replaces the local-library collections, which are listed
+// after the cloud collections. When the active local collection disappears
+// (e.g. its USB drive was removed) it is kept until playback has switched back
+// to the first collection.
+func (g *VideoPlayerScreen) SetLocalCollections(local []sharedTypes.Collection) {
+	active := g.collections[g.activeCollection]
+	requestedID := g.collections[g.requestedCollection].Id
+
+	updated := make([]sharedTypes.Collection, 0, len(g.collections)+len(local))
+	for _, c := range g.collections {
+		if !c.IsLocal() {
+			updated = append(updated, c)
+		}
+	}
+	updated = append(updated, local...)
+
+	activeIdx := indexOfCollection(updated, active.Id)
+	if activeIdx < 0 {
+		log.Printf("SetLocalCollections: active collection %s is no longer available", active.Title)
+		updated = append(updated, active)
+		activeIdx = len(updated) - 1
+		requestedID = updated[0].Id
+	}
+
+	requestedIdx := indexOfCollection(updated, requestedID)
+	if requestedIdx < 0 {
+		requestedIdx = activeIdx
+	}
+
+	g.collections = updated
+	g.activeCollection = activeIdx
+	g.requestedCollection = requestedIdx
+	log.Printf("SetLocalCollections: %d collection(s) available (%d local)", len(updated), len(local))
+
+	// Try new local content right away when nothing is playing
+	if g.player == nil && len(local) > 0 {
+		g.contentRetryAt = time.Time{}
+	}
+}
+
+// indexOfCollection returns the index of the collection with the given id, or -1
+func indexOfCollection(collections []sharedTypes.Collection, id string) int {
+	for i, c := range collections {
+		if c.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // Collections returns the list of available video collections
 func (g *VideoPlayerScreen) Collections() []sharedTypes.Collection {
 	return g.collections
 }
 
+// ReloadCatalog re-lists the active collection in the background. Buffered
+// videos keep playing; upcoming picks come from the new listing.
+func (g *VideoPlayerScreen) ReloadCatalog() {
+	if g.catalogPending {
+		return
+	}
+	g.catalogPending = true
+	g.catalogListedAt = time.Now()
+
+	collection := g.collections[g.activeCollection]
+	log.Printf("ReloadCatalog: re-listing %s", collection.Title)
+	go func(collection sharedTypes.Collection) {
+		items, err := videoFs.ListItems(collection)
+		g.catalogResultCh <- catalogResult{items: items, err: err, collectionID: collection.Id}
+	}(collection)
+}
+
+// handleCatalogResults applies a finished ReloadCatalog listing
+func (g *VideoPlayerScreen) handleCatalogResults() {
+	select {
+	case res := <-g.catalogResultCh:
+		g.catalogPending = false
+		switch {
+		case res.collectionID != g.collections[g.activeCollection].Id || g.playlist == nil:
+			log.Printf("ReloadCatalog: collection changed while listing, discarding result")
+		case res.err != nil:
+			g.catalogFailed = true
+			log.Printf("ReloadCatalog: listing failed, keeping current playlist: %v", res.err)
+		default:
+			g.catalogFailed = false
+			g.playlist.Relist(res.items)
+			log.Printf("ReloadCatalog: %d item(s) in %s", len(res.items), g.collections[g.activeCollection].Title)
+
+			// Fill the buffer from the new listing, e.g. after the first successful one
+			if g.player != nil {
+				g.startPrefetch()
+			}
+		}
+	default:
+	}
+}
+
+// refreshCatalog re-lists the active collection when it is due. The no content
+// screen is left to retryContent, which reloads the whole collection.
+func (g *VideoPlayerScreen) refreshCatalog() {
+	if g.catalogPending || g.player == nil {
+		return
+	}
+	interval := catalogRefreshInterval
+	if g.catalogFailed || g.playlist == nil || g.playlist.Len() == 0 {
+		interval = catalogRetryInterval
+	}
+	if time.Since(g.catalogListedAt) >= interval {
+		g.ReloadCatalog()
+	}
+}
+
+// Close cancels in-flight downloads and releases the current player
+func (g *VideoPlayerScreen) Close() {
+	g.cancelDownloads()
+	g.endVideoStats()
+	if g.player != nil {
+		_ = g.player.Close()
+		g.player = nil
+	}
+}
+
 // applyNewCollection switches to a new collection that was downloaded in the background
-func (g *VideoPlayerScreen) applyNewCollection(idx int, vids []string, endOfCollection bool) error {
+func (g *VideoPlayerScreen) applyNewCollection(idx int, vids []queuedVideo, playlist *Playlist) {
 	log.Printf("applyNewCollection: switching to %s", g.collections[idx].Title)
 
 	// Log memory before cleanup
 	memBefore := performance.GetSystemMemory()
 
 	// Stop current playback
+	g.publishVideoEnded()
+	g.endVideoStats()
+	previousID := g.collections[g.activeCollection].Id
 	if g.player != nil {
 		_ = g.player.Close()
 		g.player = nil // Ensure GC can collect
@@ -580,8 +1158,11 @@ func (g *VideoPlayerScreen) applyNewCollection(idx int, vids []string, endOfColl
 
 	// Clean up old videos aggressively
 	removedCount := 0
-	for _, p := range g.downloadedVideos {
-		if err := os.Remove(p); err == nil {
+	for _, v := range g.downloadedVideos {
+		if !v.temporary {
+			continue
+		}
+		if err := os.Remove(v.path); err == nil {
 			removedCount++
 		}
 	}
@@ -591,40 +1172,24 @@ func (g *VideoPlayerScreen) applyNewCollection(idx int, vids []string, endOfColl
 	g.downloadedVideos = vids
 	g.currentVideo = 0
 	g.activeCollection = idx
+	g.playlist = playlist
+	g.catalogListedAt = time.Now()
+	g.catalogFailed = playlist == nil || playlist.Len() == 0
 
-	if endOfCollection {
-		g.nextS3Index = 0
-	} else {
-		g.nextS3Index = len(vids)
-	}
-
-	// Start playing the first video of the new collection
-	file, err := os.Open(vids[0])
-	if err != nil {
-		return err
-	}
-
-	player, err := video.NewPlayer(file)
-	if err != nil {
-		return err
+	// Start playing the first video of the new collection that opens
+	if err := g.openFromBuffer(); err != nil {
+		g.showNoContent(err)
+		return
 	}
-
-	// Configure new player
-	player.SetBounceLoop(g.collections[idx].BounceLoop)
-
-	if g.renderer != nil {
-		if err := player.SetRenderer(g.renderer); err != nil {
-			return err
-		}
+	if previousID != g.collections[idx].Id {
+		events.Publish(events.TypeCollectionSwitched, events.CollectionSwitched{
+			ID:         g.collections[idx].Id,
+			Title:      g.collections[idx].Title,
+			PreviousID: previousID,
+			Videos:     len(g.downloadedVideos),
+		})
 	}
-
-	// Start playback
-	g.player = player
-	g.playStartTime = time.Now()
-	g.player.Play()
-
-	// Reset frame skipper for new collection (fresh performance profile)
-	g.frameSkipper.Reset()
+	g.beginPlayback()
 
 	// Hint to GC to clean up old collection resources
 	runtime.GC()
@@ -633,9 +1198,7 @@ func (g *VideoPlayerScreen) applyNewCollection(idx int, vids []string, endOfColl
 	memAfter := performance.GetSystemMemory()
 	freed := int64(memAfter.AvailableMB) - int64(memBefore.AvailableMB)
 	log.Printf("applyNewCollection: switched to %s with %d videos, freed ~%dMB (avail: %dMB → %dMB)",
-		g.collections[idx].Title, len(vids), freed, memBefore.AvailableMB, memAfter.AvailableMB)
-
-	return nil
+		g.collections[idx].Title, len(g.downloadedVideos), freed, memBefore.AvailableMB, memAfter.AvailableMB)
 }
 
 // PlaybackSpeed returns the current playback speed multiplier
@@ -643,8 +1206,13 @@ func (g *VideoPlayerScreen) PlaybackSpeed() float64 {
 	return g.playbackSpeed
 }
 
+// PlaylistOptions returns the current playback order configuration
+func (g *VideoPlayerScreen) PlaylistOptions() PlaylistOptions {
+	return g.playlistOptions
+}
+
 // PlaybackInterval returns the current interval setting
-func (g *VideoPlayerScreen) PlaybackInterval() string {
+func (g *VideoPlayerScreen) PlaybackInterval() sharedTypes.Interval {
 	return g.playbackInterval
 }
 
@@ -670,7 +1238,7 @@ func (g *VideoPlayerScreen) logPerformanceMetrics() {
 			healthStatus = "WARNING"
 		}
 
-		log.Printf("Performance[%s]: Decode=%.2fms Render=%.2fms Total=%.2fms Frames=%d Drops=%d (%.1f%%) Mode=%s Uptime=%ds",
+		log.Printf("Performance[%s]: Decode=%.2fms Render=%.2fms Total=%.2fms Frames=%d Drops=%d (%.1f%%) Jank=%d (%.1f%%) Mode=%s Uptime=%ds",
 			healthStatus,
 			report.AvgDecodeMs,
 			report.AvgRenderMs,
@@ -678,19 +1246,64 @@ func (g *VideoPlayerScreen) logPerformanceMetrics() {
 			report.TotalFrames,
 			report.DroppedFrames,
 			report.DropRate,
+			report.JankFrames,
+			report.JankRate,
 			skipMode.String(),
 			report.UptimeSeconds)
-
+		log.Printf("Performance p50/p95/p99/max: Decode=%s Upload=%s Render=%s Present=%s Interval=%s",
+			report.Decode, report.Upload, report.Render, report.Present, report.Interval)
+
+		diagnostics.Record(diagnostics.KindPerformance, map[string]any{
+			"report":   report,
+			"skipMode": skipMode.String(),
+			"health":   healthStatus,
+		})
 		g.lastPerfLog = now
 	}
 
 	// Log memory stats every 10 seconds
 	if now.Sub(g.lastMemoryLog) >= 10*time.Second {
 		performance.LogMemorySnapshot()
+		diagnostics.Record(diagnostics.KindMemory, map[string]any{
+			"system":   performance.GetSystemMemory(),
+			"go":       performance.GetGoMemory(),
+			"pressure": performance.GetMemoryPressure().String(),
+		})
 		g.lastMemoryLog = now
 	}
 }
 
+// FramePresented records how long presenting a frame took and the time since the
+// previous one, which drives jank detection. Called by the root screen after Present.
+func (g *VideoPlayerScreen) FramePresented(presentTime time.Duration) {
+	now := time.Now()
+	g.perfMonitor.RecordFramePresent(presentTime)
+	if !g.lastPresent.IsZero() {
+		g.perfMonitor.RecordFrameInterval(now.Sub(g.lastPresent))
+	}
+	g.lastPresent = now
+}
+
+// endVideoStats logs and records the performance summary of the video that is ending
+func (g *VideoPlayerScreen) endVideoStats() {
+	summary, ok := g.perfMonitor.EndVideo()
+	if !ok {
+		return
+	}
+	log.Printf("Video summary: %s | %.0fs Frames=%d Drops=%d Jank=%d/%d (%.1f%%, >%.1fms)",
+		summary.Name,
+		summary.DurationSeconds,
+		summary.Frames,
+		summary.DroppedFrames,
+		summary.JankFrames,
+		summary.PresentedFrames,
+		summary.JankRate,
+		summary.JankThresholdMs)
+	log.Printf("Video summary p50/p95/p99/max: Decode=%s Upload=%s Render=%s Present=%s Interval=%s",
+		summary.Decode, summary.Upload, summary.Render, summary.Present, summary.Interval)
+	diagnostics.Record(diagnostics.KindVideos, summary)
+}
+
 // GetPerformanceReport returns current performance metrics
 func (g *VideoPlayerScreen) GetPerformanceReport() performance.PerformanceReport {
 	return g.perfMonitor.GetReport()
@@ -717,11 +1330,10 @@ func (g *VideoPlayerScreen) GetFrameSkipStats() video.FrameSkipperStats {
 	return g.frameSkipper.GetStats()
 }
 
-// SetFrameSkipThresholds allows customizing frame skip performance thresholds
-// slowMs: decode time threshold for "slow" classification (default 30ms)
-// goodMs: decode time threshold for "good" classification (default 20ms)
-func (g *VideoPlayerScreen) SetFrameSkipThresholds(slowMs, goodMs float64) {
-	g.frameSkipper.SetThresholds(slowMs, goodMs)
+// SetFrameSkipTarget sets the decode time the frame skipper aims to stay under
+// targetMs: target decode latency (default 12ms)
+func (g *VideoPlayerScreen) SetFrameSkipTarget(targetMs float64) {
+	g.frameSkipper.SetTargetLatency(targetMs)
 }
 
 // GetMemoryStatus returns current system memory status
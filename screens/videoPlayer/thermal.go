@@ -0,0 +1,59 @@
+package videoPlayer
+
+import (
+	"strings"
+	"time"
+
+	"flow-frame/pkg/events"
+	"flow-frame/pkg/performance"
+	"flow-frame/pkg/video"
+)
+
+// thermalCheckInterval is how often the thermal zones and cpufreq are sampled
+const thermalCheckInterval = 2 * time.Second
+
+// thermalMinMode is the least decode work left out at each thermal level.
+// Lowering decode cost while the CPU is warm keeps it from reaching the trip
+// point; once throttled the decoder has less CPU to work with.
+func thermalMinMode(level performance.ThermalLevel) video.SkipMode {
+	switch level {
+	case performance.ThermalWarm:
+		return video.ModeSkipLoopFilterNonRef
+	case performance.ThermalThrottled:
+		return video.ModeSkipLoopFilter
+	default:
+		return video.ModeNormal
+	}
+}
+
+// checkThermal samples the thermal sensor periodically, lowers decode cost as
+// the CPU heats up and announces thermal level changes
+func (g *VideoPlayerScreen) checkThermal() {
+	if time.Since(g.lastThermalCheck) < thermalCheckInterval {
+		return
+	}
+	g.lastThermalCheck = time.Now()
+
+	snapshot, level, previous := g.thermal.Sample()
+	g.thermalSnapshot = snapshot
+	if level == previous {
+		return
+	}
+
+	g.frameSkipper.SetMinMode(thermalMinMode(level))
+	events.Publish(events.TypeThermalChanged, events.ThermalChanged{
+		From:  strings.ToLower(previous.String()),
+		To:    strings.ToLower(level.String()),
+		TempC: snapshot.MaxTempC(),
+	})
+}
+
+// Thermal returns the last thermal sample
+func (g *VideoPlayerScreen) Thermal() performance.ThermalSnapshot {
+	return g.thermalSnapshot
+}
+
+// ThermalLevel returns the thermal level the decoder is adjusted to
+func (g *VideoPlayerScreen) ThermalLevel() performance.ThermalLevel {
+	return g.thermal.Level()
+}
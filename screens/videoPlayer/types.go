@@ -1,6 +1,7 @@
 package videoPlayer
 
 import (
+	"context"
 	"time"
 
 	"flow-frame/pkg/video"
@@ -11,19 +12,35 @@ import (
 )
 
 type VideoPlayerScreen struct {
-	player *video.Player
-	err    error
+	player *video.Player // nil while there is nothing to play
+
+	// No content fallback, active while player is nil
+	contentError   string    // why nothing is playing, shown on the no content screen
+	contentRetryAt time.Time // when to look for content again
 
 	// Local video library information
-	downloadedVideos    []string // list of available videos
-	activeCollection    int      // information about the current collection
-	requestedCollection int      // information about the requested collection
+	downloadedVideos    []queuedVideo // list of available videos
+	activeCollection    int           // information about the current collection
+	requestedCollection int           // information about the requested collection
 	collections         []sharedTypes.Collection
-	nextS3Index         int // index of the next video in the collection to download from S3
+
+	// Playlist ordering for the active collection
+	playlist        *Playlist       // decides which item of the collection plays next
+	playlistOptions PlaylistOptions // order, seed and no-repeat window applied to every collection
 
 	// Playback configuration that can be tweaked at runtime via the popup menu.
-	playbackSpeed    float64 // multiplier, e.g. 1.0 = normal speed
-	playbackInterval string  // human-readable interval label, e.g. "Every hour"
+	// Collections may override both, see effectiveSpeed and effectiveInterval.
+	playbackSpeed    float64              // multiplier, e.g. 1.0 = normal speed
+	playbackInterval sharedTypes.Interval // when to move on, e.g. every hour or after 3 loops
+	brightness       float64              // 0-1; the picture is dimmed with a black overlay below 1
+
+	// Pause state
+	paused   bool      // true while playback is frozen on the current frame
+	pausedAt time.Time // when the current pause started
+
+	// Display power
+	displayOff      bool // true while the screen is blanked
+	pausedByDisplay bool // true when turning the display off caused the pause
 
 	// Runtime state
 	currentVideo  int       // index of the currently playing video
@@ -33,8 +50,19 @@ type VideoPlayerScreen struct {
 	perfMonitor            *performance.PerformanceMonitor // tracks decode/render performance
 	frameSkipper           *video.FrameSkipper              // adaptive frame skip logic
 	lastPerfLog            time.Time                       // last time performance was logged
+	lastPresent            time.Time                       // last time a frame was presented, for jank detection
 	lastMemoryLog          time.Time                       // last time memory was logged
 
+	// Last published state, for transition events
+	lastSkipMode      video.SkipMode                  // frame skip mode at the end of the previous frame
+	memoryPressure    performance.MemoryPressureLevel // memory pressure level at the last check
+	lastPressureCheck time.Time                       // last time memory pressure was sampled
+
+	// Thermal sensing; a hot CPU lowers decode cost before it throttles
+	thermal          performance.ThermalSensor   // tracks the thermal level between samples
+	thermalSnapshot  performance.ThermalSnapshot // last thermal sample
+	lastThermalCheck time.Time                   // last time the thermal sensor was sampled
+
 	// Background prefetching bookkeeping
 	prefetchResultCh chan prefetchResult // channel to receive async prefetch results
 	prefetchPending  bool                // true while a prefetch goroutine is running
@@ -44,23 +72,54 @@ type VideoPlayerScreen struct {
 	switchResultCh chan switchResult // channel to receive async switch results
 	switchPending  bool              // true while a collection-switch download is running
 
+	// Background re-listing of the active collection
+	catalogResultCh chan catalogResult // channel to receive async listing results
+	catalogPending  bool               // true while a listing goroutine is running
+	catalogListedAt time.Time          // when the active collection was last listed
+	catalogFailed   bool               // true when the last listing of the active collection failed
+
+	// Cancelled by Close to abort in-flight downloads
+	ctx             context.Context
+	cancelDownloads context.CancelFunc
+
 	// SDL2-specific fields
 	renderer        *sdl.Renderer // SDL2 renderer for video display
 	rightKeyPressed bool          // track right key state to avoid duplicate calls
 }
 
+// queuedVideo is a downloaded file waiting in the playback buffer.
+type queuedVideo struct {
+	path      string
+	key       string          // collection item key; empty for local fallback files
+	temporary bool            // downloaded copy that is deleted after playback
+	cursor    *playlistCursor // playlist position to persist once this video plays; nil for local fallback files
+}
+
+// plannedItem is a playlist pick that has not been downloaded yet.
+type plannedItem struct {
+	item   sharedTypes.CollectionItem
+	cursor playlistCursor
+}
+
 // Struct used to communicate results of background S3 prefetch operations.
 type prefetchResult struct {
-	vids            []string
-	endOfCollection bool
-	err             error
-	collectionIdx   int
+	vids         []queuedVideo
+	rejected     []string // item keys that downloaded but failed probing
+	err          error
+	collectionID string
 }
 
 // Struct used to communicate results of background collection switch downloads.
 type switchResult struct {
-	vids            []string
-	endOfCollection bool
-	err             error
-	collectionIdx   int
+	vids         []queuedVideo
+	playlist     *Playlist
+	err          error
+	collectionID string
+}
+
+// Struct used to communicate results of background catalog reloads.
+type catalogResult struct {
+	items        []sharedTypes.CollectionItem
+	err          error
+	collectionID string
 }
@@ -4,8 +4,13 @@ import (
 	"fmt"
 
 	"github.com/veandco/go-sdl2/ttf"
+
+	"flow-frame/pkg/logging"
 )
 
+// logger is the ui subsystem logger
+var logger = logging.For("ui")
+
 // Fonts manages a set of TrueType fonts at different sizes
 type Fonts struct {
 	Large  *ttf.Font // 32px for card titles
@@ -13,6 +18,14 @@ type Fonts struct {
 	Small  *ttf.Font // 18px for descriptions
 }
 
+// FontPaths are the system fonts tried in order
+var FontPaths = []string{
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf",
+	"/usr/share/fonts/TTF/DejaVuSans-Bold.ttf",
+	"/System/Library/Fonts/Helvetica.ttc",
+	"/usr/share/fonts/truetype/liberation/LiberationSans-Bold.ttf",
+}
+
 // LoadFonts loads system fonts with fallbacks for different platforms
 func LoadFonts() (*Fonts, error) {
 	// Initialize TTF
@@ -20,42 +33,27 @@ func LoadFonts() (*Fonts, error) {
 		return nil, fmt.Errorf("failed to initialize TTF: %v", err)
 	}
 
-	fonts := &Fonts{}
-
-	// Try to load system fonts with fallbacks
-	fontPaths := []string{
-		"/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf",
-		"/usr/share/fonts/TTF/DejaVuSans-Bold.ttf",
-		"/System/Library/Fonts/Helvetica.ttc",
-		"/usr/share/fonts/truetype/liberation/LiberationSans-Bold.ttf",
-	}
-
-	var err error
-	for _, path := range fontPaths {
-		// Large font for card titles
-		fonts.Large, err = ttf.OpenFont(path, 32)
-		if err == nil {
-			break
-		}
+	fonts := &Fonts{
+		Large:  openFont(32), // card titles
+		Medium: openFont(24), // tab titles
+		Small:  openFont(18), // descriptions
 	}
 
-	for _, path := range fontPaths {
-		// Medium font for tab titles
-		fonts.Medium, err = ttf.OpenFont(path, 24)
-		if err == nil {
-			break
-		}
-	}
+	return fonts, nil
+}
 
-	for _, path := range fontPaths {
-		// Small font for descriptions
-		fonts.Small, err = ttf.OpenFont(path, 18)
+// openFont opens the first of FontPaths that loads, nil if none does
+func openFont(size int) *ttf.Font {
+	for _, path := range FontPaths {
+		font, err := ttf.OpenFont(path, size)
 		if err == nil {
-			break
+			logger.Debug("font loaded", "path", path, "size", size)
+			return font
 		}
+		logger.Debug("font unavailable", "path", path, "err", err)
 	}
-
-	return fonts, nil
+	logger.Warn("no font could be loaded, text will not be drawn", "size", size)
+	return nil
 }
 
 // Close cleans up font resources
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/updater"
+	"flow-frame/widgets/settings"
+)
+
+// installedCommand is where the frame is installed; the update command turns it
+// into a link to the current slot
+const installedCommand = "/usr/local/bin/flow-frame"
+
+// frameUnit is the systemd unit of the frame
+const frameUnit = "flow-frame.service"
+
+// runUpdate installs the latest signed release into the inactive slot
+func runUpdate(args []string) int {
+	fs := newFlagSet("update")
+	check := fs.Bool("check", false, "only report whether an update is available")
+	manifestURL := fs.String("manifest", os.Getenv("FLOW_FRAME_UPDATE_URL"), "release manifest URL")
+	link := fs.String("link", installedCommand, "installed command to link to the current slot, empty to leave it alone")
+	channel := fs.String("channel", "", "release channel to follow (default: the Update Channel setting)")
+	now := fs.Bool("now", false, "install outside the maintenance window even if the frame is on screen")
+	if fs.Parse(args) != nil || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *channel == "" {
+		// Read only: the updater runs as root and must not take over the settings file
+		*channel = settings.DefaultStore().Read().UpdateChannel
+	}
+	if !slices.Contains(updater.Channels, *channel) {
+		fmt.Fprintf(os.Stderr, "flow-frame: unknown channel %q (want %s)\n", *channel, strings.Join(updater.Channels, ", "))
+		return 2
+	}
+	window, err := updater.ParseWindow(os.Getenv("FLOW_FRAME_UPDATE_WINDOW"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 2
+	}
+
+	keys, err := updater.ParseKeys(os.Getenv("FLOW_FRAME_UPDATE_KEYS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 2
+	}
+	if len(keys) == 0 {
+		fmt.Fprintln(os.Stderr, "flow-frame: no release keys configured; set update-keys (FLOW_FRAME_UPDATE_KEYS)")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	u := &updater.Updater{
+		ManifestURL:    *manifestURL,
+		Keys:           keys,
+		Slots:          updater.NewSlots(),
+		Link:           *link,
+		CurrentVersion: currentVersion(),
+		Channel:        *channel,
+		DeviceID:       updater.DeviceID(),
+		Window:         window,
+		Busy:           frameRunning,
+		Force:          *now,
+	}
+
+	if *check {
+		m, err := u.FetchManifest(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+			return 1
+		}
+		st, err := u.Slots.State()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+			return 1
+		}
+		installed := st.Slots[st.Active]
+		if installed == "" {
+			installed = u.CurrentVersion
+		}
+		fmt.Printf("Installed: %s\nAvailable: %s on %s (signature OK)\n", installed, m.Version, m.ChannelName())
+		if newer, _ := updater.Newer(m.Version, installed); newer && !updater.InRollout(u.DeviceID, m.Version, m.Rollout) {
+			fmt.Printf("Rollout:   %d%% of frames, not yet this one\n", m.Rollout)
+		}
+		fmt.Printf("Window:    %s\n", window)
+		if st.Trial != nil {
+			fmt.Printf("On trial:  %s in slot %s, %d start(s)\n", st.Trial.Version, st.Trial.Slot, st.Trial.Boots)
+		}
+		return 0
+	}
+
+	// A check requested at the frame is restarted by the frame itself
+	requested := u.Slots.Requested()
+	res, err := u.Run(ctx)
+	switch {
+	case errors.Is(err, updater.ErrTrialPending):
+		fmt.Printf("Not updating: %v\n", err)
+		return 0
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "flow-frame: update failed: %v\n", err)
+		return 1
+	case res.Updated && !requested && frameRunning():
+		fmt.Printf("Installed %s into slot %s (was %s); restarting the frame\n", res.Version, res.Slot, res.Installed)
+		if err := exec.Command("systemctl", "restart", frameUnit).Run(); err != nil {
+			log.Printf("Warning: Failed to restart %s: %v", frameUnit, err)
+		}
+	case res.Updated:
+		fmt.Printf("Installed %s into slot %s (was %s); it starts on trial with the next restart\n", res.Version, res.Slot, res.Installed)
+	case res.Deferred != "":
+		fmt.Printf("Not updating: %s\n", res.Deferred)
+	default:
+		fmt.Printf("Up to date (%s)\n", res.Installed)
+	}
+	return 0
+}
+
+// currentVersion is the version of this binary. Builds made before versions
+// were embedded only know theirs from APP_VERSION in the environment file.
+func currentVersion() string {
+	if info := buildinfo.Get(); info.Released() {
+		return info.Version
+	}
+	return os.Getenv("APP_VERSION")
+}
+
+// frameRunning reports whether the frame is on screen. At boot the updater runs
+// before the frame starts, so releases install right away.
+func frameRunning() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", frameUnit).Run() == nil
+}
+
+// runSign writes a signed release manifest for a binary, or creates a release key
+func runSign(args []string) int {
+	fs := newFlagSet("sign")
+	keyFile := fs.String("key", "", "file holding the base64 private release key")
+	version := fs.String("version", "", "release version")
+	url := fs.String("url", "", "URL the binary is downloaded from")
+	channel := fs.String("channel", updater.DefaultChannel, "release channel: "+strings.Join(updater.Channels, ", "))
+	rollout := fs.Int("rollout", 0, "percentage of frames offered the release, 0 for all")
+	genkey := fs.String("genkey", "", "create a new release key in this file and print its public key")
+	if fs.Parse(args) != nil {
+		fs.Usage()
+		return 2
+	}
+
+	if *genkey != "" {
+		private, public, err := updater.GenerateKey()
+		if err == nil {
+			err = os.WriteFile(*genkey, []byte(private+"\n"), 0o600)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Public key for update-keys: %s\n", public)
+		return 0
+	}
+
+	if *keyFile == "" || *version == "" || *url == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	data, err := os.ReadFile(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	key, err := updater.ParsePrivateKey(string(data))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+
+	m := updater.Manifest{Version: *version, URL: *url, SHA256: hex.EncodeToString(h.Sum(nil)), Size: size, Channel: *channel, Rollout: *rollout}
+	m.Sign(key)
+	if err := m.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "flow-frame: %v\n", err)
+		return 1
+	}
+	return printJSON(m)
+}
@@ -120,9 +120,14 @@ build_binary() {
         echo ""
     fi
 
-    # Build the binary with proper environment variables
+    # Build the binary with proper environment variables, embedding the version
     # Note: CGO is required for SDL2, so we don't disable it
-    GOOS="${BUILD_GOOS}" GOARCH="${BUILD_GOARCH}" go build -o "${BINARY_NAME}" .
+    local pkg="flow-frame/pkg/buildinfo"
+    local commit=$(git rev-parse --short HEAD 2>/dev/null || echo "")
+    local date=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+    GOOS="${BUILD_GOOS}" GOARCH="${BUILD_GOARCH}" go build \
+        -ldflags "-X ${pkg}.Version=${version} -X ${pkg}.Commit=${commit} -X ${pkg}.Date=${date}" \
+        -o "${BINARY_NAME}" .
 
     if [ ! -f "${BINARY_NAME}" ]; then
         print_error "Error: Build failed, binary not found"
@@ -155,6 +160,29 @@ upload_to_s3() {
     fi
 }
 
+# Sign the release and upload its manifest. Skipped unless RELEASE_SIGNING_KEY
+# names a key file created with `flow-frame sign -genkey <file>`.
+# RELEASE_CHANNEL (stable, beta or dev) and RELEASE_ROLLOUT (percentage of
+# frames, default all) are signed into the manifest.
+upload_manifest() {
+    local version=$1
+    local manifest_path="s3://${BUCKET_NAME}/${version}/manifest.json"
+
+    if [ -z "${RELEASE_SIGNING_KEY:-}" ]; then
+        print_warn "RELEASE_SIGNING_KEY not set - skipping signed manifest; frames will not install this release"
+        return
+    fi
+
+    print_info "Signing release ${version}..."
+    "./${BINARY_NAME}" sign -key "${RELEASE_SIGNING_KEY}" -version "${version}" \
+        -channel "${RELEASE_CHANNEL:-stable}" -rollout "${RELEASE_ROLLOUT:-0}" \
+        -url "https://${BUCKET_NAME}.s3.amazonaws.com/${version}/${BINARY_NAME}" \
+        "${BINARY_NAME}" > manifest.json
+    aws s3 cp manifest.json "${manifest_path}"
+    rm -f manifest.json
+    print_info "Manifest available at: ${manifest_path}"
+}
+
 # Clean up build artifacts
 cleanup() {
     if [ -f "${BINARY_NAME}" ]; then
@@ -204,6 +232,7 @@ main() {
 
     # Upload to S3
     upload_to_s3 "${NEXT_VERSION}"
+    upload_manifest "${NEXT_VERSION}"
     echo ""
 
     # Cleanup (only if we built the binary)
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"flow-frame/pkg/buildinfo"
+	"flow-frame/pkg/sysinfo"
+	"flow-frame/pkg/video"
+)
+
+// versionReport is the output of the version command
+type versionReport struct {
+	buildinfo.Info
+	Device   string                    `json:"device"`
+	Decoders []video.DecoderCapability `json:"decoders"`
+}
+
+// runVersion prints the build version, device model and decoder capabilities
+func runVersion(args []string) int {
+	fs := newFlagSet("version")
+	jsonOut := fs.Bool("json", false, "print JSON")
+	if fs.Parse(args) != nil || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	report := versionReport{Info: buildinfo.Get(), Device: sysinfo.Model(), Decoders: video.DecoderCapabilities()}
+	if *jsonOut {
+		return printJSON(report)
+	}
+	fmt.Printf("flow-frame %s\n", report.Info)
+	fmt.Printf("Go:        %s\n", report.GoVersion)
+	fmt.Printf("Device:    %s\n", report.Device)
+	fmt.Println("Decoders:")
+	for _, d := range report.Decoders {
+		fmt.Printf("  %s\n", d)
+	}
+	return 0
+}
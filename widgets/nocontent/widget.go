@@ -0,0 +1,65 @@
+package nocontent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"flow-frame/ui"
+)
+
+// Widget is the built-in screen shown while there is nothing to play, with
+// instructions for adding content
+type Widget struct{}
+
+// NewWidget creates a new no content widget
+func NewWidget() *Widget {
+	return &Widget{}
+}
+
+// Render draws the setup instructions. libraryDirs are the watched folders and
+// problem is the most recent reason nothing could be played, if any.
+func (w *Widget) Render(renderer *sdl.Renderer, windowWidth, windowHeight int32, fonts *ui.Fonts, libraryDirs []string, problem string) error {
+	// Same palette as the settings overlay
+	renderer.SetDrawColor(15, 23, 42, 255)
+	renderer.FillRect(&sdl.Rect{X: 0, Y: 0, W: windowWidth, H: windowHeight})
+
+	whiteColor := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	grayColor := sdl.Color{R: 148, G: 163, B: 184, A: 255}
+	amberColor := sdl.Color{R: 251, G: 191, B: 36, A: 255}
+
+	x := windowWidth / 8
+	currentY := windowHeight / 4
+
+	if err := ui.RenderText(renderer, "No content yet", x, currentY, whiteColor, fonts.Large); err != nil {
+		return fmt.Errorf("failed to render title: %w", err)
+	}
+	currentY += 70
+
+	steps := []string{
+		"Connect to Wi-Fi: press Down and open Settings, or scan the QR code when it appears",
+		"Plug in a USB drive with videos, or copy videos to " + strings.Join(libraryDirs, " or "),
+		"Cloud collections also need AWS credentials in the frame's config file",
+	}
+	for i, step := range steps {
+		text := fmt.Sprintf("%d. %s", i+1, step)
+		if err := ui.RenderText(renderer, text, x, currentY, grayColor, fonts.Medium); err != nil {
+			return fmt.Errorf("failed to render instructions: %w", err)
+		}
+		currentY += 45
+	}
+	currentY += 20
+
+	if err := ui.RenderText(renderer, "Playback starts automatically once a video is available.", x, currentY, whiteColor, fonts.Small); err != nil {
+		return fmt.Errorf("failed to render hint: %w", err)
+	}
+	currentY += 35
+
+	if problem != "" {
+		if err := ui.RenderText(renderer, "Last problem: "+problem, x, currentY, amberColor, fonts.Small); err != nil {
+			return fmt.Errorf("failed to render problem: %w", err)
+		}
+	}
+	return nil
+}
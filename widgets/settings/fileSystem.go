@@ -2,57 +2,242 @@ package settings
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"sync"
+
+	"flow-frame/pkg/appdata"
 )
 
-var defaultSettings = Settings{
-	PlaybackSpeed:    1.0,
-	PlaybackInterval: "Every hour",
+var defaultSettings = Defaults()
+
+// Filename is the name of the settings file inside the data directory
+const Filename = "settings.json"
+
+// legacyFiles are the working-directory relative locations used before the
+// settings moved into the data directory. They are read once and migrated.
+var legacyFiles = []string{"../../settings.json", "settings.json"}
+
+// Store reads and writes settings at a fixed path
+type Store struct {
+	path string
+	mu   sync.Mutex // serialises Save calls
 }
 
-const filename = "../../settings.json"
+// NewStore creates a store for the settings file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the location of the settings file
+func (st *Store) Path() string {
+	return st.path
+}
+
+var (
+	defaultStoreOnce sync.Once
+	defaultStore     *Store
+)
 
-// Load reads the settings file from disk. When the file is missing or cannot
-// be parsed, sane defaults are returned instead so the application can
-// continue running.
+// DefaultStore returns the store inside the application data directory
+func DefaultStore() *Store {
+	defaultStoreOnce.Do(func() {
+		defaultStore = NewStore(appdata.Path(Filename))
+	})
+	return defaultStore
+}
+
+// Load reads the settings from the default store
 func Load() Settings {
-	f, err := os.Open(filename)
+	return DefaultStore().Load()
+}
+
+// Save writes the settings to the default store
+func Save(s Settings) error {
+	return DefaultStore().Save(s)
+}
+
+// Read returns the settings on disk, migrated in memory only, or the defaults.
+// Unlike Load it never writes, so other users such as the updater running as
+// root can read the frame's settings without taking over the file.
+func (st *Store) Read() Settings {
+	data, err := os.ReadFile(st.path)
 	if err != nil {
-		// No existing file – return defaults.
 		return defaultSettings
 	}
-	defer f.Close()
-
-	var s Settings
-	if err := json.NewDecoder(f).Decode(&s); err != nil {
-		// Malformed file – fall back to defaults.
+	s, _, err := decodeSettings(data)
+	if err != nil {
 		return defaultSettings
 	}
+	return s
+}
 
-	// Ensure zero-values are replaced by defaults so that partially written
-	// configuration files do not break behaviour when new fields are added.
-	if s.PlaybackSpeed == 0 {
-		s.PlaybackSpeed = defaultSettings.PlaybackSpeed
+// Load reads the settings file from disk and migrates it to the current
+// schema. When the file is missing or cannot be parsed, sane defaults are
+// returned instead so the application can continue running.
+func (st *Store) Load() Settings {
+	data, err := os.ReadFile(st.path)
+	if os.IsNotExist(err) {
+		return st.importLegacy()
 	}
-	if s.PlaybackInterval == "" {
-		s.PlaybackInterval = defaultSettings.PlaybackInterval
+	if err != nil {
+		log.Printf("settings: failed to read %s: %v", st.path, err)
+		return defaultSettings
 	}
 
+	s, migrated, err := decodeSettings(data)
+	if err != nil {
+		// Malformed file – fall back to defaults.
+		log.Printf("settings: ignoring unreadable %s: %v", st.path, err)
+		return defaultSettings
+	}
+	if s.SchemaVersion > CurrentSchemaVersion {
+		// E.g. after rolling back an update; Save keeps a copy before replacing it
+		log.Printf("settings: %s has schema version %d, newer than %d; reading the known fields only",
+			st.path, s.SchemaVersion, CurrentSchemaVersion)
+	}
+	if migrated {
+		if err := st.Save(s); err != nil {
+			log.Printf("settings: failed to store migrated settings: %v", err)
+		}
+	}
 	return s
 }
 
+// importLegacy loads settings from a pre data-directory location, if any,
+// and writes them to the store
+func (st *Store) importLegacy() Settings {
+	for _, legacy := range legacyFiles {
+		abs, err := filepath.Abs(legacy)
+		if err != nil || abs == st.path {
+			continue
+		}
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		s, _, err := decodeSettings(data)
+		if err != nil {
+			log.Printf("settings: ignoring unreadable legacy file %s: %v", abs, err)
+			continue
+		}
+		log.Printf("settings: importing legacy settings from %s", abs)
+		if err := st.Save(s); err != nil {
+			log.Printf("settings: failed to store imported settings: %v", err)
+		}
+		return s
+	}
+
+	// No existing file – return defaults.
+	return defaultSettings
+}
+
 // Save writes the provided settings atomically to disk, creating the file when
-// necessary. Any error is returned to the caller so it can be logged.
-func Save(s Settings) error {
-	// Create will truncate an existing file or create a new one with
-	// owner-only permissions.
-	f, err := os.Create(filename)
+// necessary. A file written by a newer build is first copied to <file>.v<N>,
+// since this build would drop the fields it does not know. Any error is
+// returned to the caller so it can be logged.
+func (st *Store) Save(s Settings) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if err := st.backupNewer(); err != nil {
+		return err
+	}
+
+	s.SchemaVersion = CurrentSchemaVersion
+	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	return appdata.WriteFileAtomic(st.path, append(data, '\n'), 0o600)
+}
+
+// backupNewer copies the settings file to <file>.v<N> when its schema version N
+// is newer than CurrentSchemaVersion and no such copy exists yet
+func (st *Store) backupNewer() error {
+	data, err := os.ReadFile(st.path)
+	if err != nil {
+		return nil // nothing to preserve
+	}
+	var head struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if json.Unmarshal(data, &head) != nil || head.SchemaVersion <= CurrentSchemaVersion {
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d", st.path, head.SchemaVersion)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	if err := appdata.WriteFileAtomic(backup, data, 0o600); err != nil {
+		return fmt.Errorf("back up schema %d settings: %w", head.SchemaVersion, err)
+	}
+	log.Printf("settings: kept schema %d settings as %s", head.SchemaVersion, backup)
+	return nil
+}
+
+// Reset moves the settings file aside to <file>.bak and replaces it with the defaults
+func Reset() (Settings, error) {
+	return DefaultStore().Reset()
+}
 
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	return enc.Encode(s)
+// Reset moves the settings file aside to <file>.bak and replaces it with the defaults
+func (st *Store) Reset() (Settings, error) {
+	st.mu.Lock()
+	err := os.Rename(st.path, st.path+".bak")
+	st.mu.Unlock()
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("settings: failed to back up %s: %v", st.path, err)
+	}
+	return defaultSettings, st.Save(defaultSettings)
+}
+
+// decodeSettings parses a settings file of any schema version, applies the
+// pending migrations and decodes the result on top of the defaults so that
+// fields introduced later keep their default value. migrated reports whether
+// the file was on an older schema or held invalid values. A file from a newer
+// build is read best-effort: fields this build knows are kept, the rest are
+// ignored, and s.SchemaVersion reports its version. Negative versions are rejected.
+func decodeSettings(data []byte) (s Settings, migrated bool, err error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return defaultSettings, false, err
+	}
+	if raw == nil {
+		return defaultSettings, false, fmt.Errorf("settings file is not an object")
+	}
+
+	version := 0
+	if v, ok := raw["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+	if version < 0 {
+		return defaultSettings, false, fmt.Errorf("invalid schema version %d", version)
+	}
+	newer := version > CurrentSchemaVersion
+
+	for ; version < CurrentSchemaVersion; version++ {
+		migrations[version](raw)
+		migrated = true
+	}
+	if !newer {
+		raw["schemaVersion"] = CurrentSchemaVersion
+	}
+
+	normalised, err := json.Marshal(raw)
+	if err != nil {
+		return defaultSettings, false, err
+	}
+	s = defaultSettings
+	if err := json.Unmarshal(normalised, &s); err != nil {
+		return defaultSettings, false, err
+	}
+	// A newer build may accept values this one corrects; never rewrite its file on load
+	if Validate(&s) && !newer {
+		migrated = true // rewrite the file with the corrected values
+	}
+	return s, migrated, nil
 }
@@ -1,102 +1,66 @@
 package settings
 
-import (
-	"fmt"
-	"math"
-	"strconv"
-	"strings"
-)
+import "strings"
 
-var (
-	SpeedOptions    = []string{"0.2x", "0.5x", "0.8x", "1x", "2x", "3x"}
-	IntervalOptions = []string{"Every minute", "Every hour", "Every 12 hours", "Every day", "Every week"}
-)
-
-// BuildMainMenuItems creates the main settings menu items
-func BuildMainMenuItems(currentSpeed float64, currentInterval string) []Item {
-	return []Item{
-		{
-			Title: "Playback Speed",
-			Value: fmt.Sprintf("%.1fx", currentSpeed),
-		},
-		{
-			Title: "Playback Interval",
-			Value: currentInterval,
-		},
-		{
-			Title: "System Settings",
-			Value: "Configure system options",
-		},
-	}
-}
-
-// BuildSpeedMenuItems creates the speed selection menu items
-func BuildSpeedMenuItems(currentSpeed float64) []Item {
-	const eps = 0.0001
-	items := make([]Item, len(SpeedOptions))
-
-	for i, opt := range SpeedOptions {
-		isCurrent := false
-		if strings.HasSuffix(opt, "x") {
-			if v, err := strconv.ParseFloat(strings.TrimSuffix(opt, "x"), 64); err == nil {
-				if math.Abs(v-currentSpeed) < eps {
-					isCurrent = true
-				}
-			}
-		}
-
-		title := opt
-		if isCurrent {
-			title = "✓ " + opt
-		}
-
-		items[i] = Item{Title: title, Value: ""}
-	}
-
-	// Add back option
-	items = append(items, Item{Title: "Back", Value: ""})
-	return items
+// BackItem returns the menu entry that returns to the parent menu
+func BackItem() Item {
+	return Item{Title: "Back", Action: ActionBack}
 }
 
-// BuildIntervalMenuItems creates the interval selection menu items
-func BuildIntervalMenuItems(currentInterval string) []Item {
-	items := make([]Item, len(IntervalOptions))
-
-	for i, opt := range IntervalOptions {
-		title := opt
-		if opt == currentInterval {
-			title = "✓ " + opt
-		}
-		items[i] = Item{Title: title, Value: ""}
+// BuildMainMenuItems creates the main settings menu items: one entry per
+// registered setting followed by the system settings submenu
+func BuildMainMenuItems(s Settings) []Item {
+	items := make([]Item, 0, len(Registry)+1)
+	for _, setting := range Registry {
+		items = append(items, Item{
+			Title: setting.Label(),
+			Value: setting.ValueLabel(s),
+			Menu:  setting.Menu(),
+		})
 	}
-
-	// Add back option
-	items = append(items, Item{Title: "Back", Value: ""})
-	return items
+	return append(items, Item{
+		Title: "System Settings",
+		Value: "Configure system options",
+		Menu:  SystemMenu,
+	})
 }
 
 // BuildSystemMenuItems creates the system settings menu items
 func BuildSystemMenuItems() []Item {
 	return []Item{
-		{Title: "WiFi Networks", Value: "Connect to a WiFi network"},
-		{Title: "Restart and check for updates", Value: "Restart the flow-frame service"},
-		{Title: "Back", Value: ""},
+		{Title: "WiFi Networks", Value: "Connect to a WiFi network", Menu: WiFiMenu},
+		{Title: "About", Value: "Version and device information", Menu: AboutMenu},
+		{Title: "Generate diagnostics", Value: "Save logs and device state to a USB drive", Action: ActionDiagnostics},
+		{Title: "Restart and check for updates", Value: "Install the latest release, then restart", Action: ActionRestart},
+		BackItem(),
 	}
 }
 
-// ParseSpeedFromLabel extracts the speed value from a label string
-func ParseSpeedFromLabel(label string) (float64, error) {
-	cleanLabel := strings.TrimPrefix(label, "✓ ")
-	if !strings.HasSuffix(cleanLabel, "x") {
-		return 0, fmt.Errorf("invalid speed label format")
-	}
-
-	return strconv.ParseFloat(strings.TrimSuffix(cleanLabel, "x"), 64)
+// AboutInfo is what the About menu shows
+type AboutInfo struct {
+	Version          string
+	Build            string // commit, build date and toolchain
+	Device           string
+	Uptime           string
+	HardwareDecoders []string
+	SoftwareDecoders []string // codecs with a software decoder
 }
 
-// CleanIntervalLabel removes the checkmark from an interval label
-func CleanIntervalLabel(label string) string {
-	return strings.TrimPrefix(label, "✓ ")
+// BuildAboutMenuItems creates the informational rows of the About menu
+func BuildAboutMenuItems(info AboutInfo) []Item {
+	hardware := "None, videos are decoded in software"
+	if len(info.HardwareDecoders) > 0 {
+		hardware = strings.Join(info.HardwareDecoders, ", ")
+	}
+	return []Item{
+		{Title: "Version", Value: info.Version, Action: ActionInfo},
+		{Title: "Build", Value: info.Build, Action: ActionInfo},
+		{Title: "Device", Value: info.Device, Action: ActionInfo},
+		{Title: "Uptime", Value: info.Uptime, Action: ActionInfo},
+		{Title: "Hardware decoders", Value: hardware, Action: ActionInfo},
+		{Title: "Software decoders", Value: strings.Join(info.SoftwareDecoders, ", "), Action: ActionInfo},
+		BackItem(),
+	}
 }
 
 // BuildWiFiMenuItems creates the WiFi networks menu items
@@ -104,19 +68,19 @@ func BuildWiFiMenuItems() []Item {
 	networks, err := ScanWiFiNetworks()
 	if err != nil {
 		return []Item{
-			{Title: "Error scanning networks", Value: err.Error()},
-			{Title: "Back", Value: ""},
+			{Title: "Error scanning networks", Value: err.Error(), Action: ActionInfo},
+			BackItem(),
 		}
 	}
 
 	if len(networks) == 0 {
 		return []Item{
-			{Title: "No networks found", Value: ""},
-			{Title: "Back", Value: ""},
+			{Title: "No networks found", Action: ActionInfo},
+			BackItem(),
 		}
 	}
 
 	// Add back option to the networks list
-	networks = append(networks, Item{Title: "Back", Value: ""})
+	networks = append(networks, BackItem())
 	return networks
 }
@@ -0,0 +1,48 @@
+package settings
+
+// CurrentSchemaVersion is the settings schema written by this build. Bump it
+// and append a migration whenever a field is renamed, retyped or needs a
+// default that differs from its zero value in existing files.
+const CurrentSchemaVersion = 2
+
+// migrations[n] upgrades a raw settings object from schema n to n+1. Keys that
+// are absent after migrating take their value from defaultSettings.
+var migrations = []func(raw map[string]any){
+	migrateV0ToV1,
+	migrateV1ToV2,
+}
+
+// migrateV0ToV1 upgrades unversioned files, which could contain zero values
+// for fields that were written before they had a default
+func migrateV0ToV1(raw map[string]any) {
+	if v, ok := raw["playbackSpeed"].(float64); ok && v <= 0 {
+		delete(raw, "playbackSpeed")
+	}
+	for _, key := range []string{"playbackInterval", "playbackOrder"} {
+		if v, ok := raw[key].(string); ok && v == "" {
+			delete(raw, key)
+		}
+	}
+}
+
+// legacyIntervals maps the interval labels stored before schema 2 to durations
+var legacyIntervals = map[string]string{
+	"Every minute":   "1m",
+	"Every hour":     "1h",
+	"Every 12 hours": "12h",
+	"Every day":      "24h",
+	"Every week":     "168h",
+}
+
+// migrateV1ToV2 converts the playback interval from a menu label to a duration
+func migrateV1ToV2(raw map[string]any) {
+	label, ok := raw["playbackInterval"].(string)
+	if !ok {
+		return
+	}
+	if d, ok := legacyIntervals[label]; ok {
+		raw["playbackInterval"] = d
+	} else {
+		delete(raw, "playbackInterval")
+	}
+}
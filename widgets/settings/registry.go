@@ -0,0 +1,424 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"flow-frame/pkg/sharedTypes"
+)
+
+// Setting is a user-tunable option that appears in the settings menu. The
+// menu, its selection handling, validation on load and saving are all
+// generated from the registered settings.
+type Setting interface {
+	// Key is the persistence key of the setting in settings.json
+	Key() string
+	// Label is the menu title of the setting
+	Label() string
+	// Menu is the menu type of the setting's choice list
+	Menu() MenuType
+	// ValueLabel describes the current value of s
+	ValueLabel(s Settings) string
+	// MenuItems lists the choices with the current one checked, then any
+	// custom value steppers, followed by Back
+	MenuItems(s Settings) []Item
+	// Select applies the choice at index to s
+	Select(s *Settings, index int) error
+	// Stepper returns the numeric stepper behind the menu item at index and
+	// the value it starts from
+	Stepper(s Settings, index int) (Stepper, float64, bool)
+	// SetCustom applies a value entered with the stepper at index to s
+	SetCustom(s *Settings, index int, v float64) error
+	// Validate resets an invalid value in s to the default and reports whether it did
+	Validate(s *Settings) bool
+	// applyDefault stores the default value in s
+	applyDefault(s *Settings)
+}
+
+// Choice is one allowed value of a setting
+type Choice[T comparable] struct {
+	Label string
+	Value T
+}
+
+// Stepper describes a custom numeric value entered with left/right steps
+type Stepper struct {
+	Title     string
+	Min, Max  float64
+	Increment func(v float64) float64 // step size at v, so large values can move faster
+	Format    func(v float64) string
+}
+
+// Step moves v by one increment in direction dir (-1 or 1), clamped to the range
+func (st Stepper) Step(v float64, dir int) float64 {
+	inc := st.Increment(v)
+	if dir < 0 {
+		// Use the increment of the range we are stepping down into
+		inc = st.Increment(v - inc)
+	}
+	v += float64(dir) * inc
+	v = math.Round(v/inc) * inc // stay on the step grid, avoiding float drift
+	return math.Max(st.Min, math.Min(st.Max, v))
+}
+
+// custom is a stepper that produces values of a setting
+type custom[T comparable] struct {
+	Stepper
+	from func(v T) (float64, bool) // numeric form of v if this stepper can express it
+	to   func(v float64) T
+}
+
+// choiceSetting is a setting whose value is picked from a fixed list or
+// entered with one of its steppers
+type choiceSetting[T comparable] struct {
+	key      string
+	label    string
+	choices  []Choice[T]
+	custom   []custom[T]
+	fallback T
+	field    func(s *Settings) *T
+	valid    func(v T) bool   // optional; accepts values outside choices (e.g. hand-edited files)
+	format   func(v T) string // optional; labels values outside choices
+}
+
+func (c *choiceSetting[T]) Key() string    { return c.key }
+func (c *choiceSetting[T]) Label() string  { return c.label }
+func (c *choiceSetting[T]) Menu() MenuType { return MenuType("setting:" + c.key) }
+
+func (c *choiceSetting[T]) ValueLabel(s Settings) string {
+	v := *c.field(&s)
+	for _, choice := range c.choices {
+		if choice.Value == v {
+			return choice.Label
+		}
+	}
+	if c.format != nil {
+		return c.format(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func (c *choiceSetting[T]) MenuItems(s Settings) []Item {
+	current := *c.field(&s)
+	items := make([]Item, 0, len(c.choices)+len(c.custom)+1)
+	for _, choice := range c.choices {
+		title := choice.Label
+		if choice.Value == current {
+			title = "✓ " + title
+		}
+		items = append(items, Item{Title: title})
+	}
+	for _, cs := range c.custom {
+		item := Item{Title: cs.Title, Action: ActionStepper}
+		if v, ok := cs.from(current); ok && !c.isChoice(current) {
+			item.Title = "✓ " + item.Title
+			item.Value = cs.Format(v)
+		}
+		items = append(items, item)
+	}
+	return append(items, BackItem())
+}
+
+func (c *choiceSetting[T]) Stepper(s Settings, index int) (Stepper, float64, bool) {
+	i := index - len(c.choices)
+	if i < 0 || i >= len(c.custom) {
+		return Stepper{}, 0, false
+	}
+	st := c.custom[i]
+	start, ok := st.from(*c.field(&s))
+	if !ok {
+		start = st.Min
+	}
+	return st.Stepper, start, true
+}
+
+func (c *choiceSetting[T]) SetCustom(s *Settings, index int, v float64) error {
+	i := index - len(c.choices)
+	if i < 0 || i >= len(c.custom) {
+		return fmt.Errorf("%s: no stepper at index %d", c.key, index)
+	}
+	st := c.custom[i]
+	if v < st.Min || v > st.Max {
+		return fmt.Errorf("%s: %v outside %v..%v", c.key, v, st.Min, st.Max)
+	}
+	*c.field(s) = st.to(v)
+	return nil
+}
+
+func (c *choiceSetting[T]) Select(s *Settings, index int) error {
+	if index < 0 || index >= len(c.choices) {
+		return fmt.Errorf("%s: no choice at index %d", c.key, index)
+	}
+	*c.field(s) = c.choices[index].Value
+	return nil
+}
+
+func (c *choiceSetting[T]) Validate(s *Settings) bool {
+	v := c.field(s)
+	if c.allowed(*v) {
+		return false
+	}
+	log.Printf("settings: invalid %s %v, using default %v", c.key, *v, c.fallback)
+	*v = c.fallback
+	return true
+}
+
+func (c *choiceSetting[T]) applyDefault(s *Settings) {
+	*c.field(s) = c.fallback
+}
+
+// isChoice reports whether v is one of the fixed choices
+func (c *choiceSetting[T]) isChoice(v T) bool {
+	for _, choice := range c.choices {
+		if choice.Value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether v is one of the choices or passes the custom check
+func (c *choiceSetting[T]) allowed(v T) bool {
+	return c.isChoice(v) || (c.valid != nil && c.valid(v))
+}
+
+// Registry lists the settings shown in the main menu, in display order.
+// Adding a setting is a single declaration here plus its Settings field.
+var Registry = []Setting{
+	&choiceSetting[float64]{
+		key:   "playbackSpeed",
+		label: "Playback Speed",
+		choices: []Choice[float64]{
+			{"0.2x", 0.2}, {"0.5x", 0.5}, {"0.8x", 0.8}, {"1x", 1}, {"2x", 2}, {"3x", 3},
+		},
+		custom: []custom[float64]{{
+			Stepper: Stepper{
+				Title:     "Custom speed",
+				Min:       MinPlaybackSpeed,
+				Max:       MaxPlaybackSpeed,
+				Increment: func(float64) float64 { return 0.05 },
+				Format:    formatSpeed,
+			},
+			from: func(v float64) (float64, bool) { return v, true },
+			to:   func(v float64) float64 { return v },
+		}},
+		fallback: 1.0,
+		field:    func(s *Settings) *float64 { return &s.PlaybackSpeed },
+		valid:    func(v float64) bool { return v >= MinPlaybackSpeed && v <= MaxPlaybackSpeed },
+		format:   formatSpeed,
+	},
+	&choiceSetting[sharedTypes.Interval]{
+		key:   "playbackInterval",
+		label: "Playback Interval",
+		choices: []Choice[sharedTypes.Interval]{
+			{"Every minute", sharedTypes.Every(time.Minute)},
+			{"Every hour", sharedTypes.Every(time.Hour)},
+			{"Every 12 hours", sharedTypes.Every(12 * time.Hour)},
+			{"Every day", sharedTypes.Every(24 * time.Hour)},
+			{"Every week", sharedTypes.Every(7 * 24 * time.Hour)},
+			{"On video end", sharedTypes.OnVideoEnd()},
+		},
+		custom: []custom[sharedTypes.Interval]{
+			{
+				Stepper: Stepper{
+					Title:     "Custom duration",
+					Min:       1,
+					Max:       7 * 24 * 60,
+					Increment: minuteIncrement,
+					Format: func(v float64) string {
+						return sharedTypes.Every(time.Duration(v) * time.Minute).Label()
+					},
+				},
+				from: func(v sharedTypes.Interval) (float64, bool) {
+					if v.Mode != sharedTypes.IntervalDuration {
+						return 0, false
+					}
+					return math.Round(v.Duration.Minutes()), true
+				},
+				to: func(v float64) sharedTypes.Interval {
+					return sharedTypes.Every(time.Duration(v) * time.Minute)
+				},
+			},
+			{
+				Stepper: Stepper{
+					Title:     "Custom loop count",
+					Min:       1,
+					Max:       100,
+					Increment: func(float64) float64 { return 1 },
+					Format: func(v float64) string {
+						return sharedTypes.AfterLoops(int(v)).Label()
+					},
+				},
+				from: func(v sharedTypes.Interval) (float64, bool) {
+					if v.Mode != sharedTypes.IntervalLoops {
+						return 0, false
+					}
+					return float64(v.Loops), true
+				},
+				to: func(v float64) sharedTypes.Interval {
+					return sharedTypes.AfterLoops(int(v))
+				},
+			},
+		},
+		fallback: sharedTypes.Every(time.Hour),
+		field:    func(s *Settings) *sharedTypes.Interval { return &s.PlaybackInterval },
+		valid:    sharedTypes.Interval.Valid,
+		format:   sharedTypes.Interval.Label,
+	},
+	&choiceSetting[string]{
+		key:   "playbackOrder",
+		label: "Playback Order",
+		choices: []Choice[string]{
+			{"Sequential", "sequential"},
+			{"Shuffle", "shuffle"},
+			{"Weighted shuffle", "weighted"},
+		},
+		fallback: "sequential",
+		field:    func(s *Settings) *string { return &s.PlaybackOrder },
+	},
+	&choiceSetting[int]{
+		key:   "avoidRepeat",
+		label: "Avoid Repeats",
+		choices: []Choice[int]{
+			{"Off", 0}, {"Last 3 videos", 3}, {"Last 5 videos", 5}, {"Last 10 videos", 10},
+		},
+		custom: []custom[int]{{
+			Stepper: Stepper{
+				Title:     "Custom count",
+				Min:       0,
+				Max:       MaxAvoidRepeat,
+				Increment: func(float64) float64 { return 1 },
+				Format:    func(v float64) string { return formatAvoidRepeat(int(v)) },
+			},
+			from: func(v int) (float64, bool) { return float64(v), true },
+			to:   func(v float64) int { return int(v) },
+		}},
+		fallback: 3,
+		field:    func(s *Settings) *int { return &s.AvoidRepeat },
+		valid:    func(v int) bool { return v >= 0 && v <= MaxAvoidRepeat },
+		format:   formatAvoidRepeat,
+	},
+	&choiceSetting[float64]{
+		key:   "brightness",
+		label: "Brightness",
+		choices: []Choice[float64]{
+			{"100%", 1}, {"75%", 0.75}, {"50%", 0.5}, {"25%", 0.25},
+		},
+		custom: []custom[float64]{{
+			Stepper: Stepper{
+				Title:     "Custom brightness",
+				Min:       MinBrightness * 100,
+				Max:       100,
+				Increment: func(float64) float64 { return 5 },
+				Format:    func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+			},
+			from: func(v float64) (float64, bool) { return math.Round(v * 100), true },
+			to:   func(v float64) float64 { return v / 100 },
+		}},
+		fallback: 1.0,
+		field:    func(s *Settings) *float64 { return &s.Brightness },
+		valid:    func(v float64) bool { return v >= MinBrightness && v <= 1 },
+		format:   func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+	},
+	&choiceSetting[string]{
+		key:   "updateChannel",
+		label: "Update Channel",
+		choices: []Choice[string]{
+			{"Stable", "stable"},
+			{"Beta", "beta"},
+			{"Development", "dev"},
+		},
+		fallback: "stable",
+		field:    func(s *Settings) *string { return &s.UpdateChannel },
+	},
+}
+
+// MinBrightness keeps the picture from being dimmed to black
+const MinBrightness = 0.05
+
+// Playback speed range accepted from the stepper and settings files
+const (
+	MinPlaybackSpeed = 0.05
+	MaxPlaybackSpeed = 4.0
+)
+
+// MaxAvoidRepeat bounds the no-repeat window; the playlist shrinks it further
+// for collections with fewer videos
+const MaxAvoidRepeat = 50
+
+// formatAvoidRepeat labels a no-repeat window, e.g. "Off" or "Last 7 videos"
+func formatAvoidRepeat(v int) string {
+	switch v {
+	case 0:
+		return "Off"
+	case 1:
+		return "Last video"
+	default:
+		return fmt.Sprintf("Last %d videos", v)
+	}
+}
+
+// formatSpeed labels a playback speed multiplier, e.g. "0.25x" or "2x"
+func formatSpeed(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + "x"
+}
+
+// minuteIncrement grows the interval step with its size: minutes up to an
+// hour, quarter hours up to four hours, then hours
+func minuteIncrement(v float64) float64 {
+	switch {
+	case v < 10:
+		return 1
+	case v < 60:
+		return 5
+	case v < 4*60:
+		return 15
+	case v < 24*60:
+		return 60
+	default:
+		return 6 * 60
+	}
+}
+
+// Lookup returns the registered setting with the given persistence key
+func Lookup(key string) (Setting, bool) {
+	for _, setting := range Registry {
+		if setting.Key() == key {
+			return setting, true
+		}
+	}
+	return nil, false
+}
+
+// SettingForMenu returns the registered setting whose choice menu is menu
+func SettingForMenu(menu MenuType) (Setting, bool) {
+	if !strings.HasPrefix(string(menu), "setting:") {
+		return nil, false
+	}
+	return Lookup(strings.TrimPrefix(string(menu), "setting:"))
+}
+
+// Defaults returns the settings with every registered default applied
+func Defaults() Settings {
+	s := Settings{SchemaVersion: CurrentSchemaVersion}
+	for _, setting := range Registry {
+		setting.applyDefault(&s)
+	}
+	return s
+}
+
+// Validate resets every registered setting with an invalid value to its
+// default and reports whether anything changed
+func Validate(s *Settings) bool {
+	changed := false
+	for _, setting := range Registry {
+		if setting.Validate(s) {
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,59 @@
+package settings
+
+import (
+	"flow-frame/ui"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// StartStepper shows the numeric stepper for a custom value, starting at value
+func (w *Widget) StartStepper(stepper Stepper, value float64) {
+	w.stepper = stepper
+	w.stepperValue = value
+}
+
+// StepStepper moves the stepper value by one increment in direction dir
+func (w *Widget) StepStepper(dir int) {
+	w.stepperValue = w.stepper.Step(w.stepperValue, dir)
+}
+
+// StepperValue returns the value currently shown by the stepper
+func (w *Widget) StepperValue() float64 {
+	return w.stepperValue
+}
+
+// DrawStepper renders the numeric stepper screen
+func (w *Widget) DrawStepper(renderer *sdl.Renderer, x, y, width, height int32, largeFont, mediumFont, smallFont *ttf.Font) error {
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	gray := sdl.Color{R: 148, G: 163, B: 184, A: 255}
+
+	// Title
+	if largeFont != nil {
+		ui.RenderText(renderer, w.stepper.Title, x+40, y+20, white, largeFont)
+	}
+
+	// Value box with arrows either side
+	boxY := y + 100
+	boxHeight := int32(80)
+	renderer.SetDrawColor(51, 65, 85, 255)
+	renderer.FillRect(&sdl.Rect{X: x + 20, Y: boxY, W: width - 40, H: boxHeight})
+
+	if mediumFont != nil {
+		ui.RenderText(renderer, "<", x+40, boxY+25, gray, mediumFont)
+		ui.RenderText(renderer, ">", x+width-60, boxY+25, gray, mediumFont)
+
+		value := w.stepper.Format(w.stepperValue)
+		if tw, _, err := mediumFont.SizeUTF8(value); err == nil {
+			ui.RenderText(renderer, value, x+(width-int32(tw))/2, boxY+25, white, mediumFont)
+		} else {
+			ui.RenderText(renderer, value, x+80, boxY+25, white, mediumFont)
+		}
+	}
+
+	if smallFont != nil {
+		ui.RenderText(renderer, "Left/Right to adjust, Enter to save, Esc to cancel", x+40, boxY+boxHeight+20, gray, smallFont)
+	}
+
+	return nil
+}
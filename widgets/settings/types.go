@@ -1,17 +1,39 @@
 package settings
 
+import "flow-frame/pkg/sharedTypes"
+
 // Settings represents user-tunable configuration that should persist across
 // application restarts. Add additional fields here as new settings are
 // introduced.
 type Settings struct {
-	PlaybackSpeed    float64 `json:"playbackSpeed"`
-	PlaybackInterval string  `json:"playbackInterval"`
+	SchemaVersion    int                  `json:"schemaVersion"` // see CurrentSchemaVersion
+	PlaybackSpeed    float64              `json:"playbackSpeed"`
+	PlaybackInterval sharedTypes.Interval `json:"playbackInterval"`
+	PlaybackOrder    string               `json:"playbackOrder"`
+	Brightness       float64              `json:"brightness"`    // 0.05-1, applied by dimming the picture
+	AvoidRepeat      int                  `json:"avoidRepeat"`   // do not repeat any of the last N videos
+	ShuffleSeed      int64                `json:"shuffleSeed"`   // generated once so shuffle order survives restarts
+	UpdateChannel    string               `json:"updateChannel"` // release channel followed by the updater
 }
 
+// ItemAction identifies what selecting a menu item does beyond its menu-specific meaning
+type ItemAction int
+
+const (
+	ActionNone        ItemAction = iota // handled by the current menu (e.g. a choice or network)
+	ActionBack                          // return to the parent menu
+	ActionInfo                          // informational row, selecting it does nothing
+	ActionRestart                       // check for updates, then restart the service
+	ActionStepper                       // enter a custom value with the numeric stepper
+	ActionDiagnostics                   // write a diagnostics bundle
+)
+
 // Item represents a settings menu item
 type Item struct {
-	Title string
-	Value string
+	Title  string
+	Value  string
+	Menu   MenuType // submenu opened when selected, if any
+	Action ItemAction
 }
 
 // MenuType represents the type of settings menu being displayed
@@ -19,9 +41,9 @@ type MenuType string
 
 const (
 	MainMenu         MenuType = "main"
-	SpeedMenu        MenuType = "speed"
-	IntervalMenu     MenuType = "interval"
 	SystemMenu       MenuType = "system"
 	WiFiMenu         MenuType = "wifi"
 	WiFiPasswordMenu MenuType = "wifi_password"
+	AboutMenu        MenuType = "about"   // version and device information
+	StepperMenu      MenuType = "stepper" // numeric entry of a custom setting value
 )
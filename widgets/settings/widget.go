@@ -18,6 +18,8 @@ type Widget struct {
 	gridCol          int
 	connectionStatus string
 	statusMessage    string
+	stepper          Stepper // custom value editor shown in StepperMenu
+	stepperValue     float64
 }
 
 // NewWidget creates a new settings widget